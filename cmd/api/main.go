@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -11,16 +12,24 @@ import (
 	"syscall"
 	"time"
 
+	auditpostgres "github.com/dejobratic/tbd/internal/audit/postgres"
+	"github.com/dejobratic/tbd/internal/auth"
+	"github.com/dejobratic/tbd/internal/cache"
 	"github.com/dejobratic/tbd/internal/config"
 	"github.com/dejobratic/tbd/internal/database"
+	"github.com/dejobratic/tbd/internal/events"
 	idempostgres "github.com/dejobratic/tbd/internal/idempotency/postgres"
 	kafkapkg "github.com/dejobratic/tbd/internal/kafka"
 	ordersadapters "github.com/dejobratic/tbd/internal/orders/adapters"
 	httpadapter "github.com/dejobratic/tbd/internal/orders/adapters/http"
 	orderspostgres "github.com/dejobratic/tbd/internal/orders/adapters/postgres"
 	ordersapp "github.com/dejobratic/tbd/internal/orders/app"
+	orderscommands "github.com/dejobratic/tbd/internal/orders/app/commands"
 	ordersmetrics "github.com/dejobratic/tbd/internal/orders/metrics"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/dejobratic/tbd/internal/retry"
 	"github.com/dejobratic/tbd/internal/telemetry"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
@@ -33,29 +42,31 @@ func main() {
 	}
 
 	logLevel := parseLogLevel(cfg.Telemetry.LogLevel)
-	logger := telemetry.NewLogger(logLevel)
+	logger := telemetry.NewLogger(logLevel, cfg.Telemetry.LogFormat,
+		telemetry.WithBaggageKeys(cfg.Telemetry.LogBaggage...),
+		telemetry.WithSource(cfg.Telemetry.LogSource),
+	)
 	slog.SetDefault(logger)
 
 	tel, err := telemetry.Initialize(ctx, telemetry.Config{
-		ServiceName:     cfg.Service.Name,
-		ServiceVersion:  cfg.Service.Version,
-		Environment:     cfg.Service.Environment,
-		OTLPEndpoint:    cfg.Telemetry.OTelEndpoint,
-		EnableTracing:   cfg.Telemetry.EnableTracing,
-		EnableMetrics:   cfg.Telemetry.EnableMetrics,
-		SampleRate:      cfg.Telemetry.SampleRate,
-	})
+		ServiceName:        cfg.Service.Name,
+		ServiceVersion:     cfg.Service.Version,
+		Environment:        cfg.Service.Environment,
+		OTLPEndpoint:       cfg.Telemetry.OTelEndpoint,
+		EnableTracing:      cfg.Telemetry.EnableTracing,
+		EnableMetrics:      cfg.Telemetry.EnableMetrics,
+		SampleRate:         cfg.Telemetry.SampleRate,
+		ResourceAttributes: cfg.Telemetry.ResourceAttrs,
+	},
+		telemetry.WithLenientMetrics(logger),
+		telemetry.WithOTLPHeaders(cfg.Telemetry.OTLPHeaders),
+		telemetry.WithBatchOptions(cfg.Telemetry.BatchMaxQueueSize, cfg.Telemetry.BatchMaxExportSize, cfg.Telemetry.BatchTimeout),
+		telemetry.WithSpanBaggageKeys(spanBaggageKeys(cfg.HTTP)...),
+	)
 	if err != nil {
 		logger.Error("failed to initialize telemetry", "error", err)
 		os.Exit(1)
 	}
-	defer func() {
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := tel.Shutdown(shutdownCtx); err != nil {
-			logger.Error("failed to shutdown telemetry", "error", err)
-		}
-	}()
 
 	logger.Info("telemetry initialized",
 		"service", cfg.Service.Name,
@@ -67,12 +78,24 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	pool, err := database.NewPool(ctx, cfg.Database.URL)
+	poolOptions := []database.PoolOption{
+		database.WithQueryExecMode(cfg.Database.QueryExecMode),
+		database.WithStatementCacheCapacity(cfg.Database.StatementCacheCapacity),
+	}
+
+	connectRetry := retry.Policy{
+		MaxAttempts:    cfg.Database.ConnectAttempts,
+		InitialBackoff: cfg.Database.ConnectBackoff,
+		MaxBackoff:     10 * time.Second,
+		Jitter:         0.2,
+	}
+
+	pool, err := database.NewPoolWithRetry(ctx, cfg.Database.URL, connectRetry, poolOptions...)
 	if err != nil {
 		logger.Error("failed to create database pool", "error", err)
 		os.Exit(1)
 	}
-	defer pool.Close()
+	pools := []*pgxpool.Pool{pool}
 
 	if cfg.Database.AutoMigrate {
 		logger.Info("running database migrations", "path", cfg.Database.MigrationsPath)
@@ -83,71 +106,211 @@ func main() {
 		logger.Info("migrations completed successfully")
 	}
 
-	meter := tel.MeterProvider().Meter("tbd-api")
+	if err := database.CheckSchemaVersion(ctx, pool); err != nil {
+		logger.Error("refusing to start: database schema does not match this build",
+			"error", err,
+			"required_version", database.RequiredSchemaVersion,
+		)
+		os.Exit(1)
+	}
 
-	dbMetrics, err := database.NewMetrics(meter)
+	// Each subsystem records under its own instrumentation scope, matching its spans.
+	dbMetrics, err := database.NewMetrics(tel.Meter(database.ScopeName))
 	if err != nil {
 		logger.Error("failed to initialize database metrics", "error", err)
 		os.Exit(1)
 	}
 
-	kafkaMetrics, err := kafkapkg.NewMetrics(meter)
+	kafkaMetrics, err := kafkapkg.NewMetrics(tel.Meter(kafkapkg.ScopeName))
 	if err != nil {
 		logger.Error("failed to initialize kafka metrics", "error", err)
 		os.Exit(1)
 	}
 
-	httpMetrics, err := httpadapter.NewMetrics(meter)
+	httpMetrics, err := httpadapter.NewMetrics(tel.Meter(httpadapter.ScopeName))
 	if err != nil {
 		logger.Error("failed to initialize http metrics", "error", err)
 		os.Exit(1)
 	}
 
-	businessMetrics, err := ordersmetrics.NewMetrics(meter)
+	businessMetrics, err := ordersmetrics.NewMetrics(tel.Meter(ordersmetrics.ScopeName))
 	if err != nil {
 		logger.Error("failed to initialize business metrics", "error", err)
 		os.Exit(1)
 	}
 
-	baseRepo := orderspostgres.NewRepository(pool)
-	repo := ordersadapters.NewObservableRepository(baseRepo, dbMetrics)
+	if failed := tel.FailedInstruments(); len(failed) > 0 {
+		logger.Warn("some metric instruments failed to register", "instruments", failed)
+	}
 
-	idemStore := idempostgres.NewStore(pool)
+	if err := database.ValidateIdentifier(cfg.Database.Schema); err != nil {
+		logger.Error("invalid DB_SCHEMA", "error", err)
+		os.Exit(1)
+	}
+	schema := orderspostgres.WithSchema(cfg.Database.Schema)
+	statementTracing := orderspostgres.WithStatementAttributes(cfg.Database.TraceStatements)
+	maxRows := orderspostgres.WithMaxRows(cfg.Database.MaxListRows)
+	repoLogger := orderspostgres.WithLogger(logger)
+	baseRepo := ordersadapters.NewTimeoutRepository(orderspostgres.NewRepository(pool, schema, statementTracing, maxRows, repoLogger), cfg.Database.QueryTimeout)
+	auditLog := auditpostgres.NewStore(pool, auditpostgres.WithSchema(cfg.Database.Schema))
+	var repo ports.OrderRepository = ordersadapters.NewObservableRepository(baseRepo, dbMetrics)
+
+	if cfg.Database.ReplicaURL != "" {
+		replicaPool, err := database.NewPoolWithRetry(ctx, cfg.Database.ReplicaURL, connectRetry, poolOptions...)
+		if err != nil {
+			logger.Error("failed to create replica database pool", "error", err)
+			os.Exit(1)
+		}
+		pools = append(pools, replicaPool)
+
+		replicaRepo := ordersadapters.NewObservableRepository(
+			ordersadapters.NewTimeoutRepository(orderspostgres.NewRepository(replicaPool, schema, statementTracing, maxRows, repoLogger), cfg.Database.QueryTimeout),
+			dbMetrics,
+		)
+		repo = ordersadapters.NewReadWriteRepository(repo, replicaRepo)
+		logger.Info("read replica enabled for order queries")
+	}
+
+	if cfg.Orders.CacheEnabled {
+		cacheMetrics, err := cache.NewMetrics(tel.Meter(cache.ScopeName))
+		if err != nil {
+			logger.Error("failed to initialize cache metrics", "error", err)
+			os.Exit(1)
+		}
+		repo = ordersadapters.NewCachedRepository(repo, cfg.Orders.CacheSize, cfg.Orders.CacheTTL, cacheMetrics)
+		logger.Info("order cache enabled", "size", cfg.Orders.CacheSize, "ttl", cfg.Orders.CacheTTL)
+	}
+
+	idemStore := idempostgres.NewStore(pool, idempostgres.WithSchema(cfg.Database.Schema))
 
 	baseEventBus := kafkapkg.NewNoopEventBus()
-	eventBus := ordersadapters.NewObservableEventBus(baseEventBus, kafkaMetrics)
+	dispatcher := events.NewDispatcher()
+	topics := kafkapkg.Topics{
+		OrderCreated:   cfg.Kafka.TopicOrderCreated,
+		OrderProcessed: cfg.Kafka.TopicOrderProcessed,
+		OrderFailed:    cfg.Kafka.TopicOrderFailed,
+	}
+	eventBus := ordersadapters.NewObservableEventBus(
+		ordersadapters.NewDispatchingEventBus(baseEventBus, dispatcher),
+		kafkaMetrics,
+		ordersadapters.WithEventTopics(topics),
+	)
 
-	service := ordersapp.NewService(repo, eventBus, idemStore, logger, businessMetrics)
-	ordersHandler := httpadapter.NewHandler(service)
+	orderIDs, err := orderscommands.NewIDGenerator(cfg.Orders.IDStrategy)
+	if err != nil {
+		logger.Error("invalid ORDER_ID_STRATEGY", "error", err)
+		os.Exit(1)
+	}
+
+	service := ordersapp.NewService(repo, eventBus, idemStore, logger, businessMetrics,
+		ordersapp.WithCreateOrderOptions(
+			orderscommands.WithBlockedEmailDomains(cfg.Orders.BlockedEmailDomains),
+			orderscommands.WithReferenceSequence(orderspostgres.NewReferenceSequence(pool, schema)),
+			orderscommands.WithAllowZeroAmount(cfg.Orders.AllowZeroAmount),
+			orderscommands.WithIDGenerator(orderIDs),
+			orderscommands.WithMaxEmailLength(cfg.Orders.MaxEmailLength),
+		),
+		ordersapp.WithAuditLog(auditLog),
+		ordersapp.WithPageLimits(ports.PageLimits{
+			DefaultSize: cfg.Orders.DefaultPageSize,
+			MaxSize:     cfg.Orders.MaxPageSize,
+		}),
+	)
+	for route := range cfg.Auth.RouteScopes {
+		if !httpadapter.KnownRoute(route) {
+			logger.Error("unknown route in AUTH_ROUTE_SCOPES", "route", route)
+			os.Exit(1)
+		}
+	}
+
+	handlerOptions := []httpadapter.Option{
+		httpadapter.WithIdempotencyHeader(cfg.HTTP.IdempotencyHeader),
+		httpadapter.WithIdempotencyHeaderAliases(cfg.HTTP.IdempotencyHeaderAliases...),
+		httpadapter.WithUUIDIdempotencyKeys(cfg.HTTP.IdempotencyKeyRequireUUID),
+		httpadapter.WithAdminToken(cfg.HTTP.AdminToken),
+		httpadapter.WithReprocessLimits(cfg.Orders.ReprocessBatchSize, cfg.Orders.ReprocessLimit),
+		httpadapter.WithRouteScopes(cfg.Auth.RouteScopes),
+		httpadapter.WithCreateRetryAfter(cfg.HTTP.CreateRetryAfter),
+		httpadapter.WithCreateStatus(cfg.Orders.CreateStatus),
+		httpadapter.WithMaxCreateBodyBytes(cfg.HTTP.MaxCreateBodyBytes),
+		httpadapter.WithCreateRejectionMetrics(httpMetrics),
+		httpadapter.WithDebugErrors(cfg.HTTP.DebugErrors),
+		httpadapter.WithGoneForTerminalOrders(cfg.HTTP.GoneForTerminalOrders),
+	}
+	if cfg.HTTP.IdempotencyFailOpen {
+		handlerOptions = append(handlerOptions, httpadapter.WithIdempotencyFailOpen(logger, httpMetrics))
+		logger.Warn("idempotency fail-open enabled; store outages may allow duplicate orders")
+	}
+	if cfg.HTTP.DebugErrors {
+		logger.Warn("debug errors enabled; 5xx responses include internal error details")
+	}
+	repanic := cfg.HTTP.RepanicOnPanic
+	if repanic && cfg.Service.Environment == "production" {
+		logger.Warn("HTTP_REPANIC ignored in production; panics are recovered as 500 responses")
+		repanic = false
+	}
+
+	ordersHandler := httpadapter.NewHandler(service, handlerOptions...)
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+	mux.Handle("/healthz", httpadapter.WithRouteTemplate("/healthz", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
-	})
-	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		if err := database.CheckHealth(r.Context(), pool); err != nil {
-			respondJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready", "error": err.Error()})
-			return
-		}
-		respondJSON(w, http.StatusOK, map[string]string{"status": "ready"})
-	})
-	mux.HandleFunc(cfg.HTTP.MetricsPath, func(w http.ResponseWriter, _ *http.Request) {
+	})))
+	mux.Handle("/readyz", httpadapter.WithRouteTemplate("/readyz", readinessHandler(pool, eventBus)))
+	mux.Handle(cfg.HTTP.MetricsPath, httpadapter.WithRouteTemplate(cfg.HTTP.MetricsPath, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("# Metrics are exposed via OpenTelemetry to the configured OTLP endpoint\n"))
-	})
+	})))
 
 	ordersHandler.Register(mux)
 
-	handler := withRecovery(withLogging(httpadapter.WithMetrics(mux, httpMetrics)))
+	maxConcurrency := cfg.HTTP.MaxConcurrency
+	if maxConcurrency == 0 {
+		maxConcurrency = int(pool.Config().MaxConns) * concurrencyPerConnection
+	}
+
+	var handler http.Handler = mux
+	if cfg.HTTP.TenantHeader != "" || cfg.HTTP.TenantClaim != "" {
+		handler = httpadapter.WithTenant(handler,
+			httpadapter.WithTenantHeader(cfg.HTTP.TenantHeader),
+			httpadapter.WithTenantClaim(cfg.HTTP.TenantClaim),
+		)
+	}
+	if cfg.Auth.Enabled() {
+		handler = auth.RequireJWT(newVerifier(ctx, cfg.Auth), handler, "/healthz", "/readyz", cfg.HTTP.MetricsPath, "/admin/")
+		logger.Info("jwt authentication enabled", "jwks", cfg.Auth.JWKSURL != "")
+	}
+
+	if cfg.HTTP.Compression {
+		handler = httpadapter.WithCompression(handler)
+	}
+	handler = httpadapter.WithCORS(handler, httpadapter.CORSConfig{
+		AllowedOrigins:   cfg.HTTP.CORSAllowedOrigins,
+		AllowCredentials: cfg.HTTP.CORSAllowCredentials,
+		AllowedHeaders:   append([]string{"Authorization", "Content-Type", cfg.HTTP.IdempotencyHeader}, cfg.HTTP.IdempotencyHeaderAliases...),
+	})
+	handler = httpadapter.WithMaxConcurrency(handler, maxConcurrency, httpadapter.WithRejectionMetrics(httpMetrics))
+	handler = httpadapter.WithMetrics(handler, httpMetrics, httpadapter.WithMetricsExclusions(cfg.HTTP.InstrumentationExclusions...))
+	handler = httpadapter.WithLogging(handler, logger,
+		httpadapter.WithSlowRequestThreshold(cfg.HTTP.SlowRequestThreshold),
+		httpadapter.WithLoggingExclusions(cfg.HTTP.InstrumentationExclusions...),
+	)
+	// Recovery runs inside the server span so a panic's 500 is traced and carries its trace ID.
+	handler = httpadapter.WithRecovery(handler, logger,
+		httpadapter.WithPanicMetrics(httpMetrics),
+		httpadapter.WithRepanic(repanic),
+	)
+	handler = httpadapter.WithTracing(handler, httpadapter.WithTraceContextReporting(logger, httpMetrics))
+	handler = httpadapter.WithWriteTimeouts(handler, cfg.HTTP.WriteTimeoutOverrides)
 
 	srv := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.HTTP.Port),
 		Handler:           handler,
-		ReadHeaderTimeout: 10 * time.Second,
-		ReadTimeout:       15 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		IdleTimeout:       60 * time.Second,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
 	}
 
 	go func() {
@@ -162,42 +325,116 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.HTTP.ShutdownGrace)*time.Second)
 	defer cancel()
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		logger.Error("graceful shutdown failed", "error", err)
-	} else {
-		logger.Info("http server stopped")
-	}
+	// Components stop in dependency order: stop accepting requests first so nothing new
+	// reaches the database, flush telemetry while the pools can still be observed, and
+	// close the pools last. There is no outbox relay yet; its drain belongs after the server.
+	shutdown(shutdownCtx, logger, []shutdownStep{
+		{name: "http server", stop: srv.Shutdown},
+		{name: "telemetry", stop: tel.Shutdown},
+		{name: "database pools", stop: func(context.Context) error {
+			for _, p := range pools {
+				p.Close()
+			}
+			return nil
+		}},
+	})
 }
 
-func withLogging(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+// shutdownStep stops one component within the shared shutdown budget.
+type shutdownStep struct {
+	name string
+	stop func(context.Context) error
+}
+
+// shutdown runs steps in order, logging each one. A failed or timed-out step is logged and
+// the remaining steps still run, so a stuck server cannot keep the pools open.
+func shutdown(ctx context.Context, logger *slog.Logger, steps []shutdownStep) {
+	logger.Info("shutting down")
+	for _, step := range steps {
 		start := time.Now()
-		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
-		next.ServeHTTP(rw, r)
-		slog.Info("http request", "method", r.Method, "path", r.URL.Path, "status", rw.status, "duration", time.Since(start))
-	})
+		if err := step.stop(ctx); err != nil {
+			logger.Error("shutdown step failed", "step", step.name, "error", err, "duration", time.Since(start))
+			continue
+		}
+		logger.Info("shutdown step completed", "step", step.name, "duration", time.Since(start))
+	}
+	logger.Info("shutdown complete")
 }
 
-func withRecovery(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if rec := recover(); rec != nil {
-				slog.Error("panic recovered", "error", rec)
-				respondJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
-			}
-		}()
-		next.ServeHTTP(w, r)
-	})
+// newVerifier builds a JWT verifier accepting whichever of HS256 and RS256 is configured.
+// Admin endpoints are exempt from it because they authenticate with ADMIN_API_TOKEN. A JWKS
+// key set is refreshed in the background until ctx is done.
+func newVerifier(ctx context.Context, cfg config.AuthConfig) *auth.Verifier {
+	opts := []auth.Option{auth.WithAudience(cfg.Audience), auth.WithIssuer(cfg.Issuer)}
+	if cfg.JWTSecret != "" {
+		opts = append(opts, auth.WithSecret([]byte(cfg.JWTSecret)))
+	}
+	if cfg.JWKSURL != "" {
+		jwks := auth.NewJWKS(cfg.JWKSURL, cfg.JWKSRefreshInterval, nil)
+		go jwks.Run(ctx)
+		opts = append(opts, auth.WithKeySet(jwks))
+	}
+	return auth.NewVerifier(opts...)
 }
 
-type responseWriter struct {
-	http.ResponseWriter
-	status int
+// concurrencyPerConnection sizes the default HTTP concurrency limit relative to the database
+// pool, leaving headroom for requests that never reach the database.
+const concurrencyPerConnection = 4
+
+// spanBaggageKeys lists the baggage members copied onto every span: the tenant ID when
+// tenant tagging is configured.
+func spanBaggageKeys(cfg config.HTTPConfig) []string {
+	if cfg.TenantHeader == "" && cfg.TenantClaim == "" {
+		return nil
+	}
+	return []string{httpadapter.TenantAttributeKey}
 }
 
-func (w *responseWriter) WriteHeader(status int) {
-	w.status = status
-	w.ResponseWriter.WriteHeader(status)
+// readinessHandler reports ready only when the database answers, its schema is at the
+// version this build requires, and the event bus is healthy; each check's result is
+// included in the body.
+func readinessHandler(pool *pgxpool.Pool, eventBus ports.EventBus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ready := true
+		checks := map[string]any{}
+
+		if err := database.CheckHealth(r.Context(), pool); err != nil {
+			ready = false
+			checks["database"] = map[string]string{"status": "failed", "error": err.Error()}
+		} else {
+			checks["database"] = map[string]string{"status": "ok"}
+		}
+
+		schema := map[string]any{"expected_version": database.RequiredSchemaVersion}
+		var versionErr *database.SchemaVersionError
+		switch err := database.CheckSchemaVersion(r.Context(), pool); {
+		case errors.As(err, &versionErr):
+			ready = false
+			schema["status"] = "outdated"
+			schema["version"] = versionErr.Actual
+			schema["dirty"] = versionErr.Dirty
+		case err != nil:
+			ready = false
+			schema["status"] = "failed"
+			schema["error"] = err.Error()
+		default:
+			schema["status"] = "ok"
+		}
+		checks["schema"] = schema
+
+		if err := eventBus.HealthCheck(r.Context()); err != nil {
+			ready = false
+			checks["event_bus"] = map[string]string{"status": "failed", "error": err.Error()}
+		} else {
+			checks["event_bus"] = map[string]string{"status": "ok"}
+		}
+
+		if !ready {
+			respondJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "not ready", "checks": checks})
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]any{"status": "ready", "checks": checks})
+	}
 }
 
 func respondJSON(w http.ResponseWriter, status int, payload any) {
@@ -0,0 +1,83 @@
+// Package audit keeps an append-only record of who changed which order, and how.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/auth"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/telemetry"
+)
+
+// Recorded actions.
+const (
+	ActionCreate       = "create"
+	ActionCancel       = "cancel"
+	ActionStatusChange = "status_change"
+)
+
+// AnonymousActor is recorded when a change is made without an authenticated caller.
+const AnonymousActor = "anonymous"
+
+// Entry is one audited change to an order. FromStatus is empty for creations.
+type Entry struct {
+	OrderID    string             `json:"order_id"`
+	Action     string             `json:"action"`
+	Actor      string             `json:"actor"`
+	FromStatus domain.OrderStatus `json:"from_status,omitempty"`
+	ToStatus   domain.OrderStatus `json:"to_status,omitempty"`
+	TraceID    string             `json:"trace_id,omitempty"`
+	OccurredAt time.Time          `json:"occurred_at"`
+}
+
+// Log stores audit entries. Entries are never updated or deleted.
+type Log interface {
+	Record(ctx context.Context, entry Entry) error
+	// ListByOrder returns the order's entries, oldest first.
+	ListByOrder(ctx context.Context, orderID string) ([]Entry, error)
+}
+
+// NewEntry builds an entry for action on orderID, taking the actor and trace ID from ctx.
+func NewEntry(ctx context.Context, orderID, action string, from, to domain.OrderStatus) Entry {
+	return Entry{
+		OrderID:    orderID,
+		Action:     action,
+		Actor:      Actor(ctx),
+		FromStatus: from,
+		ToStatus:   to,
+		TraceID:    telemetry.TraceID(ctx),
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// Recorder records order creations and status changes to a Log. It implements
+// ports.TransitionRecorder.
+type Recorder struct {
+	log Log
+}
+
+func NewRecorder(log Log) Recorder {
+	return Recorder{log: log}
+}
+
+// RecordTransition records the change of orderID from one status to another. An empty from
+// is a creation, and a move to canceled a cancel.
+func (r Recorder) RecordTransition(ctx context.Context, orderID string, from, to domain.OrderStatus) error {
+	action := ActionStatusChange
+	switch {
+	case from == "":
+		action = ActionCreate
+	case to == domain.StatusCanceled:
+		action = ActionCancel
+	}
+	return r.log.Record(ctx, NewEntry(ctx, orderID, action, from, to))
+}
+
+// Actor returns the JWT subject of the caller, or AnonymousActor without one.
+func Actor(ctx context.Context) string {
+	if claims, ok := auth.ClaimsFromContext(ctx); ok && claims.Subject != "" {
+		return claims.Subject
+	}
+	return AnonymousActor
+}
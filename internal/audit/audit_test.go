@@ -0,0 +1,67 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/audit"
+	"github.com/dejobratic/tbd/internal/audit/memory"
+	"github.com/dejobratic/tbd/internal/auth"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+)
+
+func TestNewEntry(t *testing.T) {
+	t.Run("records the authenticated subject as actor", func(t *testing.T) {
+		ctx := auth.ContextWithClaims(context.Background(), &auth.Claims{Subject: "user-42"})
+
+		entry := audit.NewEntry(ctx, "order-1", audit.ActionCancel, domain.StatusPending, domain.StatusCanceled)
+
+		if entry.Actor != "user-42" {
+			t.Errorf("expected actor user-42, got %q", entry.Actor)
+		}
+		if entry.FromStatus != domain.StatusPending || entry.ToStatus != domain.StatusCanceled {
+			t.Errorf("expected pending -> canceled, got %s -> %s", entry.FromStatus, entry.ToStatus)
+		}
+		if entry.OccurredAt.IsZero() {
+			t.Error("expected occurred_at to be set")
+		}
+	})
+
+	t.Run("records anonymous changes", func(t *testing.T) {
+		entry := audit.NewEntry(context.Background(), "order-1", audit.ActionCreate, "", domain.StatusPending)
+
+		if entry.Actor != audit.AnonymousActor {
+			t.Errorf("expected anonymous actor, got %q", entry.Actor)
+		}
+	})
+}
+
+func TestRecorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to domain.OrderStatus
+		want     string
+	}{
+		{name: "records creations", to: domain.StatusPending, want: audit.ActionCreate},
+		{name: "records cancels", from: domain.StatusPending, to: domain.StatusCanceled, want: audit.ActionCancel},
+		{name: "records status changes", from: domain.StatusPending, to: domain.StatusCompleted, want: audit.ActionStatusChange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := memory.NewStore()
+
+			if err := audit.NewRecorder(log).RecordTransition(context.Background(), "order-1", tt.from, tt.to); err != nil {
+				t.Fatalf("failed to record transition: %v", err)
+			}
+
+			entries, err := log.ListByOrder(context.Background(), "order-1")
+			if err != nil {
+				t.Fatalf("failed to list entries: %v", err)
+			}
+			if len(entries) != 1 || entries[0].Action != tt.want || entries[0].FromStatus != tt.from || entries[0].ToStatus != tt.to {
+				t.Errorf("expected one %s entry %s -> %s, got %+v", tt.want, tt.from, tt.to, entries)
+			}
+		})
+	}
+}
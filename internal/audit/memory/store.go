@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dejobratic/tbd/internal/audit"
+)
+
+// Store keeps audit entries in memory in insertion order.
+type Store struct {
+	mu      sync.RWMutex
+	entries map[string][]audit.Entry
+}
+
+func NewStore() *Store {
+	return &Store{entries: make(map[string][]audit.Entry)}
+}
+
+func (s *Store) Record(_ context.Context, entry audit.Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.OrderID] = append(s.entries[entry.OrderID], entry)
+	return nil
+}
+
+func (s *Store) ListByOrder(_ context.Context, orderID string) ([]audit.Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]audit.Entry{}, s.entries[orderID]...), nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dejobratic/tbd/internal/audit"
+	"github.com/dejobratic/tbd/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DefaultSchema is the schema holding audit_log unless WithSchema overrides it.
+const DefaultSchema = "public"
+
+// Store writes audit entries to the append-only audit_log table.
+type Store struct {
+	pool  *pgxpool.Pool
+	table string
+}
+
+// Option customizes a Store.
+type Option func(*Store)
+
+// WithSchema qualifies the audit_log table with schema. It panics if schema is not a plain
+// identifier; validate configured values with database.ValidateIdentifier first.
+func WithSchema(schema string) Option {
+	if err := database.ValidateIdentifier(schema); err != nil {
+		panic(fmt.Sprintf("postgres.WithSchema: %v", err))
+	}
+	return func(s *Store) {
+		s.table = pgx.Identifier{schema, "audit_log"}.Sanitize()
+	}
+}
+
+func NewStore(pool *pgxpool.Pool, opts ...Option) *Store {
+	s := &Store{pool: pool, table: pgx.Identifier{DefaultSchema, "audit_log"}.Sanitize()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Store) Record(ctx context.Context, entry audit.Entry) error {
+	query := `
+		INSERT INTO ` + s.table + ` (order_id, action, actor, from_status, to_status, trace_id, occurred_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7)
+	`
+
+	_, err := s.pool.Exec(ctx, query,
+		entry.OrderID,
+		entry.Action,
+		entry.Actor,
+		string(entry.FromStatus),
+		string(entry.ToStatus),
+		entry.TraceID,
+		entry.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) ListByOrder(ctx context.Context, orderID string) ([]audit.Entry, error) {
+	query := `
+		SELECT order_id, action, actor, COALESCE(from_status, ''), COALESCE(to_status, ''), COALESCE(trace_id, ''), occurred_at
+		FROM ` + s.table + `
+		WHERE order_id = $1
+		ORDER BY occurred_at, id
+	`
+
+	rows, err := s.pool.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []audit.Entry{}
+	for rows.Next() {
+		var entry audit.Entry
+		if err := rows.Scan(
+			&entry.OrderID,
+			&entry.Action,
+			&entry.Actor,
+			&entry.FromStatus,
+			&entry.ToStatus,
+			&entry.TraceID,
+			&entry.OccurredAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate audit entries: %w", err)
+	}
+
+	return entries, nil
+}
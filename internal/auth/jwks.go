@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minJWKSRefetch limits how often an unknown key ID or a failed fetch forces a refetch, so
+// tokens with bogus key IDs or an IdP outage cannot hammer the IdP.
+const minJWKSRefetch = 30 * time.Second
+
+// JWKS fetches RSA signing keys from a JSON Web Key Set URL and caches them, refreshing
+// after refreshInterval or when a token names a key it has not seen. Fetches run outside
+// the cache lock and are shared by concurrent callers; a stale but cached key is served
+// while its refresh runs in the background.
+type JWKS struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	failedAt  time.Time
+	failure   error
+	inflight  *jwksFetch
+	now       func() time.Time
+}
+
+// jwksFetch is a fetch in progress; done is closed once err is set.
+type jwksFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// NewJWKS returns a key set backed by url. Keys are fetched lazily on first use, and
+// periodically once Run is started.
+func NewJWKS(url string, refreshInterval time.Duration, client *http.Client) *JWKS {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &JWKS{
+		url:             url,
+		client:          client,
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+	}
+}
+
+// Run refreshes the key set every refresh interval until ctx is done, so requests rarely
+// find it stale. Failures are kept for Key to report; cached keys stay in use meanwhile.
+func (j *JWKS) Run(ctx context.Context) {
+	if j.refreshInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(j.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fetch := j.startFetch(ctx)
+			<-fetch.done
+		}
+	}
+}
+
+// Key returns the RSA public key for kid.
+func (j *JWKS) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	j.mu.Lock()
+	now := j.now()
+	age := now.Sub(j.fetchedAt)
+	key, known := j.keys[kid]
+	due := j.keys == nil || age > j.refreshInterval || (!known && age > minJWKSRefetch)
+	backoff := j.failure != nil && now.Sub(j.failedAt) < minJWKSRefetch
+	failure, empty := j.failure, j.keys == nil
+	j.mu.Unlock()
+
+	switch {
+	case known:
+		if due && !backoff {
+			// Keep serving the cached key while the refresh runs.
+			j.startFetch(context.WithoutCancel(ctx))
+		}
+		return key, nil
+	case backoff && empty:
+		return nil, failure
+	case due && !backoff:
+		fetch := j.startFetch(context.WithoutCancel(ctx))
+		select {
+		case <-fetch.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if fetch.err != nil {
+			return nil, fetch.err
+		}
+		j.mu.Lock()
+		key, known = j.keys[kid]
+		j.mu.Unlock()
+	}
+
+	if !known {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// startFetch returns the fetch in progress, starting one when there is none.
+func (j *JWKS) startFetch(ctx context.Context) *jwksFetch {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.inflight != nil {
+		return j.inflight
+	}
+	fetch := &jwksFetch{done: make(chan struct{})}
+	j.inflight = fetch
+
+	go func() {
+		keys, err := j.fetch(ctx)
+
+		j.mu.Lock()
+		if err != nil {
+			j.failure = err
+			j.failedAt = j.now()
+		} else {
+			j.keys = keys
+			j.fetchedAt = j.now()
+			j.failure = nil
+		}
+		j.inflight = nil
+		j.mu.Unlock()
+
+		fetch.err = err
+		close(fetch.done)
+	}()
+	return fetch
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// fetch downloads and parses the key set.
+func (j *JWKS) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build jwks request: %w", err)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := parseRSAKey(jwk)
+		if err != nil {
+			return nil, fmt.Errorf("parse jwk %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func parseRSAKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("exponent out of range")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
@@ -0,0 +1,136 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/auth"
+)
+
+// jwksServer publishes key as key-1. While outage is set, requests block until release is
+// closed and then fail.
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+	outage  atomic.Bool
+	release chan struct{}
+}
+
+func newJWKSServer(t *testing.T, key *rsa.PrivateKey, delay time.Duration) *jwksServer {
+	t.Helper()
+	s := &jwksServer{release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		time.Sleep(delay)
+		if s.outage.Load() {
+			<-s.release
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(func() {
+		close(s.release)
+		s.Close()
+	})
+	return s
+}
+
+func TestJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	t.Run("shares one fetch between concurrent callers", func(t *testing.T) {
+		server := newJWKSServer(t, key, 20*time.Millisecond)
+		jwks := auth.NewJWKS(server.URL, time.Hour, nil)
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := jwks.Key(context.Background(), "key-1"); err != nil {
+					t.Errorf("expected key, got %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := server.fetches.Load(); got != 1 {
+			t.Errorf("expected 1 jwks fetch, got %d", got)
+		}
+	})
+
+	t.Run("serves the cached key without blocking during an outage", func(t *testing.T) {
+		server := newJWKSServer(t, key, 0)
+		jwks := auth.NewJWKS(server.URL, time.Nanosecond, nil)
+		if _, err := jwks.Key(context.Background(), "key-1"); err != nil {
+			t.Fatalf("expected key, got %v", err)
+		}
+
+		server.outage.Store(true)
+		start := time.Now()
+		for range 5 {
+			if _, err := jwks.Key(context.Background(), "key-1"); err != nil {
+				t.Fatalf("expected cached key, got %v", err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected cached keys without waiting on the IdP, took %v", elapsed)
+		}
+
+		server.release <- struct{}{}
+		waitFor(t, func() bool {
+			_, err := jwks.Key(context.Background(), "key-1")
+			return err == nil && server.fetches.Load() == 2
+		})
+		for range 5 {
+			if _, err := jwks.Key(context.Background(), "key-1"); err != nil {
+				t.Fatalf("expected cached key after a failed refresh, got %v", err)
+			}
+		}
+		if got := server.fetches.Load(); got != 2 {
+			t.Errorf("expected failed refresh to back off, got %d fetches", got)
+		}
+	})
+
+	t.Run("refreshes periodically once run", func(t *testing.T) {
+		server := newJWKSServer(t, key, 0)
+		jwks := auth.NewJWKS(server.URL, 10*time.Millisecond, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go jwks.Run(ctx)
+
+		waitFor(t, func() bool { return server.fetches.Load() >= 2 })
+	})
+}
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
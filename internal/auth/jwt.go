@@ -0,0 +1,216 @@
+// Package auth authenticates API callers with JWT bearer tokens.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// ErrInvalidToken matches every token verification failure.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the registered JWT claims the service relies on. Extra holds every claim of
+// the token, including custom ones.
+type Claims struct {
+	Subject   string         `json:"sub"`
+	Issuer    string         `json:"iss"`
+	Audience  Audience       `json:"aud"`
+	ExpiresAt int64          `json:"exp"`
+	NotBefore int64          `json:"nbf"`
+	IssuedAt  int64          `json:"iat"`
+	Extra     map[string]any `json:"-"`
+}
+
+// Audience is the aud claim, which may be a single string or a list.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("aud must be a string or list of strings: %w", err)
+	}
+	*a = list
+	return nil
+}
+
+// KeySet resolves the public key for a token's key ID.
+type KeySet interface {
+	Key(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
+
+// Option customizes a Verifier.
+type Option func(*Verifier)
+
+// WithSecret accepts HS256 tokens signed with secret.
+func WithSecret(secret []byte) Option {
+	return func(v *Verifier) {
+		v.secret = secret
+	}
+}
+
+// WithKeySet accepts RS256 tokens signed by a key in keys, typically a JWKS.
+func WithKeySet(keys KeySet) Option {
+	return func(v *Verifier) {
+		v.keys = keys
+	}
+}
+
+// WithAudience requires the aud claim to contain audience.
+func WithAudience(audience string) Option {
+	return func(v *Verifier) {
+		v.audience = audience
+	}
+}
+
+// WithIssuer requires the iss claim to equal issuer.
+func WithIssuer(issuer string) Option {
+	return func(v *Verifier) {
+		v.issuer = issuer
+	}
+}
+
+// WithLeeway tolerates clock skew when checking exp and nbf.
+func WithLeeway(leeway time.Duration) Option {
+	return func(v *Verifier) {
+		v.leeway = leeway
+	}
+}
+
+// Verifier checks JWT signatures and registered claims.
+type Verifier struct {
+	secret   []byte
+	keys     KeySet
+	audience string
+	issuer   string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewVerifier returns a verifier. At least one of WithSecret or WithKeySet must be given for
+// any token to verify.
+func NewVerifier(opts ...Option) *Verifier {
+	v := &Verifier{now: time.Now}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify parses token, checks its signature, expiry, audience and issuer, and returns its
+// claims. Every failure matches ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature encoding: %v", ErrInvalidToken, err)
+	}
+	if err := v.verifySignature(ctx, hdr, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if err := decodeSegment(parts[1], &claims.Extra); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (v *Verifier) verifySignature(ctx context.Context, hdr header, signingInput string, signature []byte) error {
+	switch hdr.Alg {
+	case AlgHS256:
+		if len(v.secret) == 0 {
+			return fmt.Errorf("%w: HS256 tokens are not accepted", ErrInvalidToken)
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	case AlgRS256:
+		if v.keys == nil {
+			return fmt.Errorf("%w: RS256 tokens are not accepted", ErrInvalidToken)
+		}
+		key, err := v.keys.Key(ctx, hdr.Kid)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidToken, err)
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, hdr.Alg)
+	}
+}
+
+func (v *Verifier) validateClaims(claims Claims) error {
+	now := v.now()
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(v.leeway)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	if claims.NotBefore != 0 && now.Before(time.Unix(claims.NotBefore, 0).Add(-v.leeway)) {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, dst any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
@@ -0,0 +1,173 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/auth"
+)
+
+var testSecret = []byte("test-secret")
+
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	t.Helper()
+	input := encodeSegment(t, map[string]string{"alg": auth.AlgHS256, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	input := encodeSegment(t, map[string]string{"alg": auth.AlgRS256, "typ": "JWT", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub": "user-1",
+		"iss": "https://idp.example.com",
+		"aud": "tbd-api",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifierHS256(t *testing.T) {
+	verifier := auth.NewVerifier(
+		auth.WithSecret(testSecret),
+		auth.WithAudience("tbd-api"),
+		auth.WithIssuer("https://idp.example.com"),
+	)
+
+	t.Run("accepts a valid token and exposes its claims", func(t *testing.T) {
+		claims := validClaims()
+		claims["scope"] = "orders:read"
+
+		got, err := verifier.Verify(context.Background(), signHS256(t, testSecret, claims))
+		if err != nil {
+			t.Fatalf("expected token to verify, got %v", err)
+		}
+		if got.Subject != "user-1" {
+			t.Errorf("expected subject user-1, got %q", got.Subject)
+		}
+		if got.Extra["scope"] != "orders:read" {
+			t.Errorf("expected custom claim scope, got %v", got.Extra["scope"])
+		}
+	})
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{"wrong secret", func() string { return signHS256(t, []byte("other"), validClaims()) }},
+		{"expired", func() string {
+			claims := validClaims()
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			return signHS256(t, testSecret, claims)
+		}},
+		{"missing exp", func() string {
+			claims := validClaims()
+			delete(claims, "exp")
+			return signHS256(t, testSecret, claims)
+		}},
+		{"not yet valid", func() string {
+			claims := validClaims()
+			claims["nbf"] = time.Now().Add(time.Hour).Unix()
+			return signHS256(t, testSecret, claims)
+		}},
+		{"wrong audience", func() string {
+			claims := validClaims()
+			claims["aud"] = []string{"other-api"}
+			return signHS256(t, testSecret, claims)
+		}},
+		{"wrong issuer", func() string {
+			claims := validClaims()
+			claims["iss"] = "https://evil.example.com"
+			return signHS256(t, testSecret, claims)
+		}},
+		{"malformed", func() string { return "not-a-jwt" }},
+	}
+
+	for _, tt := range tests {
+		t.Run("rejects "+tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), tt.token())
+			if !errors.Is(err, auth.ErrInvalidToken) {
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifierRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	verifier := auth.NewVerifier(auth.WithKeySet(auth.NewJWKS(server.URL, time.Hour, nil)))
+
+	t.Run("accepts tokens signed by a published key and caches the key set", func(t *testing.T) {
+		for range 3 {
+			if _, err := verifier.Verify(context.Background(), signRS256(t, key, "key-1", validClaims())); err != nil {
+				t.Fatalf("expected token to verify, got %v", err)
+			}
+		}
+		if got := fetches.Load(); got != 1 {
+			t.Errorf("expected 1 jwks fetch, got %d", got)
+		}
+	})
+
+	t.Run("rejects tokens with an unknown key id", func(t *testing.T) {
+		_, err := verifier.Verify(context.Background(), signRS256(t, key, "key-2", validClaims()))
+		if !errors.Is(err, auth.ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("rejects HS256 tokens when no secret is configured", func(t *testing.T) {
+		_, err := verifier.Verify(context.Background(), signHS256(t, testSecret, validClaims()))
+		if !errors.Is(err, auth.ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/auth"
+)
+
+func TestRequireJWT(t *testing.T) {
+	var gotSubject string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSubject = ""
+		if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+			gotSubject = claims.Subject
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := auth.RequireJWT(auth.NewVerifier(auth.WithSecret(testSecret)), next, "/healthz", "/admin/")
+
+	t.Run("stores claims in context for a valid token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+signHS256(t, testSecret, validClaims()))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		if gotSubject != "user-1" {
+			t.Errorf("expected subject user-1 in context, got %q", gotSubject)
+		}
+	})
+
+	t.Run("returns 401 without a token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rec.Code)
+		}
+		if rec.Header().Get("WWW-Authenticate") == "" {
+			t.Error("expected WWW-Authenticate header")
+		}
+	})
+
+	t.Run("returns 401 for an invalid token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+		req.Header.Set("Authorization", "Bearer "+signHS256(t, []byte("other"), validClaims()))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("lets exempt paths through unauthenticated", func(t *testing.T) {
+		for _, path := range []string{"/healthz", "/admin/orders/reprocess"} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("expected status 200 for %s, got %d", path, rec.Code)
+			}
+		}
+	})
+}
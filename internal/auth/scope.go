@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Scopes returns the scopes granted to the caller, read from the space-delimited OAuth
+// "scope" claim or the "scp" claim, which some providers send as a list.
+func (c *Claims) Scopes() []string {
+	var scopes []string
+	for _, name := range []string{"scope", "scp"} {
+		switch value := c.Extra[name].(type) {
+		case string:
+			scopes = append(scopes, strings.Fields(value)...)
+		case []any:
+			for _, item := range value {
+				if s, ok := item.(string); ok {
+					scopes = append(scopes, s)
+				}
+			}
+		}
+	}
+	return scopes
+}
+
+// HasScope reports whether the caller was granted scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// RequireScope wraps a handler so that only callers whose claims, stored by RequireJWT,
+// grant scope reach it. Unauthenticated requests get 401 and authenticated callers without
+// the scope get 403.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				writeUnauthorized(w, "")
+				return
+			}
+			if !claims.HasScope(scope) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+				writeJSONError(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
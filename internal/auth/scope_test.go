@@ -0,0 +1,70 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/auth"
+)
+
+func TestClaimsScopes(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra map[string]any
+		want  []string
+	}{
+		{"space-delimited scope claim", map[string]any{"scope": "orders:read orders:write"}, []string{"orders:read", "orders:write"}},
+		{"scp list claim", map[string]any{"scp": []any{"orders:admin"}}, []string{"orders:admin"}},
+		{"no scopes", map[string]any{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &auth.Claims{Extra: tt.extra}
+			got := claims.Scopes()
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := auth.RequireScope("orders:admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(claims *auth.Claims) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders/bulk-status", nil)
+		if claims != nil {
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("allows callers with the scope", func(t *testing.T) {
+		if got := serve(&auth.Claims{Extra: map[string]any{"scope": "orders:read orders:admin"}}); got != http.StatusOK {
+			t.Errorf("expected status 200, got %d", got)
+		}
+	})
+
+	t.Run("returns 403 for callers without the scope", func(t *testing.T) {
+		if got := serve(&auth.Claims{Extra: map[string]any{"scope": "orders:read"}}); got != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", got)
+		}
+	})
+
+	t.Run("returns 401 for unauthenticated requests", func(t *testing.T) {
+		if got := serve(nil); got != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheLRU(t *testing.T) {
+	t.Run("returns stored value", func(t *testing.T) {
+		c := NewLRU[string, int](2, time.Minute)
+		c.Set("a", 1)
+
+		got, ok := c.Get("a")
+		if !ok || got != 1 {
+			t.Errorf("expected (1, true), got (%d, %v)", got, ok)
+		}
+	})
+
+	t.Run("evicts least recently used entry when full", func(t *testing.T) {
+		c := NewLRU[string, int](2, time.Minute)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Get("a")
+		c.Set("c", 3)
+
+		if _, ok := c.Get("b"); ok {
+			t.Error("expected b to be evicted")
+		}
+		if _, ok := c.Get("a"); !ok {
+			t.Error("expected a to remain cached")
+		}
+		if c.Len() != 2 {
+			t.Errorf("expected 2 entries, got %d", c.Len())
+		}
+	})
+
+	t.Run("expires entries after ttl", func(t *testing.T) {
+		now := time.Now()
+		c := NewLRU[string, int](2, time.Minute)
+		c.now = func() time.Time { return now }
+		c.Set("a", 1)
+
+		now = now.Add(time.Minute)
+
+		if _, ok := c.Get("a"); ok {
+			t.Error("expected a to be expired")
+		}
+		if c.Len() != 0 {
+			t.Errorf("expected expired entry to be removed, got %d entries", c.Len())
+		}
+	})
+
+	t.Run("removes deleted entries", func(t *testing.T) {
+		c := NewLRU[string, int](2, time.Minute)
+		c.Set("a", 1)
+		c.Delete("a")
+
+		if _, ok := c.Get("a"); ok {
+			t.Error("expected a to be deleted")
+		}
+	})
+
+	t.Run("is safe under concurrent access", func(t *testing.T) {
+		c := NewLRU[int, int](16, time.Minute)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					c.Set(j, i)
+					c.Get(j)
+					c.Delete(j - 1)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if c.Len() > 16 {
+			t.Errorf("expected at most 16 entries, got %d", c.Len())
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ScopeName is the instrumentation scope to create the meter for cache metrics with.
+const ScopeName = "github.com/dejobratic/tbd/internal/cache"
+
+type Metrics struct {
+	lookupsTotal metric.Int64Counter
+}
+
+func NewMetrics(meter metric.Meter) (*Metrics, error) {
+	m := &Metrics{}
+
+	var err error
+
+	m.lookupsTotal, err = meter.Int64Counter(
+		"cache_lookups_total",
+		metric.WithDescription("Total cache lookups by result"),
+		metric.WithUnit("{lookup}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create cache_lookups_total counter: %w", err)
+	}
+
+	return m, nil
+}
+
+func (m *Metrics) RecordLookup(ctx context.Context, cache string, hit bool) {
+	result := "hit"
+	if !hit {
+		result = "miss"
+	}
+	m.lookupsTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("cache", cache),
+		attribute.String("result", result),
+	))
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestInitializeMetrics(t *testing.T) {
+	t.Run("initializes all metric instruments successfully", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		meter := mp.Meter("test")
+
+		metrics, err := NewMetrics(meter)
+		if err != nil {
+			t.Fatalf("NewMetrics() failed: %v", err)
+		}
+
+		if metrics == nil {
+			t.Fatal("NewMetrics() returned nil")
+		}
+
+		if metrics.lookupsTotal == nil {
+			t.Error("lookupsTotal is nil")
+		}
+	})
+}
+
+func TestRecordCacheLookup(t *testing.T) {
+	t.Run("records lookups with cache and result labels", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		meter := mp.Meter("test")
+
+		metrics, err := NewMetrics(meter)
+		if err != nil {
+			t.Fatalf("NewMetrics() failed: %v", err)
+		}
+
+		ctx := context.Background()
+
+		metrics.RecordLookup(ctx, "orders", true)
+		metrics.RecordLookup(ctx, "orders", false)
+
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			t.Fatalf("Failed to collect metrics: %v", err)
+		}
+
+		found := false
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name == "cache_lookups_total" {
+					found = true
+					sum, ok := m.Data.(metricdata.Sum[int64])
+					if !ok {
+						t.Fatal("Expected Sum[int64] data type")
+					}
+					if len(sum.DataPoints) != 2 {
+						t.Errorf("Expected 2 data points, got %d", len(sum.DataPoints))
+					}
+				}
+			}
+		}
+
+		if !found {
+			t.Error("cache_lookups_total metric not found")
+		}
+	})
+}
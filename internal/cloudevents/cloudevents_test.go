@@ -0,0 +1,81 @@
+package cloudevents_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/cloudevents"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSerialize(t *testing.T) {
+	t.Run("wraps data in a CloudEvents envelope", func(t *testing.T) {
+		serializer := cloudevents.NewSerializer("/tbd-api", "com.tbd.")
+		at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		encoded, err := serializer.Serialize(context.Background(), "order.created", "order-1", at, map[string]string{"order_id": "order-1"})
+		if err != nil {
+			t.Fatalf("failed to serialize: %v", err)
+		}
+
+		var envelope cloudevents.Envelope
+		if err := json.Unmarshal(encoded, &envelope); err != nil {
+			t.Fatalf("failed to decode envelope: %v", err)
+		}
+
+		if envelope.SpecVersion != "1.0" {
+			t.Errorf("expected specversion 1.0, got %s", envelope.SpecVersion)
+		}
+		if envelope.ID == "" {
+			t.Error("expected id to be generated")
+		}
+		if envelope.Source != "/tbd-api" {
+			t.Errorf("expected source /tbd-api, got %s", envelope.Source)
+		}
+		if envelope.Type != "com.tbd.order.created" {
+			t.Errorf("expected type com.tbd.order.created, got %s", envelope.Type)
+		}
+		if !envelope.Time.Equal(at) {
+			t.Errorf("expected time %v, got %v", at, envelope.Time)
+		}
+		if envelope.TraceParent != "" {
+			t.Errorf("expected no traceparent without a span, got %s", envelope.TraceParent)
+		}
+
+		var data map[string]string
+		if err := json.Unmarshal(envelope.Data, &data); err != nil {
+			t.Fatalf("failed to decode data: %v", err)
+		}
+		if data["order_id"] != "order-1" {
+			t.Errorf("expected order_id order-1, got %v", data)
+		}
+	})
+
+	t.Run("includes traceparent from context", func(t *testing.T) {
+		serializer := cloudevents.NewSerializer("/tbd-api", "com.tbd.")
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		}))
+
+		encoded, err := serializer.Serialize(ctx, "order.created", "order-1", time.Now(), nil)
+		if err != nil {
+			t.Fatalf("failed to serialize: %v", err)
+		}
+
+		var envelope cloudevents.Envelope
+		if err := json.Unmarshal(encoded, &envelope); err != nil {
+			t.Fatalf("failed to decode envelope: %v", err)
+		}
+
+		want := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		if envelope.TraceParent != want {
+			t.Errorf("expected traceparent %s, got %s", want, envelope.TraceParent)
+		}
+	})
+}
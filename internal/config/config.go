@@ -2,9 +2,14 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config captures runtime configuration for the API service.
@@ -14,30 +19,128 @@ type Config struct {
 	Kafka     KafkaConfig
 	Telemetry TelemetryConfig
 	Service   ServiceConfig
+	Orders    OrdersConfig
+	Auth      AuthConfig
 }
 
 type HTTPConfig struct {
-	Port          int
-	MetricsPath   string
-	ShutdownGrace int
+	Port                      int
+	MetricsPath               string
+	ShutdownGrace             int
+	IdempotencyHeader         string
+	IdempotencyHeaderAliases  []string
+	IdempotencyKeyRequireUUID bool
+	IdempotencyFailOpen       bool
+	AdminToken                string
+	MaxConcurrency            int
+	SlowRequestThreshold      time.Duration
+	CORSAllowedOrigins        []string
+	CORSAllowCredentials      bool
+	Compression               bool
+	CreateRetryAfter          time.Duration
+	// MaxCreateBodyBytes caps order creation payloads; larger bodies get 413.
+	MaxCreateBodyBytes int64
+	// DebugErrors returns internal error details in 5xx response bodies.
+	DebugErrors bool
+	// GoneForTerminalOrders answers 410 for canceled and failed orders on GET by ID.
+	GoneForTerminalOrders bool
+	// RepanicOnPanic re-raises recovered handler panics after recording them, for fail-fast
+	// debugging. It is ignored in production.
+	RepanicOnPanic bool
+	// TenantHeader and TenantClaim name where the caller's tenant ID is read from; the claim
+	// wins when both are set. Tenant tagging is off when neither is.
+	TenantHeader string
+	TenantClaim  string
+	// InstrumentationExclusions are paths left out of request metrics and access logs.
+	InstrumentationExclusions []string
+
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// WriteTimeoutOverrides replaces WriteTimeout for exact request paths, such as streaming
+	// endpoints. A zero duration disables the write deadline.
+	WriteTimeoutOverrides map[string]time.Duration
 }
 
 type DatabaseConfig struct {
 	URL            string
+	ReplicaURL     string
 	AutoMigrate    bool
 	MigrationsPath string
+	QueryTimeout   time.Duration
+
+	QueryExecMode          string
+	StatementCacheCapacity int
+	// TraceStatements records parameterized SQL on repository spans.
+	TraceStatements bool
+	// Schema qualifies the orders, outbox, and idempotency tables.
+	Schema string
+	// MaxListRows caps the orders any single List call returns; 0 disables the cap.
+	MaxListRows int
+	// ConnectAttempts and ConnectBackoff control retries of the initial connection.
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
 }
 
 type KafkaConfig struct {
 	Brokers []string
+	// Topic names for each order event, with KAFKA_TOPIC_PREFIX already applied to defaults.
+	TopicOrderCreated   string
+	TopicOrderProcessed string
+	TopicOrderFailed    string
 }
 
 type TelemetryConfig struct {
 	LogLevel      string
+	LogFormat     string
+	LogSource     bool
+	LogBaggage    []string
 	OTelEndpoint  string
+	OTLPHeaders   map[string]string
+	ResourceAttrs map[string]string
 	EnableTracing bool
 	EnableMetrics bool
 	SampleRate    float64
+
+	BatchMaxQueueSize  int
+	BatchMaxExportSize int
+	BatchTimeout       time.Duration
+}
+
+type OrdersConfig struct {
+	BlockedEmailDomains []string
+	CacheEnabled        bool
+	CacheSize           int
+	CacheTTL            time.Duration
+	ReprocessBatchSize  int
+	ReprocessLimit      int
+	AllowZeroAmount     bool
+	DefaultPageSize     int
+	MaxPageSize         int
+	IDStrategy          string
+	MaxEmailLength      int
+	// CreateStatus is the status answered, and stored for replays, when an order is
+	// created: 201 or 202.
+	CreateStatus int
+}
+
+// AuthConfig configures JWT bearer authentication. Authentication is enabled when a shared
+// secret or a JWKS URL is set.
+type AuthConfig struct {
+	JWTSecret           string
+	JWKSURL             string
+	JWKSRefreshInterval time.Duration
+	Audience            string
+	Issuer              string
+	// RouteScopes maps handler route names, such as "orders.cancel", to the scope callers
+	// need to use them.
+	RouteScopes map[string]string
+}
+
+// Enabled reports whether requests must carry a valid JWT.
+func (c AuthConfig) Enabled() bool {
+	return c.JWTSecret != "" || c.JWKSURL != ""
 }
 
 type ServiceConfig struct {
@@ -47,16 +150,45 @@ type ServiceConfig struct {
 }
 
 const (
-	defaultHTTPPort       = 8080
-	defaultMetricsPath    = "/metrics"
-	defaultShutdownGrace  = 15
-	defaultMigrationsPath = "migrations"
-	defaultAutoMigrate    = true
-	defaultServiceName    = "tbd-api"
-	defaultServiceVersion = "0.1.0"
-	defaultEnvironment    = "development"
-	defaultLogLevel       = "info"
-	defaultOTelSampleRate = 1.0
+	defaultHTTPPort          = 8080
+	defaultMetricsPath       = "/metrics"
+	defaultIdemHeader        = "Idempotency-Key"
+	defaultShutdownGrace     = 15
+	defaultSlowRequest       = time.Second
+	defaultCreateRetryAfter  = time.Second
+	defaultMaxCreateBody     = 1 << 20
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 15 * time.Second
+	defaultWriteTimeout      = 15 * time.Second
+	defaultIdleTimeout       = 60 * time.Second
+	defaultMigrationsPath    = "migrations"
+	defaultAutoMigrate       = true
+	defaultQueryTimeout      = 5 * time.Second
+	defaultQueryExecMode     = "cache_statement"
+	defaultStatementCache    = 512
+	defaultDBSchema          = "public"
+	defaultConnectAttempts   = 5
+	defaultMaxListRows       = 10000
+	defaultConnectBackoff    = 500 * time.Millisecond
+	defaultServiceName       = "tbd-api"
+	defaultServiceVersion    = "0.1.0"
+	defaultEnvironment       = "development"
+	defaultLogLevel          = "info"
+	defaultLogFormat         = "json"
+	defaultOTelSampleRate    = 1.0
+	defaultBatchQueueSize    = 2048
+	defaultBatchSize         = 512
+	defaultBatchTimeout      = 5 * time.Second
+	defaultOrderCacheSize    = 1000
+	defaultOrderCacheTTL     = 30 * time.Second
+	defaultReprocessBatch    = 100
+	defaultReprocessLimit    = 1000
+	defaultPageSize          = 20
+	defaultMaxPageSize       = 100
+	defaultOrderIDStrategy   = "hex"
+	defaultMaxEmailLength    = 254
+	defaultCreateStatus      = 202
+	defaultJWKSRefresh       = 15 * time.Minute
 )
 
 // Load reads configuration from environment variables, applying defaults when needed.
@@ -66,8 +198,14 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("loading HTTP config: %w", err)
 	}
 
-	dbCfg := loadDatabaseConfig()
-	kafkaCfg := loadKafkaConfig()
+	dbCfg, err := loadDatabaseConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading database config: %w", err)
+	}
+	kafkaCfg, err := loadKafkaConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading kafka config: %w", err)
+	}
 	telCfg, err := loadTelemetryConfig()
 	if err != nil {
 		return nil, fmt.Errorf("loading telemetry config: %w", err)
@@ -75,12 +213,24 @@ func Load() (*Config, error) {
 
 	serviceCfg := loadServiceConfig()
 
+	ordersCfg, err := loadOrdersConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading orders config: %w", err)
+	}
+
+	authCfg, err := loadAuthConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading auth config: %w", err)
+	}
+
 	return &Config{
 		HTTP:      httpCfg,
 		Database:  dbCfg,
 		Kafka:     kafkaCfg,
 		Telemetry: telCfg,
 		Service:   serviceCfg,
+		Orders:    ordersCfg,
+		Auth:      authCfg,
 	}, nil
 }
 
@@ -104,15 +254,149 @@ func loadHTTPConfig() (HTTPConfig, error) {
 	}
 
 	metricsPath := getEnvOrDefault("API_METRICS_PATH", defaultMetricsPath)
+	idemHeader := getEnvOrDefault("IDEMPOTENCY_HEADER", defaultIdemHeader)
+	idemRequireUUID := getBoolEnv("IDEMPOTENCY_KEY_REQUIRE_UUID", false)
+	idemFailOpen := getBoolEnv("IDEMPOTENCY_FAIL_OPEN", false)
+	adminToken := getEnvOrDefault("ADMIN_API_TOKEN", "")
+
+	maxConcurrency := 0
+	if value, ok := os.LookupEnv("HTTP_MAX_CONCURRENCY"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_MAX_CONCURRENCY: %w", err)
+		}
+		if parsed < 0 {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_MAX_CONCURRENCY: must not be negative")
+		}
+		maxConcurrency = parsed
+	}
+
+	var idemAliases []string
+	if value, ok := os.LookupEnv("IDEMPOTENCY_HEADER_ALIASES"); ok && value != "" {
+		idemAliases = strings.Split(value, ",")
+	}
+
+	slowRequestThreshold := defaultSlowRequest
+	if value, ok := os.LookupEnv("HTTP_SLOW_REQUEST_THRESHOLD"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_SLOW_REQUEST_THRESHOLD: %w", err)
+		}
+		slowRequestThreshold = parsed
+	}
+
+	createRetryAfter := defaultCreateRetryAfter
+	if value, ok := os.LookupEnv("HTTP_CREATE_RETRY_AFTER"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_CREATE_RETRY_AFTER: %w", err)
+		}
+		if parsed < 0 {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_CREATE_RETRY_AFTER: must not be negative")
+		}
+		createRetryAfter = parsed
+	}
+
+	maxCreateBodyBytes := int64(defaultMaxCreateBody)
+	if value, ok := os.LookupEnv("HTTP_MAX_CREATE_BODY_BYTES"); ok {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_MAX_CREATE_BODY_BYTES: %w", err)
+		}
+		if parsed < 0 {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_MAX_CREATE_BODY_BYTES: must not be negative")
+		}
+		maxCreateBodyBytes = parsed
+	}
+
+	readHeaderTimeout, err := getDurationEnv("HTTP_READ_HEADER_TIMEOUT", defaultReadHeaderTimeout)
+	if err != nil {
+		return HTTPConfig{}, err
+	}
+	readTimeout, err := getDurationEnv("HTTP_READ_TIMEOUT", defaultReadTimeout)
+	if err != nil {
+		return HTTPConfig{}, err
+	}
+	writeTimeout, err := getDurationEnv("HTTP_WRITE_TIMEOUT", defaultWriteTimeout)
+	if err != nil {
+		return HTTPConfig{}, err
+	}
+	idleTimeout, err := getDurationEnv("HTTP_IDLE_TIMEOUT", defaultIdleTimeout)
+	if err != nil {
+		return HTTPConfig{}, err
+	}
+
+	writeTimeoutOverrides := map[string]time.Duration{}
+	rawOverrides, err := parseKeyValueList(os.Getenv("HTTP_WRITE_TIMEOUT_OVERRIDES"))
+	if err != nil {
+		return HTTPConfig{}, fmt.Errorf("invalid HTTP_WRITE_TIMEOUT_OVERRIDES: %w", err)
+	}
+	for path, value := range rawOverrides {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_WRITE_TIMEOUT_OVERRIDES: %s: %w", path, err)
+		}
+		if parsed < 0 {
+			return HTTPConfig{}, fmt.Errorf("invalid HTTP_WRITE_TIMEOUT_OVERRIDES: %s: must not be negative", path)
+		}
+		writeTimeoutOverrides[path] = parsed
+	}
+
+	instrumentationExclusions := []string{metricsPath, "/healthz", "/readyz"}
+	if value, ok := os.LookupEnv("HTTP_INSTRUMENTATION_EXCLUDE_PATHS"); ok {
+		instrumentationExclusions = nil
+		for _, path := range strings.Split(value, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				instrumentationExclusions = append(instrumentationExclusions, path)
+			}
+		}
+	}
+
+	var corsOrigins []string
+	if value, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok && value != "" {
+		for _, origin := range strings.Split(value, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				corsOrigins = append(corsOrigins, origin)
+			}
+		}
+	}
+	corsCredentials := getBoolEnv("CORS_ALLOW_CREDENTIALS", false)
+	compression := getBoolEnv("HTTP_COMPRESSION", true)
+	if corsCredentials && slices.Contains(corsOrigins, "*") {
+		return HTTPConfig{}, fmt.Errorf("invalid CORS_ALLOW_CREDENTIALS: cannot be combined with a wildcard origin; list origins explicitly")
+	}
 
 	return HTTPConfig{
-		Port:          port,
-		MetricsPath:   metricsPath,
-		ShutdownGrace: shutdownGrace,
+		Port:                      port,
+		MetricsPath:               metricsPath,
+		ShutdownGrace:             shutdownGrace,
+		IdempotencyHeader:         idemHeader,
+		IdempotencyHeaderAliases:  idemAliases,
+		IdempotencyKeyRequireUUID: idemRequireUUID,
+		IdempotencyFailOpen:       idemFailOpen,
+		AdminToken:                adminToken,
+		MaxConcurrency:            maxConcurrency,
+		SlowRequestThreshold:      slowRequestThreshold,
+		CORSAllowedOrigins:        corsOrigins,
+		CORSAllowCredentials:      corsCredentials,
+		Compression:               compression,
+		CreateRetryAfter:          createRetryAfter,
+		MaxCreateBodyBytes:        maxCreateBodyBytes,
+		TenantHeader:              strings.TrimSpace(os.Getenv("TENANT_HEADER")),
+		TenantClaim:               strings.TrimSpace(os.Getenv("TENANT_CLAIM")),
+		DebugErrors:               getBoolEnv("DEBUG_ERRORS", false),
+		RepanicOnPanic:            getBoolEnv("HTTP_REPANIC", false),
+		GoneForTerminalOrders:     getBoolEnv("HTTP_GONE_FOR_TERMINAL_ORDERS", false),
+		InstrumentationExclusions: instrumentationExclusions,
+		ReadHeaderTimeout:         readHeaderTimeout,
+		ReadTimeout:               readTimeout,
+		WriteTimeout:              writeTimeout,
+		IdleTimeout:               idleTimeout,
+		WriteTimeoutOverrides:     writeTimeoutOverrides,
 	}, nil
 }
 
-func loadDatabaseConfig() DatabaseConfig {
+func loadDatabaseConfig() (DatabaseConfig, error) {
 	databaseURL := os.Getenv("DATABASE_URL")
 	if databaseURL == "" {
 		databaseURL = buildDatabaseURL()
@@ -125,28 +409,165 @@ func loadDatabaseConfig() DatabaseConfig {
 
 	migrationsPath := getEnvOrDefault("MIGRATIONS_PATH", defaultMigrationsPath)
 
-	return DatabaseConfig{
-		URL:            databaseURL,
-		AutoMigrate:    autoMigrate,
-		MigrationsPath: migrationsPath,
+	queryTimeout := defaultQueryTimeout
+	if value, ok := os.LookupEnv("DB_QUERY_TIMEOUT"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return DatabaseConfig{}, fmt.Errorf("invalid DB_QUERY_TIMEOUT: %w", err)
+		}
+		queryTimeout = parsed
+	}
+
+	queryExecMode := getEnvOrDefault("DB_QUERY_EXEC_MODE", defaultQueryExecMode)
+	switch queryExecMode {
+	case "cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol":
+	default:
+		return DatabaseConfig{}, fmt.Errorf("invalid DB_QUERY_EXEC_MODE: %q", queryExecMode)
+	}
+
+	statementCacheCapacity := defaultStatementCache
+	if value, ok := os.LookupEnv("DB_STATEMENT_CACHE_CAPACITY"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return DatabaseConfig{}, fmt.Errorf("invalid DB_STATEMENT_CACHE_CAPACITY: %w", err)
+		}
+		statementCacheCapacity = parsed
+	}
+
+	maxListRows := defaultMaxListRows
+	if value, ok := os.LookupEnv("DB_MAX_LIST_ROWS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return DatabaseConfig{}, fmt.Errorf("invalid DB_MAX_LIST_ROWS: %w", err)
+		}
+		if parsed < 0 {
+			return DatabaseConfig{}, fmt.Errorf("invalid DB_MAX_LIST_ROWS: must not be negative")
+		}
+		maxListRows = parsed
+	}
+
+	connectAttempts := defaultConnectAttempts
+	if value, ok := os.LookupEnv("DB_CONNECT_ATTEMPTS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return DatabaseConfig{}, fmt.Errorf("invalid DB_CONNECT_ATTEMPTS: %w", err)
+		}
+		if parsed < 1 {
+			return DatabaseConfig{}, fmt.Errorf("invalid DB_CONNECT_ATTEMPTS: must be at least 1")
+		}
+		connectAttempts = parsed
 	}
+
+	connectBackoff, err := getDurationEnv("DB_CONNECT_BACKOFF", defaultConnectBackoff)
+	if err != nil {
+		return DatabaseConfig{}, err
+	}
+
+	return DatabaseConfig{
+		URL:                    databaseURL,
+		ReplicaURL:             os.Getenv("DATABASE_REPLICA_URL"),
+		AutoMigrate:            autoMigrate,
+		MigrationsPath:         migrationsPath,
+		QueryTimeout:           queryTimeout,
+		QueryExecMode:          queryExecMode,
+		StatementCacheCapacity: statementCacheCapacity,
+		TraceStatements:        getBoolEnv("DB_TRACE_STATEMENTS", false),
+		Schema:                 getEnvOrDefault("DB_SCHEMA", defaultDBSchema),
+		MaxListRows:            maxListRows,
+		ConnectAttempts:        connectAttempts,
+		ConnectBackoff:         connectBackoff,
+	}, nil
 }
 
-func loadKafkaConfig() KafkaConfig {
+func loadKafkaConfig() (KafkaConfig, error) {
 	var brokers []string
 	if value, ok := os.LookupEnv("KAFKA_BROKERS"); ok && value != "" {
-		brokers = strings.Split(value, ",")
+		for _, broker := range strings.Split(value, ",") {
+			if broker = strings.TrimSpace(broker); broker == "" {
+				continue
+			}
+			if err := validateBroker(broker); err != nil {
+				return KafkaConfig{}, fmt.Errorf("invalid KAFKA_BROKERS: %w", err)
+			}
+			brokers = append(brokers, broker)
+		}
+		if len(brokers) == 0 {
+			return KafkaConfig{}, fmt.Errorf("invalid KAFKA_BROKERS: no broker addresses in %q", value)
+		}
+	}
+
+	prefix := os.Getenv("KAFKA_TOPIC_PREFIX")
+	topics := map[string]string{
+		"KAFKA_TOPIC_ORDER_CREATED":   getEnvOrDefault("KAFKA_TOPIC_ORDER_CREATED", prefix+"order.created"),
+		"KAFKA_TOPIC_ORDER_PROCESSED": getEnvOrDefault("KAFKA_TOPIC_ORDER_PROCESSED", prefix+"order.processed"),
+		"KAFKA_TOPIC_ORDER_FAILED":    getEnvOrDefault("KAFKA_TOPIC_ORDER_FAILED", prefix+"order.failed"),
+	}
+	seen := map[string]string{}
+	for _, key := range slices.Sorted(maps.Keys(topics)) {
+		topic := topics[key]
+		if err := validateTopicName(topic); err != nil {
+			return KafkaConfig{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if other, ok := seen[topic]; ok {
+			return KafkaConfig{}, fmt.Errorf("invalid %s: topic %q is also used by %s", key, topic, other)
+		}
+		seen[topic] = key
 	}
 
 	return KafkaConfig{
-		Brokers: brokers,
+		Brokers:             brokers,
+		TopicOrderCreated:   topics["KAFKA_TOPIC_ORDER_CREATED"],
+		TopicOrderProcessed: topics["KAFKA_TOPIC_ORDER_PROCESSED"],
+		TopicOrderFailed:    topics["KAFKA_TOPIC_ORDER_FAILED"],
+	}, nil
+}
+
+// validateBroker checks that a broker address is host:port with a port in 1-65535.
+func validateBroker(broker string) error {
+	host, port, err := net.SplitHostPort(broker)
+	if err != nil {
+		return fmt.Errorf("broker %q must be host:port: %w", broker, err)
+	}
+	if host == "" {
+		return fmt.Errorf("broker %q has no host", broker)
 	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("broker %q has invalid port %q", broker, port)
+	}
+	return nil
+}
+
+// validateTopicName applies Kafka's topic naming rules: 1-249 characters from
+// [a-zA-Z0-9._-], and not "." or "..".
+func validateTopicName(name string) error {
+	if name == "" || len(name) > 249 {
+		return fmt.Errorf("topic name must be 1-249 characters, got %d", len(name))
+	}
+	if name == "." || name == ".." {
+		return fmt.Errorf("topic name %q is reserved", name)
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '.' && r != '_' && r != '-' {
+			return fmt.Errorf("topic name %q contains invalid character %q", name, r)
+		}
+	}
+	return nil
 }
 
 func loadTelemetryConfig() (TelemetryConfig, error) {
 	logLevel := getEnvOrDefault("LOG_LEVEL", defaultLogLevel)
 	otelEndpoint := getEnvOrDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "")
 
+	otlpHeaders, err := parseKeyValueList(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return TelemetryConfig{}, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS: %w", err)
+	}
+
+	resourceAttrs, err := parseKeyValueList(os.Getenv("RESOURCE_ATTRIBUTES"))
+	if err != nil {
+		return TelemetryConfig{}, fmt.Errorf("invalid RESOURCE_ATTRIBUTES: %w", err)
+	}
+
 	enableTracing := getBoolEnv("OTEL_ENABLE_TRACING", true)
 	enableMetrics := getBoolEnv("OTEL_ENABLE_METRICS", true)
 
@@ -159,12 +580,61 @@ func loadTelemetryConfig() (TelemetryConfig, error) {
 		sampleRate = parsed
 	}
 
+	batchQueueSize := defaultBatchQueueSize
+	if value, ok := os.LookupEnv("OTEL_BSP_MAX_QUEUE_SIZE"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return TelemetryConfig{}, fmt.Errorf("invalid OTEL_BSP_MAX_QUEUE_SIZE: %w", err)
+		}
+		batchQueueSize = parsed
+	}
+
+	batchSize := defaultBatchSize
+	if value, ok := os.LookupEnv("OTEL_BSP_MAX_EXPORT_BATCH_SIZE"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return TelemetryConfig{}, fmt.Errorf("invalid OTEL_BSP_MAX_EXPORT_BATCH_SIZE: %w", err)
+		}
+		batchSize = parsed
+	}
+
+	batchTimeout := defaultBatchTimeout
+	if value, ok := os.LookupEnv("OTEL_BSP_SCHEDULE_DELAY"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return TelemetryConfig{}, fmt.Errorf("invalid OTEL_BSP_SCHEDULE_DELAY: %w", err)
+		}
+		batchTimeout = parsed
+	}
+
+	logFormat := getEnvOrDefault("LOG_FORMAT", defaultLogFormat)
+	if logFormat != "json" && logFormat != "text" {
+		return TelemetryConfig{}, fmt.Errorf("invalid LOG_FORMAT: %q must be json or text", logFormat)
+	}
+
+	var logBaggage []string
+	if value, ok := os.LookupEnv("LOG_BAGGAGE_KEYS"); ok && value != "" {
+		for _, key := range strings.Split(value, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				logBaggage = append(logBaggage, key)
+			}
+		}
+	}
+
 	return TelemetryConfig{
-		LogLevel:      logLevel,
-		OTelEndpoint:  otelEndpoint,
-		EnableTracing: enableTracing,
-		EnableMetrics: enableMetrics,
-		SampleRate:    sampleRate,
+		LogLevel:           logLevel,
+		LogFormat:          logFormat,
+		LogSource:          getBoolEnv("LOG_SOURCE", false),
+		LogBaggage:         logBaggage,
+		OTelEndpoint:       otelEndpoint,
+		OTLPHeaders:        otlpHeaders,
+		ResourceAttrs:      resourceAttrs,
+		EnableTracing:      enableTracing,
+		EnableMetrics:      enableMetrics,
+		SampleRate:         sampleRate,
+		BatchMaxQueueSize:  batchQueueSize,
+		BatchMaxExportSize: batchSize,
+		BatchTimeout:       batchTimeout,
 	}, nil
 }
 
@@ -176,6 +646,163 @@ func loadServiceConfig() ServiceConfig {
 	}
 }
 
+func loadOrdersConfig() (OrdersConfig, error) {
+	var blocked []string
+	if value, ok := os.LookupEnv("BLOCKED_EMAIL_DOMAINS"); ok && value != "" {
+		blocked = append(blocked, strings.Split(value, ",")...)
+	}
+
+	if path, ok := os.LookupEnv("BLOCKED_EMAIL_DOMAINS_FILE"); ok && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid BLOCKED_EMAIL_DOMAINS_FILE: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			blocked = append(blocked, line)
+		}
+	}
+
+	cacheSize := defaultOrderCacheSize
+	if value, ok := os.LookupEnv("ORDER_CACHE_SIZE"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid ORDER_CACHE_SIZE: %w", err)
+		}
+		cacheSize = parsed
+	}
+
+	cacheTTL := defaultOrderCacheTTL
+	if value, ok := os.LookupEnv("ORDER_CACHE_TTL"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid ORDER_CACHE_TTL: %w", err)
+		}
+		cacheTTL = parsed
+	}
+
+	reprocessBatch := defaultReprocessBatch
+	if value, ok := os.LookupEnv("REPROCESS_BATCH_SIZE"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid REPROCESS_BATCH_SIZE: %w", err)
+		}
+		reprocessBatch = parsed
+	}
+
+	reprocessLimit := defaultReprocessLimit
+	if value, ok := os.LookupEnv("REPROCESS_LIMIT"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid REPROCESS_LIMIT: %w", err)
+		}
+		reprocessLimit = parsed
+	}
+
+	pageSize := defaultPageSize
+	if value, ok := os.LookupEnv("DEFAULT_PAGE_SIZE"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid DEFAULT_PAGE_SIZE: %w", err)
+		}
+		pageSize = parsed
+	}
+
+	maxPageSize := defaultMaxPageSize
+	if value, ok := os.LookupEnv("MAX_PAGE_SIZE"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid MAX_PAGE_SIZE: %w", err)
+		}
+		maxPageSize = parsed
+	}
+
+	maxEmailLength := defaultMaxEmailLength
+	if value, ok := os.LookupEnv("ORDER_MAX_EMAIL_LENGTH"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid ORDER_MAX_EMAIL_LENGTH: %w", err)
+		}
+		if parsed <= 0 {
+			return OrdersConfig{}, fmt.Errorf("invalid ORDER_MAX_EMAIL_LENGTH: must be positive")
+		}
+		maxEmailLength = parsed
+	}
+
+	createStatus := defaultCreateStatus
+	if value, ok := os.LookupEnv("ORDER_CREATE_STATUS"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return OrdersConfig{}, fmt.Errorf("invalid ORDER_CREATE_STATUS: %w", err)
+		}
+		if parsed != 201 && parsed != 202 {
+			return OrdersConfig{}, fmt.Errorf("invalid ORDER_CREATE_STATUS: must be 201 or 202, got %d", parsed)
+		}
+		createStatus = parsed
+	}
+
+	if pageSize <= 0 || maxPageSize <= 0 {
+		return OrdersConfig{}, fmt.Errorf("invalid page sizes: DEFAULT_PAGE_SIZE and MAX_PAGE_SIZE must be positive")
+	}
+	if pageSize > maxPageSize {
+		return OrdersConfig{}, fmt.Errorf("invalid DEFAULT_PAGE_SIZE: %d exceeds MAX_PAGE_SIZE %d", pageSize, maxPageSize)
+	}
+
+	return OrdersConfig{
+		BlockedEmailDomains: blocked,
+		CacheEnabled:        getBoolEnv("ORDER_CACHE_ENABLED", false),
+		CacheSize:           cacheSize,
+		CacheTTL:            cacheTTL,
+		ReprocessBatchSize:  reprocessBatch,
+		ReprocessLimit:      reprocessLimit,
+		AllowZeroAmount:     getBoolEnv("ORDER_ALLOW_ZERO_AMOUNT", false),
+		DefaultPageSize:     pageSize,
+		MaxPageSize:         maxPageSize,
+		IDStrategy:          getEnvOrDefault("ORDER_ID_STRATEGY", defaultOrderIDStrategy),
+		MaxEmailLength:      maxEmailLength,
+		CreateStatus:        createStatus,
+	}, nil
+}
+
+func loadAuthConfig() (AuthConfig, error) {
+	jwksURL := os.Getenv("AUTH_JWKS_URL")
+	if jwksURL != "" {
+		if _, err := url.ParseRequestURI(jwksURL); err != nil {
+			return AuthConfig{}, fmt.Errorf("invalid AUTH_JWKS_URL: %w", err)
+		}
+	}
+
+	refreshInterval := defaultJWKSRefresh
+	if value, ok := os.LookupEnv("AUTH_JWKS_REFRESH_INTERVAL"); ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return AuthConfig{}, fmt.Errorf("invalid AUTH_JWKS_REFRESH_INTERVAL: %w", err)
+		}
+		refreshInterval = parsed
+	}
+
+	routeScopes, err := parseKeyValueList(os.Getenv("AUTH_ROUTE_SCOPES"))
+	if err != nil {
+		return AuthConfig{}, fmt.Errorf("invalid AUTH_ROUTE_SCOPES: %w", err)
+	}
+
+	cfg := AuthConfig{
+		JWTSecret:           os.Getenv("AUTH_JWT_SECRET"),
+		JWKSURL:             jwksURL,
+		JWKSRefreshInterval: refreshInterval,
+		Audience:            os.Getenv("AUTH_JWT_AUDIENCE"),
+		Issuer:              os.Getenv("AUTH_JWT_ISSUER"),
+		RouteScopes:         routeScopes,
+	}
+	if len(cfg.RouteScopes) > 0 && !cfg.Enabled() {
+		return AuthConfig{}, fmt.Errorf("invalid AUTH_ROUTE_SCOPES: requires AUTH_JWT_SECRET or AUTH_JWKS_URL")
+	}
+	return cfg, nil
+}
+
 func buildDatabaseURL() string {
 	host := getEnvOrDefault("DB_HOST", "localhost")
 	port := getEnvOrDefault("DB_PORT", "5432")
@@ -194,6 +821,45 @@ func buildDatabaseURL() string {
 	)
 }
 
+// parseKeyValueList parses the OpenTelemetry "key1=value1,key2=value2" format, where values
+// may be URL-encoded.
+func parseKeyValueList(raw string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("malformed pair %q", pair)
+		}
+		decoded, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("decode value for %q: %w", key, err)
+		}
+		result[key] = decoded
+	}
+	return result, nil
+}
+
+// getDurationEnv parses key as a non-negative time.Duration, returning defaultValue when unset.
+func getDurationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if parsed < 0 {
+		return 0, fmt.Errorf("invalid %s: must not be negative", key)
+	}
+	return parsed, nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
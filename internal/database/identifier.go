@@ -0,0 +1,19 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// identifierPattern allows unquoted Postgres identifiers: a letter or underscore followed by
+// letters, digits, or underscores, at most 63 bytes (NAMEDATALEN - 1).
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// ValidateIdentifier rejects names that are not plain Postgres identifiers, so configured
+// schema and table names can be interpolated into queries without risk of injection.
+func ValidateIdentifier(name string) error {
+	if !identifierPattern.MatchString(name) {
+		return fmt.Errorf("invalid identifier %q: use letters, digits, and underscores, starting with a letter or underscore", name)
+	}
+	return nil
+}
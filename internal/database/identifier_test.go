@@ -0,0 +1,33 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateIdentifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "public"},
+		{name: "tenant_42"},
+		{name: "_blue"},
+		{name: strings.Repeat("a", 63)},
+		{name: "", wantErr: true},
+		{name: "42tenant", wantErr: true},
+		{name: "tenant-a", wantErr: true},
+		{name: `public"; DROP TABLE orders; --`, wantErr: true},
+		{name: "public.orders", wantErr: true},
+		{name: strings.Repeat("a", 64), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIdentifier(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIdentifier(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,8 +8,12 @@ import (
 	"go.opentelemetry.io/otel/metric"
 )
 
+// ScopeName is the instrumentation scope to create the meter for database metrics with.
+const ScopeName = "github.com/dejobratic/tbd/internal/database"
+
 type Metrics struct {
 	queryDuration metric.Float64Histogram
+	queries       metric.Int64Counter
 }
 
 func NewMetrics(meter metric.Meter) (*Metrics, error) {
@@ -26,11 +30,29 @@ func NewMetrics(meter metric.Meter) (*Metrics, error) {
 		return nil, fmt.Errorf("create db_query_duration histogram: %w", err)
 	}
 
+	m.queries, err = meter.Int64Counter(
+		"db_queries_total",
+		metric.WithDescription("Database queries by operation and result"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create db_queries_total counter: %w", err)
+	}
+
 	return m, nil
 }
 
-func (m *Metrics) RecordQuery(ctx context.Context, operation string, durationSeconds float64) {
+// RecordQuery records the duration of one query and counts it under result "ok" or "error".
+func (m *Metrics) RecordQuery(ctx context.Context, operation string, durationSeconds float64, success bool) {
 	m.queryDuration.Record(ctx, durationSeconds, metric.WithAttributes(
 		attribute.String("operation", operation),
 	))
+
+	result := "ok"
+	if !success {
+		result = "error"
+	}
+	m.queries.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("operation", operation),
+		attribute.String("result", result),
+	))
 }
@@ -26,6 +26,10 @@ func TestInitializeMetrics(t *testing.T) {
 		if metrics.queryDuration == nil {
 			t.Error("queryDuration is nil")
 		}
+
+		if metrics.queries == nil {
+			t.Error("queries is nil")
+		}
 	})
 }
 
@@ -42,8 +46,8 @@ func TestRecordDatabaseQuery(t *testing.T) {
 
 		ctx := context.Background()
 
-		metrics.RecordQuery(ctx, "create_order", 0.1)
-		metrics.RecordQuery(ctx, "get_order_by_id", 0.05)
+		metrics.RecordQuery(ctx, "create_order", 0.1, true)
+		metrics.RecordQuery(ctx, "get_order_by_id", 0.05, true)
 
 		var rm metricdata.ResourceMetrics
 		if err := reader.Collect(ctx, &rm); err != nil {
@@ -71,3 +75,46 @@ func TestRecordDatabaseQuery(t *testing.T) {
 		}
 	})
 }
+
+func TestRecordDatabaseQueryCount(t *testing.T) {
+	t.Run("counts queries by operation and result", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+		metrics, err := NewMetrics(mp.Meter("test"))
+		if err != nil {
+			t.Fatalf("NewMetrics() failed: %v", err)
+		}
+
+		ctx := context.Background()
+		metrics.RecordQuery(ctx, "create_order", 0.1, true)
+		metrics.RecordQuery(ctx, "create_order", 0.1, true)
+		metrics.RecordQuery(ctx, "create_order", 0.2, false)
+
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			t.Fatalf("Failed to collect metrics: %v", err)
+		}
+
+		counts := map[string]int64{}
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name != "db_queries_total" {
+					continue
+				}
+				sum, ok := m.Data.(metricdata.Sum[int64])
+				if !ok {
+					t.Fatal("Expected Sum[int64] data type")
+				}
+				for _, dp := range sum.DataPoints {
+					result, _ := dp.Attributes.Value("result")
+					counts[result.AsString()] = dp.Value
+				}
+			}
+		}
+
+		if counts["ok"] != 2 || counts["error"] != 1 {
+			t.Errorf("Expected 2 ok and 1 error, got %v", counts)
+		}
+	})
+}
@@ -4,11 +4,110 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/dejobratic/tbd/internal/retry"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
-	pool, err := pgxpool.New(ctx, databaseURL)
+// Query execution modes accepted by WithQueryExecMode. The cache modes prepare each
+// distinct SQL statement once per connection and reuse it; exec and simple_protocol
+// reparse every query but work behind transaction-pooling proxies such as PgBouncer.
+const (
+	QueryExecModeCacheStatement = "cache_statement"
+	QueryExecModeCacheDescribe  = "cache_describe"
+	QueryExecModeDescribeExec   = "describe_exec"
+	QueryExecModeExec           = "exec"
+	QueryExecModeSimpleProtocol = "simple_protocol"
+)
+
+var queryExecModes = map[string]pgx.QueryExecMode{
+	QueryExecModeCacheStatement: pgx.QueryExecModeCacheStatement,
+	QueryExecModeCacheDescribe:  pgx.QueryExecModeCacheDescribe,
+	QueryExecModeDescribeExec:   pgx.QueryExecModeDescribeExec,
+	QueryExecModeExec:           pgx.QueryExecModeExec,
+	QueryExecModeSimpleProtocol: pgx.QueryExecModeSimpleProtocol,
+}
+
+// PoolOption customizes NewPool.
+type PoolOption func(*poolOptions)
+
+type poolOptions struct {
+	queryExecMode          string
+	statementCacheCapacity int
+}
+
+// WithQueryExecMode sets how queries are sent to the server. An empty mode keeps the pgx
+// default, cache_statement.
+func WithQueryExecMode(mode string) PoolOption {
+	return func(o *poolOptions) {
+		o.queryExecMode = mode
+	}
+}
+
+// WithStatementCacheCapacity sets how many prepared statements each connection caches in
+// the cache modes. A non-positive capacity keeps the pgx default.
+func WithStatementCacheCapacity(capacity int) PoolOption {
+	return func(o *poolOptions) {
+		o.statementCacheCapacity = capacity
+	}
+}
+
+func NewPool(ctx context.Context, databaseURL string, opts ...PoolOption) (*pgxpool.Pool, error) {
+	config, err := poolConfig(databaseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return connect(ctx, config)
+}
+
+// NewPoolWithRetry is NewPool, retrying the connection according to policy, e.g. while the
+// database is still starting. Configuration errors are returned without retrying.
+func NewPoolWithRetry(ctx context.Context, databaseURL string, policy retry.Policy, opts ...PoolOption) (*pgxpool.Pool, error) {
+	config, err := poolConfig(databaseURL, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var pool *pgxpool.Pool
+	err = retry.Do(ctx, policy, func() error {
+		var err error
+		pool, err = connect(ctx, config)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+func poolConfig(databaseURL string, opts []PoolOption) (*pgxpool.Config, error) {
+	options := poolOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	config, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse database url: %w", err)
+	}
+
+	if options.queryExecMode != "" {
+		mode, ok := queryExecModes[options.queryExecMode]
+		if !ok {
+			return nil, fmt.Errorf("unsupported query exec mode %q", options.queryExecMode)
+		}
+		config.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if options.statementCacheCapacity > 0 {
+		config.ConnConfig.StatementCacheCapacity = options.statementCacheCapacity
+		config.ConnConfig.DescriptionCacheCapacity = options.statementCacheCapacity
+	}
+
+	return config, nil
+}
+
+func connect(ctx context.Context, config *pgxpool.Config) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.NewWithConfig(ctx, config.Copy())
 	if err != nil {
 		return nil, fmt.Errorf("create pool: %w", err)
 	}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RequiredSchemaVersion is the migration version this binary needs. Bump it with every new
+// migration in the migrations directory.
+const RequiredSchemaVersion = 13
+
+// ErrSchemaOutdated matches every SchemaVersionError.
+var ErrSchemaOutdated = errors.New("database schema is out of date")
+
+// SchemaVersionError reports a schema that is behind RequiredSchemaVersion or left dirty by
+// a failed migration.
+type SchemaVersionError struct {
+	Actual   uint
+	Expected uint
+	Dirty    bool
+}
+
+func (e *SchemaVersionError) Error() string {
+	if e.Dirty {
+		return fmt.Sprintf("database schema version %d is dirty; fix the failed migration", e.Actual)
+	}
+	return fmt.Sprintf("database schema version %d is behind required version %d; run migrations", e.Actual, e.Expected)
+}
+
+func (e *SchemaVersionError) Is(target error) bool {
+	return target == ErrSchemaOutdated
+}
+
+// MigrationVersion returns the version recorded by golang-migrate and whether the last
+// migration failed part-way. A database that was never migrated reports version 0.
+func MigrationVersion(ctx context.Context, pool *pgxpool.Pool) (version uint, dirty bool, err error) {
+	var exists bool
+	if err := pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return 0, false, fmt.Errorf("check schema_migrations table: %w", err)
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var v int64
+	err = pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&v, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("read migration version: %w", err)
+	}
+	return uint(v), dirty, nil
+}
+
+// CheckSchemaVersion returns a SchemaVersionError when the schema is dirty or older than
+// RequiredSchemaVersion. Newer schemas pass, so a binary can still run while a later
+// release's migrations roll out.
+func CheckSchemaVersion(ctx context.Context, pool *pgxpool.Pool) error {
+	version, dirty, err := MigrationVersion(ctx, pool)
+	if err != nil {
+		return err
+	}
+	if dirty || version < RequiredSchemaVersion {
+		return &SchemaVersionError{Actual: version, Expected: RequiredSchemaVersion, Dirty: dirty}
+	}
+	return nil
+}
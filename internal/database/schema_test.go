@@ -0,0 +1,48 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRequiredSchemaVersion(t *testing.T) {
+	t.Run("matches the latest migration", func(t *testing.T) {
+		entries, err := os.ReadDir(filepath.Join("..", "..", "migrations"))
+		if err != nil {
+			t.Fatalf("failed to read migrations: %v", err)
+		}
+
+		var latest uint64
+		for _, entry := range entries {
+			prefix, _, ok := strings.Cut(entry.Name(), "_")
+			if !ok || !strings.HasSuffix(entry.Name(), ".up.sql") {
+				continue
+			}
+			version, err := strconv.ParseUint(prefix, 10, 64)
+			if err != nil {
+				t.Fatalf("unexpected migration name %q", entry.Name())
+			}
+			latest = max(latest, version)
+		}
+
+		if latest != RequiredSchemaVersion {
+			t.Errorf("RequiredSchemaVersion is %d but the latest migration is %d", RequiredSchemaVersion, latest)
+		}
+	})
+}
+
+func TestSchemaVersionError(t *testing.T) {
+	t.Run("matches ErrSchemaOutdated", func(t *testing.T) {
+		err := error(&SchemaVersionError{Actual: 3, Expected: 9})
+		if !errors.Is(err, ErrSchemaOutdated) {
+			t.Error("expected errors.Is to match ErrSchemaOutdated")
+		}
+		if !strings.Contains(err.Error(), "behind required version 9") {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+	})
+}
@@ -0,0 +1,130 @@
+// Package events fans order events out to in-process subscribers such as SSE streams,
+// audit loggers, or webhook senders.
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types carried by the dispatcher. They match the Kafka topics the events are
+// published to.
+const (
+	TypeOrderCreated   = "order.created"
+	TypeOrderProcessed = "order.processed"
+	TypeOrderFailed    = "order.failed"
+)
+
+const defaultBufferSize = 64
+
+// Event is an order lifecycle event delivered to subscribers.
+type Event struct {
+	Type       string
+	OrderID    string
+	Reason     string
+	OccurredAt time.Time
+}
+
+// Option customizes a Dispatcher.
+type Option func(*Dispatcher)
+
+// WithBufferSize sets how many undelivered events each subscriber may queue before new
+// events are dropped for it.
+func WithBufferSize(size int) Option {
+	return func(d *Dispatcher) {
+		if size > 0 {
+			d.bufferSize = size
+		}
+	}
+}
+
+// Dispatcher delivers published events to every subscriber without blocking the publisher:
+// a subscriber whose buffer is full misses the event instead of stalling the others.
+type Dispatcher struct {
+	bufferSize int
+
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+// NewDispatcher returns a dispatcher with no subscribers.
+func NewDispatcher(opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		bufferSize:  defaultBufferSize,
+		subscribers: map[*Subscription]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Subscribe registers a new subscriber. Callers must Close the subscription when done.
+func (d *Dispatcher) Subscribe() *Subscription {
+	sub := &Subscription{
+		events:     make(chan Event, d.bufferSize),
+		dispatcher: d,
+	}
+
+	d.mu.Lock()
+	d.subscribers[sub] = struct{}{}
+	d.mu.Unlock()
+
+	return sub
+}
+
+// Publish delivers event to every current subscriber that has buffer space.
+func (d *Dispatcher) Publish(_ context.Context, event Event) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for sub := range d.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribers returns the number of active subscriptions.
+func (d *Dispatcher) Subscribers() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.subscribers)
+}
+
+func (d *Dispatcher) unsubscribe(sub *Subscription) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.subscribers[sub]; !ok {
+		return
+	}
+	delete(d.subscribers, sub)
+	close(sub.events)
+}
+
+// Subscription receives events from a Dispatcher.
+type Subscription struct {
+	events     chan Event
+	dispatcher *Dispatcher
+	dropped    atomic.Uint64
+}
+
+// Events returns the channel events are delivered on. It is closed by Close.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns how many events were discarded because the subscriber fell behind.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and closes the events channel. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.dispatcher.unsubscribe(s)
+}
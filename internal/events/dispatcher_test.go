@@ -0,0 +1,62 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/events"
+)
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delivers each event to every subscriber", func(t *testing.T) {
+		dispatcher := events.NewDispatcher()
+		first := dispatcher.Subscribe()
+		defer first.Close()
+		second := dispatcher.Subscribe()
+		defer second.Close()
+
+		dispatcher.Publish(ctx, events.Event{Type: events.TypeOrderCreated, OrderID: "order-1"})
+
+		for _, sub := range []*events.Subscription{first, second} {
+			got := <-sub.Events()
+			if got.OrderID != "order-1" || got.Type != events.TypeOrderCreated {
+				t.Errorf("unexpected event %+v", got)
+			}
+		}
+	})
+
+	t.Run("drops events for a full subscriber without blocking", func(t *testing.T) {
+		dispatcher := events.NewDispatcher(events.WithBufferSize(1))
+		slow := dispatcher.Subscribe()
+		defer slow.Close()
+
+		for range 3 {
+			dispatcher.Publish(ctx, events.Event{Type: events.TypeOrderCreated, OrderID: "order-1"})
+		}
+
+		if got := slow.Dropped(); got != 2 {
+			t.Errorf("expected 2 dropped events, got %d", got)
+		}
+		if got := len(slow.Events()); got != 1 {
+			t.Errorf("expected 1 buffered event, got %d", got)
+		}
+	})
+
+	t.Run("stops delivering after close", func(t *testing.T) {
+		dispatcher := events.NewDispatcher()
+		sub := dispatcher.Subscribe()
+		sub.Close()
+		sub.Close()
+
+		dispatcher.Publish(ctx, events.Event{Type: events.TypeOrderFailed, OrderID: "order-1"})
+
+		if _, ok := <-sub.Events(); ok {
+			t.Error("expected closed events channel")
+		}
+		if got := dispatcher.Subscribers(); got != 0 {
+			t.Errorf("expected 0 subscribers, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// Store keeps idempotent responses in memory. Like the postgres store, the first response
+// saved for a key wins and later saves are ignored.
+type Store struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+type entry struct {
+	response  ports.StoredResponse
+	createdAt time.Time
+}
+
+func NewStore() *Store {
+	return &Store{entries: make(map[string]entry)}
+}
+
+func (s *Store) Get(_ context.Context, key string) (*ports.StoredResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	resp := stored.response
+	resp.Body = append([]byte(nil), resp.Body...)
+	return &resp, nil
+}
+
+func (s *Store) Save(_ context.Context, key string, response ports.StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; exists {
+		return nil
+	}
+	response.Body = append([]byte(nil), response.Body...)
+	s.entries[key] = entry{response: response, createdAt: time.Now().UTC()}
+	return nil
+}
+
+func (s *Store) CountSince(_ context.Context, since time.Time) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, stored := range s.entries {
+		if !stored.createdAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
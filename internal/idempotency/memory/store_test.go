@@ -0,0 +1,38 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/idempotency/memory"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/dejobratic/tbd/internal/orders/ports/portstest"
+)
+
+func TestIdempotencyStoreConformance(t *testing.T) {
+	portstest.RunIdempotencyStoreTests(t, func(t *testing.T) ports.IdempotencyStore {
+		return memory.NewStore()
+	})
+}
+
+func TestStoreSave(t *testing.T) {
+	t.Run("preserves first response on duplicate save", func(t *testing.T) {
+		store := memory.NewStore()
+		ctx := context.Background()
+
+		if err := store.Save(ctx, "key", ports.StoredResponse{StatusCode: 202, OrderID: "order-1"}); err != nil {
+			t.Fatalf("failed to save first response: %v", err)
+		}
+		if err := store.Save(ctx, "key", ports.StoredResponse{StatusCode: 200, OrderID: "order-2"}); err != nil {
+			t.Fatalf("failed to save second response: %v", err)
+		}
+
+		got, err := store.Get(ctx, "key")
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		if got.OrderID != "order-1" {
+			t.Errorf("expected first response to be preserved, got order ID %s", got.OrderID)
+		}
+	})
+}
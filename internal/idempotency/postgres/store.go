@@ -4,32 +4,58 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/dejobratic/tbd/internal/database"
 	"github.com/dejobratic/tbd/internal/orders/ports"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// DefaultSchema is the schema holding idempotency_keys unless WithSchema overrides it.
+const DefaultSchema = "public"
+
 type Store struct {
-	pool *pgxpool.Pool
+	pool  *pgxpool.Pool
+	table string
+}
+
+// Option customizes a Store.
+type Option func(*Store)
+
+// WithSchema qualifies the idempotency_keys table with schema. It panics if schema is not a
+// plain identifier; validate configured values with database.ValidateIdentifier first.
+func WithSchema(schema string) Option {
+	if err := database.ValidateIdentifier(schema); err != nil {
+		panic(fmt.Sprintf("postgres.WithSchema: %v", err))
+	}
+	return func(s *Store) {
+		s.table = pgx.Identifier{schema, "idempotency_keys"}.Sanitize()
+	}
 }
 
-func NewStore(pool *pgxpool.Pool) *Store {
-	return &Store{pool: pool}
+func NewStore(pool *pgxpool.Pool, opts ...Option) *Store {
+	s := &Store{pool: pool, table: pgx.Identifier{DefaultSchema, "idempotency_keys"}.Sanitize()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *Store) Get(ctx context.Context, key string) (*ports.StoredResponse, error) {
 	query := `
-		SELECT status_code, body, order_id
-		FROM idempotency_keys
+		SELECT status_code, body, order_id, retry_after_ms
+		FROM ` + s.table + `
 		WHERE key = $1
 	`
 
 	var resp ports.StoredResponse
+	var retryAfterMS int64
 	err := s.pool.QueryRow(ctx, query, key).Scan(
 		&resp.StatusCode,
 		&resp.Body,
 		&resp.OrderID,
+		&retryAfterMS,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -37,21 +63,33 @@ func (s *Store) Get(ctx context.Context, key string) (*ports.StoredResponse, err
 		}
 		return nil, fmt.Errorf("select idempotency key: %w", err)
 	}
+	resp.RetryAfter = time.Duration(retryAfterMS) * time.Millisecond
 
 	return &resp, nil
 }
 
 func (s *Store) Save(ctx context.Context, key string, response ports.StoredResponse) error {
 	query := `
-		INSERT INTO idempotency_keys (key, status_code, body, order_id)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO ` + s.table + ` (key, status_code, body, order_id, retry_after_ms)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (key) DO NOTHING
 	`
 
-	_, err := s.pool.Exec(ctx, query, key, response.StatusCode, response.Body, response.OrderID)
+	_, err := s.pool.Exec(ctx, query, key, response.StatusCode, response.Body, response.OrderID, response.RetryAfter.Milliseconds())
 	if err != nil {
 		return fmt.Errorf("insert idempotency key: %w", err)
 	}
 
 	return nil
 }
+
+func (s *Store) CountSince(ctx context.Context, since time.Time) (int64, error) {
+	query := `SELECT count(*) FROM ` + s.table + ` WHERE created_at >= $1`
+
+	var count int64
+	if err := s.pool.QueryRow(ctx, query, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count idempotency keys: %w", err)
+	}
+
+	return count, nil
+}
@@ -7,10 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/dejobratic/tbd/internal/database"
 	"github.com/dejobratic/tbd/internal/idempotency/postgres"
 	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/dejobratic/tbd/internal/orders/ports/portstest"
 	"github.com/jackc/pgx/v5/pgxpool"
 	testpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -92,6 +94,7 @@ func TestStoreIdempotencyKey(t *testing.T) {
 			StatusCode: 201,
 			Body:       []byte(`{"order_id": "test-order-1"}`),
 			OrderID:    "test-order-1",
+			RetryAfter: 1500 * time.Millisecond,
 		}
 
 		err := store.Save(ctx, key, response)
@@ -119,6 +122,10 @@ func TestStoreIdempotencyKey(t *testing.T) {
 		if retrieved.OrderID != response.OrderID {
 			t.Errorf("expected order ID %s, got %s", response.OrderID, retrieved.OrderID)
 		}
+
+		if retrieved.RetryAfter != response.RetryAfter {
+			t.Errorf("expected retry after %v, got %v", response.RetryAfter, retrieved.RetryAfter)
+		}
 	})
 
 	t.Run("returns nil when key not found", func(t *testing.T) {
@@ -163,3 +170,14 @@ func TestStoreIdempotencyKey(t *testing.T) {
 		}
 	})
 }
+
+func TestIdempotencyStoreConformance(t *testing.T) {
+	pool := setupTestDB(t)
+
+	portstest.RunIdempotencyStoreTests(t, func(t *testing.T) ports.IdempotencyStore {
+		if _, err := pool.Exec(context.Background(), "TRUNCATE idempotency_keys"); err != nil {
+			t.Fatalf("failed to truncate idempotency keys: %v", err)
+		}
+		return postgres.NewStore(pool)
+	})
+}
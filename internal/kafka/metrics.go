@@ -8,6 +8,9 @@ import (
 	"go.opentelemetry.io/otel/metric"
 )
 
+// ScopeName is the instrumentation scope to create the meter for Kafka producer metrics with.
+const ScopeName = "github.com/dejobratic/tbd/internal/kafka"
+
 type Metrics struct {
 	producerLatency metric.Float64Histogram
 }
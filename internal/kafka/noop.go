@@ -27,3 +27,35 @@ func (n *NoopEventBus) PublishOrderFailed(_ context.Context, orderID string, rea
 	slog.Debug("event::order_failed", "order_id", orderID, "reason", reason)
 	return nil
 }
+
+// HealthCheck always succeeds; there is no broker to reach.
+func (n *NoopEventBus) HealthCheck(context.Context) error {
+	return nil
+}
+
+// RecordingEventBus logs events like NoopEventBus and additionally keeps them in memory so
+// integration tests can verify what was emitted.
+type RecordingEventBus struct {
+	*SpyEventBus
+	noop NoopEventBus
+}
+
+// NewRecordingEventBus returns a no-op event publisher that records published events.
+func NewRecordingEventBus() *RecordingEventBus {
+	return &RecordingEventBus{SpyEventBus: NewSpyEventBus()}
+}
+
+func (r *RecordingEventBus) PublishOrderCreated(ctx context.Context, orderID string) error {
+	_ = r.noop.PublishOrderCreated(ctx, orderID)
+	return r.SpyEventBus.PublishOrderCreated(ctx, orderID)
+}
+
+func (r *RecordingEventBus) PublishOrderProcessed(ctx context.Context, orderID string) error {
+	_ = r.noop.PublishOrderProcessed(ctx, orderID)
+	return r.SpyEventBus.PublishOrderProcessed(ctx, orderID)
+}
+
+func (r *RecordingEventBus) PublishOrderFailed(ctx context.Context, orderID string, reason string) error {
+	_ = r.noop.PublishOrderFailed(ctx, orderID, reason)
+	return r.SpyEventBus.PublishOrderFailed(ctx, orderID, reason)
+}
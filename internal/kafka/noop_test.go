@@ -0,0 +1,52 @@
+package kafka_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/kafka"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+var (
+	_ ports.EventBus = (*kafka.NoopEventBus)(nil)
+	_ ports.EventBus = (*kafka.RecordingEventBus)(nil)
+	_ ports.EventBus = (*kafka.SpyEventBus)(nil)
+	_ ports.EventBus = (*kafka.Producer)(nil)
+)
+
+func TestRecordingEventBus(t *testing.T) {
+	t.Run("records events while publishing nothing", func(t *testing.T) {
+		bus := kafka.NewRecordingEventBus()
+		ctx := context.Background()
+
+		if err := bus.PublishOrderCreated(ctx, "order-1"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := bus.PublishOrderFailed(ctx, "order-1", "timeout"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		events := bus.Events()
+		if len(events) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(events))
+		}
+		if events[1].Type != kafka.EventOrderFailed || events[1].Reason != "timeout" {
+			t.Errorf("expected failed event with reason, got %+v", events[1])
+		}
+	})
+
+	t.Run("zero-value noop bus still publishes nothing", func(t *testing.T) {
+		var bus kafka.NoopEventBus
+
+		if err := bus.PublishOrderCreated(context.Background(), "order-1"); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("noop bus is always healthy", func(t *testing.T) {
+		if err := kafka.NewNoopEventBus().HealthCheck(context.Background()); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/cloudevents"
+	"github.com/dejobratic/tbd/internal/retry"
+)
+
+// CloudEventTypePrefix namespaces event types, e.g. "com.tbd.order.created".
+const CloudEventTypePrefix = "com.tbd."
+
+// Message is a record written to a Kafka topic.
+type Message struct {
+	Topic string
+	Key   []byte
+	Value []byte
+}
+
+// Writer writes messages to Kafka. Implementations are expected to use a key-hash
+// partitioner, so that messages sharing a key land on the same partition in write order.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...Message) error
+}
+
+// Pinger is implemented by writers that can probe the cluster, e.g. by fetching topic
+// metadata, without writing a message.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Producer publishes order lifecycle events through a Writer as CloudEvents. Every message
+// is keyed by order ID so that events for one order (created, then processed or failed)
+// keep their relative order for consumers.
+type Producer struct {
+	writer     Writer
+	serializer *cloudevents.Serializer
+	topics     Topics
+	retry      retry.Policy
+	now        func() time.Time
+}
+
+// ProducerOption customizes a Producer.
+type ProducerOption func(*Producer)
+
+// WithTopics sets the topics events are written to. Defaults to DefaultTopics.
+func WithTopics(topics Topics) ProducerOption {
+	return func(p *Producer) {
+		p.topics = topics
+	}
+}
+
+// WithRetry retries failed writes according to policy. By default a write is attempted once.
+func WithRetry(policy retry.Policy) ProducerOption {
+	return func(p *Producer) {
+		p.retry = policy
+	}
+}
+
+// NewProducer returns a producer that writes events with the given writer, using source as
+// the CloudEvents source attribute.
+func NewProducer(writer Writer, source string, opts ...ProducerOption) *Producer {
+	p := &Producer{
+		writer:     writer,
+		serializer: cloudevents.NewSerializer(source, CloudEventTypePrefix),
+		topics:     DefaultTopics(),
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Event is an order lifecycle event before it is mapped to the wire format.
+type Event struct {
+	Type       string
+	OrderID    string
+	Reason     string
+	OccurredAt time.Time
+}
+
+type eventData struct {
+	OrderID string `json:"order_id"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+func (p *Producer) PublishOrderCreated(ctx context.Context, orderID string) error {
+	return p.publish(ctx, Event{Type: EventOrderCreated, OrderID: orderID})
+}
+
+func (p *Producer) PublishOrderProcessed(ctx context.Context, orderID string) error {
+	return p.publish(ctx, Event{Type: EventOrderProcessed, OrderID: orderID})
+}
+
+func (p *Producer) PublishOrderFailed(ctx context.Context, orderID string, reason string) error {
+	return p.publish(ctx, Event{Type: EventOrderFailed, OrderID: orderID, Reason: reason})
+}
+
+// HealthCheck probes the cluster when the writer implements Pinger. Writers that cannot be
+// probed are assumed healthy; publish errors still surface on each write.
+func (p *Producer) HealthCheck(ctx context.Context) error {
+	pinger, ok := p.writer.(Pinger)
+	if !ok {
+		return nil
+	}
+	if err := pinger.Ping(ctx); err != nil {
+		return fmt.Errorf("kafka: ping: %w", err)
+	}
+	return nil
+}
+
+func (p *Producer) publish(ctx context.Context, event Event) error {
+	event.OccurredAt = p.now()
+
+	payload, err := p.serializer.Serialize(ctx, event.Type, event.OrderID, event.OccurredAt, eventData{
+		OrderID: event.OrderID,
+		Reason:  event.Reason,
+	})
+	if err != nil {
+		return fmt.Errorf("serialize %s event: %w", event.Type, err)
+	}
+
+	msg := Message{
+		Topic: p.topics.For(event.Type),
+		Key:   []byte(event.OrderID),
+		Value: payload,
+	}
+	err = retry.Do(ctx, p.retry, func() error {
+		return p.writer.WriteMessages(ctx, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("write %s event: %w", event.Type, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package kafka_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/cloudevents"
+	"github.com/dejobratic/tbd/internal/kafka"
+	"github.com/dejobratic/tbd/internal/retry"
+)
+
+// fakeWriter assigns messages to partitions by key hash, like the real writer's balancer,
+// and records them per partition in write order.
+type fakeWriter struct {
+	mu         sync.Mutex
+	partitions map[int][]kafka.Message
+	count      int
+	err        error
+}
+
+func newFakeWriter(partitions int) *fakeWriter {
+	return &fakeWriter{partitions: map[int][]kafka.Message{}, count: partitions}
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, msg := range msgs {
+		h := fnv.New32a()
+		_, _ = h.Write(msg.Key)
+		partition := int(h.Sum32() % uint32(w.count))
+		w.partitions[partition] = append(w.partitions[partition], msg)
+	}
+	return nil
+}
+
+func (w *fakeWriter) messagesForKey(key string) []kafka.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var result []kafka.Message
+	seen := -1
+	for partition, msgs := range w.partitions {
+		for _, msg := range msgs {
+			if string(msg.Key) != key {
+				continue
+			}
+			if seen != -1 && seen != partition {
+				return nil
+			}
+			seen = partition
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func TestProducer(t *testing.T) {
+	t.Run("keys every message by order ID", func(t *testing.T) {
+		writer := newFakeWriter(1)
+		producer := kafka.NewProducer(writer, "/tbd-api")
+		ctx := context.Background()
+
+		_ = producer.PublishOrderCreated(ctx, "order-1")
+		_ = producer.PublishOrderFailed(ctx, "order-2", "declined")
+
+		for _, msg := range writer.partitions[0] {
+			var envelope cloudevents.Envelope
+			if err := json.Unmarshal(msg.Value, &envelope); err != nil {
+				t.Fatalf("failed to decode payload: %v", err)
+			}
+			if string(msg.Key) != envelope.Subject {
+				t.Errorf("expected key %s, got %s", envelope.Subject, msg.Key)
+			}
+			if envelope.Type != kafka.CloudEventTypePrefix+msg.Topic {
+				t.Errorf("expected type %s, got %s", kafka.CloudEventTypePrefix+msg.Topic, envelope.Type)
+			}
+		}
+	})
+
+	t.Run("preserves order of events for the same order", func(t *testing.T) {
+		writer := newFakeWriter(8)
+		producer := kafka.NewProducer(writer, "/tbd-api")
+		ctx := context.Background()
+
+		_ = producer.PublishOrderCreated(ctx, "order-1")
+		_ = producer.PublishOrderCreated(ctx, "order-2")
+		_ = producer.PublishOrderProcessed(ctx, "order-2")
+		_ = producer.PublishOrderProcessed(ctx, "order-1")
+		_ = producer.PublishOrderFailed(ctx, "order-3", "timeout")
+
+		msgs := writer.messagesForKey("order-1")
+		if len(msgs) != 2 {
+			t.Fatalf("expected 2 messages on a single partition for order-1, got %d", len(msgs))
+		}
+		if msgs[0].Topic != kafka.EventOrderCreated || msgs[1].Topic != kafka.EventOrderProcessed {
+			t.Errorf("expected created before processed, got %s then %s", msgs[0].Topic, msgs[1].Topic)
+		}
+	})
+
+	t.Run("writes to the configured topics", func(t *testing.T) {
+		writer := newFakeWriter(1)
+		producer := kafka.NewProducer(writer, "/tbd-api", kafka.WithTopics(kafka.Topics{
+			OrderCreated: "prod.order.created",
+			OrderFailed:  "prod.order.failed",
+		}))
+		ctx := context.Background()
+
+		_ = producer.PublishOrderCreated(ctx, "order-1")
+		_ = producer.PublishOrderProcessed(ctx, "order-1")
+		_ = producer.PublishOrderFailed(ctx, "order-2", "declined")
+
+		want := []string{"prod.order.created", kafka.EventOrderProcessed, "prod.order.failed"}
+		for i, msg := range writer.partitions[0] {
+			if msg.Topic != want[i] {
+				t.Errorf("message %d: expected topic %s, got %s", i, want[i], msg.Topic)
+			}
+		}
+	})
+
+	t.Run("retries failed writes", func(t *testing.T) {
+		writer := &flakyWriter{fakeWriter: newFakeWriter(1), failures: 2}
+		producer := kafka.NewProducer(writer, "/tbd-api", kafka.WithRetry(retry.Policy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		}))
+
+		if err := producer.PublishOrderCreated(context.Background(), "order-1"); err != nil {
+			t.Fatalf("expected publish to succeed after retries, got %v", err)
+		}
+		if got := len(writer.messagesForKey("order-1")); got != 1 {
+			t.Errorf("expected 1 message written, got %d", got)
+		}
+	})
+
+	t.Run("returns writer error", func(t *testing.T) {
+		writerErr := errors.New("leader not available")
+		writer := newFakeWriter(1)
+		writer.err = writerErr
+		producer := kafka.NewProducer(writer, "/tbd-api")
+
+		err := producer.PublishOrderCreated(context.Background(), "order-1")
+
+		if !errors.Is(err, writerErr) {
+			t.Errorf("expected writer error, got %v", err)
+		}
+	})
+}
+
+// flakyWriter fails the first failures writes before delegating to fakeWriter.
+type flakyWriter struct {
+	*fakeWriter
+	failures int
+}
+
+func (w *flakyWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("leader not available")
+	}
+	return w.fakeWriter.WriteMessages(ctx, msgs...)
+}
+
+// pingingWriter is a writer that can also probe the cluster.
+type pingingWriter struct {
+	*fakeWriter
+	err error
+}
+
+func (w pingingWriter) Ping(context.Context) error {
+	return w.err
+}
+
+func TestProducerHealthCheck(t *testing.T) {
+	t.Run("reports a failed ping", func(t *testing.T) {
+		unreachable := errors.New("no brokers reachable")
+		producer := kafka.NewProducer(pingingWriter{fakeWriter: newFakeWriter(1), err: unreachable}, "/tbd-api")
+
+		if err := producer.HealthCheck(context.Background()); !errors.Is(err, unreachable) {
+			t.Errorf("expected ping error, got %v", err)
+		}
+	})
+
+	t.Run("is healthy when the ping succeeds", func(t *testing.T) {
+		producer := kafka.NewProducer(pingingWriter{fakeWriter: newFakeWriter(1)}, "/tbd-api")
+
+		if err := producer.HealthCheck(context.Background()); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("assumes writers without ping are healthy", func(t *testing.T) {
+		producer := kafka.NewProducer(newFakeWriter(1), "/tbd-api")
+
+		if err := producer.HealthCheck(context.Background()); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
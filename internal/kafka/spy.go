@@ -0,0 +1,118 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+)
+
+// Event types recorded by SpyEventBus. They match the topics events are published to.
+const (
+	EventOrderCreated   = "order.created"
+	EventOrderProcessed = "order.processed"
+	EventOrderFailed    = "order.failed"
+)
+
+// PublishedEvent is an event captured by SpyEventBus.
+type PublishedEvent struct {
+	Type    string
+	OrderID string
+	Reason  string
+}
+
+// SpyEventBus records published events in memory so tests can assert which events fired.
+// Failures can be injected per event type with FailOn; failed publishes are not recorded.
+type SpyEventBus struct {
+	mu       sync.Mutex
+	events   []PublishedEvent
+	failures map[string]error
+}
+
+// NewSpyEventBus returns an empty spy event bus.
+func NewSpyEventBus() *SpyEventBus {
+	return &SpyEventBus{failures: map[string]error{}}
+}
+
+// FailOn makes publishing the given event type return err. A nil err clears the failure.
+func (s *SpyEventBus) FailOn(eventType string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		delete(s.failures, eventType)
+		return
+	}
+	s.failures[eventType] = err
+}
+
+func (s *SpyEventBus) PublishOrderCreated(_ context.Context, orderID string) error {
+	return s.record(PublishedEvent{Type: EventOrderCreated, OrderID: orderID})
+}
+
+func (s *SpyEventBus) PublishOrderProcessed(_ context.Context, orderID string) error {
+	return s.record(PublishedEvent{Type: EventOrderProcessed, OrderID: orderID})
+}
+
+func (s *SpyEventBus) PublishOrderFailed(_ context.Context, orderID string, reason string) error {
+	return s.record(PublishedEvent{Type: EventOrderFailed, OrderID: orderID, Reason: reason})
+}
+
+// HealthCheck always succeeds.
+func (s *SpyEventBus) HealthCheck(context.Context) error {
+	return nil
+}
+
+// Events returns every recorded event in publish order.
+func (s *SpyEventBus) Events() []PublishedEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]PublishedEvent(nil), s.events...)
+}
+
+// PublishedCreated returns the order IDs of recorded order.created events.
+func (s *SpyEventBus) PublishedCreated() []string {
+	return s.orderIDs(EventOrderCreated)
+}
+
+// PublishedProcessed returns the order IDs of recorded order.processed events.
+func (s *SpyEventBus) PublishedProcessed() []string {
+	return s.orderIDs(EventOrderProcessed)
+}
+
+// PublishedFailed returns the order IDs of recorded order.failed events.
+func (s *SpyEventBus) PublishedFailed() []string {
+	return s.orderIDs(EventOrderFailed)
+}
+
+// Reset clears recorded events and injected failures.
+func (s *SpyEventBus) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = nil
+	s.failures = map[string]error{}
+}
+
+func (s *SpyEventBus) record(event PublishedEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err, ok := s.failures[event.Type]; ok {
+		return err
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *SpyEventBus) orderIDs(eventType string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []string
+	for _, event := range s.events {
+		if event.Type == eventType {
+			ids = append(ids, event.OrderID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,79 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/kafka"
+)
+
+func TestSpyEventBus(t *testing.T) {
+	t.Run("records published events in order", func(t *testing.T) {
+		spy := kafka.NewSpyEventBus()
+		ctx := context.Background()
+
+		_ = spy.PublishOrderCreated(ctx, "order-1")
+		_ = spy.PublishOrderProcessed(ctx, "order-1")
+		_ = spy.PublishOrderFailed(ctx, "order-2", "payment declined")
+
+		events := spy.Events()
+		want := []kafka.PublishedEvent{
+			{Type: kafka.EventOrderCreated, OrderID: "order-1"},
+			{Type: kafka.EventOrderProcessed, OrderID: "order-1"},
+			{Type: kafka.EventOrderFailed, OrderID: "order-2", Reason: "payment declined"},
+		}
+		if len(events) != len(want) {
+			t.Fatalf("expected %d events, got %d", len(want), len(events))
+		}
+		for i := range want {
+			if events[i] != want[i] {
+				t.Errorf("expected event %d to be %+v, got %+v", i, want[i], events[i])
+			}
+		}
+
+		if got := spy.PublishedCreated(); len(got) != 1 || got[0] != "order-1" {
+			t.Errorf("expected created [order-1], got %v", got)
+		}
+		if got := spy.PublishedFailed(); len(got) != 1 || got[0] != "order-2" {
+			t.Errorf("expected failed [order-2], got %v", got)
+		}
+	})
+
+	t.Run("returns injected error without recording", func(t *testing.T) {
+		spy := kafka.NewSpyEventBus()
+		publishErr := errors.New("broker down")
+		spy.FailOn(kafka.EventOrderCreated, publishErr)
+
+		err := spy.PublishOrderCreated(context.Background(), "order-1")
+
+		if !errors.Is(err, publishErr) {
+			t.Errorf("expected injected error, got %v", err)
+		}
+		if got := spy.PublishedCreated(); len(got) != 0 {
+			t.Errorf("expected no recorded events, got %v", got)
+		}
+		if err := spy.PublishOrderProcessed(context.Background(), "order-1"); err != nil {
+			t.Errorf("expected other event types to succeed, got %v", err)
+		}
+	})
+
+	t.Run("records concurrent publishes", func(t *testing.T) {
+		spy := kafka.NewSpyEventBus()
+
+		var wg sync.WaitGroup
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = spy.PublishOrderCreated(context.Background(), "order")
+			}()
+		}
+		wg.Wait()
+
+		if got := len(spy.PublishedCreated()); got != 50 {
+			t.Errorf("expected 50 events, got %d", got)
+		}
+	})
+}
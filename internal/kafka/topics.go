@@ -0,0 +1,34 @@
+package kafka
+
+// Topics names the Kafka topic each order event type is published to.
+type Topics struct {
+	OrderCreated   string
+	OrderProcessed string
+	OrderFailed    string
+}
+
+// DefaultTopics publishes every event to a topic named after its type.
+func DefaultTopics() Topics {
+	return Topics{
+		OrderCreated:   EventOrderCreated,
+		OrderProcessed: EventOrderProcessed,
+		OrderFailed:    EventOrderFailed,
+	}
+}
+
+// For returns the topic for eventType. Unknown or unset types fall back to the event type.
+func (t Topics) For(eventType string) string {
+	var topic string
+	switch eventType {
+	case EventOrderCreated:
+		topic = t.OrderCreated
+	case EventOrderProcessed:
+		topic = t.OrderProcessed
+	case EventOrderFailed:
+		topic = t.OrderFailed
+	}
+	if topic == "" {
+		return eventType
+	}
+	return topic
+}
@@ -0,0 +1,23 @@
+package kafka_test
+
+import (
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/kafka"
+)
+
+func TestTopicsFor(t *testing.T) {
+	topics := kafka.Topics{OrderCreated: "prod.order.created"}
+
+	t.Run("returns the configured topic", func(t *testing.T) {
+		if got := topics.For(kafka.EventOrderCreated); got != "prod.order.created" {
+			t.Errorf("expected prod.order.created, got %s", got)
+		}
+	})
+
+	t.Run("falls back to the event type when unset", func(t *testing.T) {
+		if got := topics.For(kafka.EventOrderFailed); got != kafka.EventOrderFailed {
+			t.Errorf("expected %s, got %s", kafka.EventOrderFailed, got)
+		}
+	})
+}
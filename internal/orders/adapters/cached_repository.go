@@ -0,0 +1,185 @@
+package adapters
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/cache"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+const ordersCacheName = "orders"
+
+// CachedRepository serves GetByID from a bounded, TTL'd LRU and invalidates entries on writes.
+// A load that was in flight when its key was invalidated is not cached, so a read racing a
+// write cannot cache the row as it was before the write. Misses are loaded with
+// ports.WithPrimaryReads, so a cache over a replica router is not filled from a lagging
+// replica; reads already marked that way skip the cache.
+type CachedRepository struct {
+	repo    ports.OrderRepository
+	cache   *cache.LRU[string, domain.Order]
+	metrics *cache.Metrics
+
+	mu    sync.Mutex
+	fills map[string]*fill
+}
+
+// fill tracks the loads in flight for one key. generation is bumped by each invalidation;
+// a load only caches its result if the generation is unchanged since it started.
+type fill struct {
+	generation uint64
+	loads      int
+}
+
+func NewCachedRepository(repo ports.OrderRepository, size int, ttl time.Duration, metrics *cache.Metrics) *CachedRepository {
+	return &CachedRepository{
+		repo:    repo,
+		cache:   cache.NewLRU[string, domain.Order](size, ttl),
+		metrics: metrics,
+		fills:   map[string]*fill{},
+	}
+}
+
+func (r *CachedRepository) Create(ctx context.Context, order domain.Order) error {
+	err := r.repo.Create(ctx, order)
+	r.invalidate(order.ID)
+	return err
+}
+
+func (r *CachedRepository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	if ports.PrimaryReads(ctx) {
+		return r.repo.GetByID(ctx, id)
+	}
+	if order, ok := r.cache.Get(id); ok {
+		r.metrics.RecordLookup(ctx, ordersCacheName, true)
+		return &order, nil
+	}
+	r.metrics.RecordLookup(ctx, ordersCacheName, false)
+
+	generation := r.startFill(id)
+	order, err := r.repo.GetByID(ports.WithPrimaryReads(ctx), id)
+	if err != nil {
+		r.endFill(id, generation, nil)
+		return nil, err
+	}
+	r.endFill(id, generation, order)
+	return order, nil
+}
+
+// GetByIDs serves cached orders and loads only the misses from the underlying repository.
+func (r *CachedRepository) GetByIDs(ctx context.Context, ids []string) (map[string]domain.Order, error) {
+	if ports.PrimaryReads(ctx) {
+		return r.repo.GetByIDs(ctx, ids)
+	}
+	orders := make(map[string]domain.Order, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if order, ok := r.cache.Get(id); ok {
+			r.metrics.RecordLookup(ctx, ordersCacheName, true)
+			orders[id] = order
+			continue
+		}
+		r.metrics.RecordLookup(ctx, ordersCacheName, false)
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return orders, nil
+	}
+
+	generations := make([]uint64, len(missing))
+	for i, id := range missing {
+		generations[i] = r.startFill(id)
+	}
+	loaded, err := r.repo.GetByIDs(ports.WithPrimaryReads(ctx), missing)
+	for i, id := range missing {
+		var order *domain.Order
+		if found, ok := loaded[id]; ok && err == nil {
+			order = &found
+		}
+		r.endFill(id, generations[i], order)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for id, order := range loaded {
+		orders[id] = order
+	}
+	return orders, nil
+}
+
+func (r *CachedRepository) GetByReference(ctx context.Context, reference string) (*domain.Order, error) {
+	return r.repo.GetByReference(ctx, reference)
+}
+
+func (r *CachedRepository) Exists(ctx context.Context, id string) (bool, error) {
+	if _, ok := r.cache.Get(id); ok && !ports.PrimaryReads(ctx) {
+		return true, nil
+	}
+	return r.repo.Exists(ctx, id)
+}
+
+func (r *CachedRepository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
+	return r.repo.List(ctx, filter)
+}
+
+func (r *CachedRepository) Count(ctx context.Context, filter ports.ListFilter) (int, error) {
+	return r.repo.Count(ctx, filter)
+}
+
+func (r *CachedRepository) DistinctCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error) {
+	return r.repo.DistinctCustomers(ctx, filter)
+}
+
+func (r *CachedRepository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
+	err := r.repo.UpdateStatus(ctx, id, status)
+	r.invalidate(id)
+	return err
+}
+
+func (r *CachedRepository) AppendStatusHistory(ctx context.Context, id string, transition domain.StatusTransition) error {
+	return r.repo.AppendStatusHistory(ctx, id, transition)
+}
+
+func (r *CachedRepository) GetStatusHistory(ctx context.Context, id string) ([]domain.StatusTransition, error) {
+	return r.repo.GetStatusHistory(ctx, id)
+}
+
+// startFill registers a load of id and returns the generation it started at.
+func (r *CachedRepository) startFill(id string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, ok := r.fills[id]
+	if !ok {
+		f = &fill{}
+		r.fills[id] = f
+	}
+	f.loads++
+	return f.generation
+}
+
+// endFill caches order, when the load succeeded, unless id was invalidated since the load
+// started.
+func (r *CachedRepository) endFill(id string, generation uint64, order *domain.Order) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.fills[id]
+	if order != nil && f.generation == generation {
+		r.cache.Set(id, *order)
+	}
+	f.loads--
+	if f.loads == 0 {
+		delete(r.fills, id)
+	}
+}
+
+// invalidate drops id from the cache and stops loads in flight from caching it.
+func (r *CachedRepository) invalidate(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.fills[id]; ok {
+		f.generation++
+	}
+	r.cache.Delete(id)
+}
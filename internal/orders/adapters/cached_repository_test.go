@@ -0,0 +1,171 @@
+package adapters_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/cache"
+	"github.com/dejobratic/tbd/internal/orders/adapters"
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// pausingRepository holds a GetByID after it has read the order, until release is closed.
+type pausingRepository struct {
+	ports.OrderRepository
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+func (r *pausingRepository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	order, err := r.OrderRepository.GetByID(ctx, id)
+	close(r.loaded)
+	<-r.release
+	return order, err
+}
+
+func TestCachedRepository(t *testing.T) {
+	metrics, err := cache.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	ctx := context.Background()
+	seed := func(t *testing.T) *memory.Repository {
+		t.Helper()
+		repo := memory.NewRepository()
+		if err := repo.Create(ctx, domain.Order{ID: "order-1", Status: domain.StatusPending}); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+		return repo
+	}
+
+	t.Run("does not cache a load that raced an update", func(t *testing.T) {
+		base := seed(t)
+		paused := &pausingRepository{OrderRepository: base, loaded: make(chan struct{}), release: make(chan struct{})}
+		repo := adapters.NewCachedRepository(paused, 10, time.Minute, metrics)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if _, err := repo.GetByID(ctx, "order-1"); err != nil {
+				t.Errorf("failed to get order: %v", err)
+			}
+		}()
+		<-paused.loaded
+		if err := repo.UpdateStatus(ctx, "order-1", domain.StatusProcessing); err != nil {
+			t.Fatalf("failed to update status: %v", err)
+		}
+		close(paused.release)
+		<-done
+
+		// Later reads go straight to the base repository.
+		paused.loaded, paused.release = make(chan struct{}), make(chan struct{})
+		close(paused.release)
+		order, err := repo.GetByID(ctx, "order-1")
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		if order.Status != domain.StatusProcessing {
+			t.Errorf("expected the updated status, got %s", order.Status)
+		}
+	})
+
+	t.Run("serves the latest status under concurrent reads and writes", func(t *testing.T) {
+		repo := adapters.NewCachedRepository(seed(t), 10, time.Minute, metrics)
+		statuses := []domain.OrderStatus{domain.StatusProcessing, domain.StatusPending}
+
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 200 {
+					if _, err := repo.GetByID(ctx, "order-1"); err != nil {
+						t.Errorf("failed to get order: %v", err)
+						return
+					}
+				}
+			}()
+		}
+		for i := range 200 {
+			if err := repo.UpdateStatus(ctx, "order-1", statuses[i%2]); err != nil {
+				t.Fatalf("failed to update status: %v", err)
+			}
+		}
+		wg.Wait()
+
+		order, err := repo.GetByID(ctx, "order-1")
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		if want := statuses[199%2]; order.Status != want {
+			t.Errorf("expected status %s, got %s", want, order.Status)
+		}
+	})
+}
+
+func TestCachedRepositoryPrimaryReads(t *testing.T) {
+	metrics, err := cache.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	ctx := context.Background()
+	base := memory.NewRepository()
+	if err := base.Create(ctx, domain.Order{ID: "order-1", Status: domain.StatusPending}); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+	repo := adapters.NewCachedRepository(base, 10, time.Minute, metrics)
+	if _, err := repo.GetByID(ctx, "order-1"); err != nil {
+		t.Fatalf("failed to get order: %v", err)
+	}
+
+	// A write the cache did not see, e.g. from another instance.
+	if err := base.UpdateStatus(ctx, "order-1", domain.StatusCompleted); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+
+	order, err := repo.GetByID(ports.WithPrimaryReads(ctx), "order-1")
+	if err != nil {
+		t.Fatalf("failed to get order: %v", err)
+	}
+	if order.Status != domain.StatusCompleted {
+		t.Errorf("expected a primary read to skip the cache, got %s", order.Status)
+	}
+}
+
+func TestCachedRepositoryOverReplica(t *testing.T) {
+	metrics, err := cache.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	ctx := context.Background()
+	// The replica never catches up, so anything read from it is stale.
+	primary, replica := memory.NewRepository(), memory.NewRepository()
+	for _, repo := range []*memory.Repository{primary, replica} {
+		if err := repo.Create(ctx, domain.Order{ID: "order-1", Status: domain.StatusPending}); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	repo := adapters.NewCachedRepository(adapters.NewReadWriteRepository(primary, replica), 10, time.Minute, metrics)
+
+	if err := repo.UpdateStatus(ctx, "order-1", domain.StatusProcessing); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	for range 2 {
+		order, err := repo.GetByID(ctx, "order-1")
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		if order.Status != domain.StatusProcessing {
+			t.Errorf("expected the written status, got %s", order.Status)
+		}
+	}
+	orders, err := repo.GetByIDs(ctx, []string{"order-1"})
+	if err != nil || orders["order-1"].Status != domain.StatusProcessing {
+		t.Errorf("expected the written status, got %+v %v", orders, err)
+	}
+}
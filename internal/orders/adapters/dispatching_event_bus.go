@@ -0,0 +1,56 @@
+package adapters
+
+import (
+	"context"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/events"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// DispatchingEventBus forwards events to the wrapped bus and, once the bus accepts them,
+// to an in-process dispatcher so local subscribers see the same events.
+type DispatchingEventBus struct {
+	bus        ports.EventBus
+	dispatcher *events.Dispatcher
+}
+
+func NewDispatchingEventBus(bus ports.EventBus, dispatcher *events.Dispatcher) *DispatchingEventBus {
+	return &DispatchingEventBus{
+		bus:        bus,
+		dispatcher: dispatcher,
+	}
+}
+
+func (e *DispatchingEventBus) PublishOrderCreated(ctx context.Context, orderID string) error {
+	if err := e.bus.PublishOrderCreated(ctx, orderID); err != nil {
+		return err
+	}
+	e.dispatch(ctx, events.Event{Type: events.TypeOrderCreated, OrderID: orderID})
+	return nil
+}
+
+func (e *DispatchingEventBus) PublishOrderProcessed(ctx context.Context, orderID string) error {
+	if err := e.bus.PublishOrderProcessed(ctx, orderID); err != nil {
+		return err
+	}
+	e.dispatch(ctx, events.Event{Type: events.TypeOrderProcessed, OrderID: orderID})
+	return nil
+}
+
+func (e *DispatchingEventBus) PublishOrderFailed(ctx context.Context, orderID string, reason string) error {
+	if err := e.bus.PublishOrderFailed(ctx, orderID, reason); err != nil {
+		return err
+	}
+	e.dispatch(ctx, events.Event{Type: events.TypeOrderFailed, OrderID: orderID, Reason: reason})
+	return nil
+}
+
+func (e *DispatchingEventBus) HealthCheck(ctx context.Context) error {
+	return e.bus.HealthCheck(ctx)
+}
+
+func (e *DispatchingEventBus) dispatch(ctx context.Context, event events.Event) {
+	event.OccurredAt = time.Now().UTC()
+	e.dispatcher.Publish(ctx, event)
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content codings understood by WithCompression. Brotli needs an encoder registered with
+// WithEncoder; gzip is built in.
+const (
+	EncodingBrotli   = "br"
+	EncodingGzip     = "gzip"
+	EncodingIdentity = "identity"
+)
+
+const defaultCompressionMinSize = 1024
+
+// Encoder wraps w in a compressing writer for one content coding.
+type Encoder func(w io.Writer) (io.WriteCloser, error)
+
+// CompressionOption customizes WithCompression.
+type CompressionOption func(*compressor)
+
+// WithEncoder registers the encoder used for a content coding, e.g. a Brotli writer for
+// EncodingBrotli. It replaces any encoder registered for the same coding.
+func WithEncoder(coding string, encoder Encoder) CompressionOption {
+	return func(c *compressor) {
+		c.encoders[coding] = encoder
+	}
+}
+
+// WithCompressionMinSize leaves responses smaller than size bytes uncompressed.
+func WithCompressionMinSize(size int) CompressionOption {
+	return func(c *compressor) {
+		c.minSize = size
+	}
+}
+
+type compressor struct {
+	encoders map[string]Encoder
+	minSize  int
+}
+
+// encodingPreference breaks ties between equally weighted codings.
+var encodingPreference = []string{EncodingBrotli, EncodingGzip}
+
+// WithCompression compresses responses with the best coding the client accepts, preferring
+// br over gzip over identity at equal q-values. Responses fall back to identity when no
+// supported coding is acceptable, and always carry Vary: Accept-Encoding. Only gzip is built
+// in; br is never chosen unless an encoder is registered with WithEncoder.
+func WithCompression(next http.Handler, opts ...CompressionOption) http.Handler {
+	c := &compressor{
+		encoders: map[string]Encoder{
+			EncodingGzip: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+		},
+		minSize: defaultCompressionMinSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		coding := negotiateEncoding(r.Header.Get("Accept-Encoding"), c.encoders)
+		if coding == EncodingIdentity || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, coding: coding, encoder: c.encoders[coding], minSize: c.minSize}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks the supported coding with the highest q-value in an
+// Accept-Encoding header, returning identity when none is acceptable.
+func negotiateEncoding(header string, encoders map[string]Encoder) string {
+	if header == "" {
+		return EncodingIdentity
+	}
+
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		weights[name] = weight
+	}
+
+	best, bestWeight := EncodingIdentity, 0.0
+	consider := func(coding string) {
+		weight, ok := weights[coding]
+		if !ok {
+			weight, ok = weights["*"]
+		}
+		if ok && weight > bestWeight {
+			best, bestWeight = coding, weight
+		}
+	}
+	for _, coding := range encodingPreference {
+		if _, ok := encoders[coding]; ok {
+			consider(coding)
+		}
+	}
+	for coding := range encoders {
+		if coding != EncodingBrotli && coding != EncodingGzip {
+			consider(coding)
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response and only compresses it once it reaches
+// minSize bytes, so small bodies are not inflated by compression overhead.
+type compressWriter struct {
+	http.ResponseWriter
+	coding  string
+	encoder Encoder
+	minSize int
+
+	status  int
+	buf     []byte
+	decided bool
+	writer  io.Writer
+	closer  io.Closer
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	cw.status = code
+	if code < http.StatusOK || code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.passthrough()
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		return cw.writer.Write(b)
+	}
+	if cw.Header().Get("Content-Encoding") != "" {
+		cw.passthrough()
+		return cw.writer.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) < cw.minSize {
+		return len(b), nil
+	}
+	if err := cw.startCompression(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close flushes buffered output and finishes the compressed stream.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		cw.passthrough()
+	}
+	if cw.closer != nil {
+		return cw.closer.Close()
+	}
+	return nil
+}
+
+func (cw *compressWriter) startCompression() error {
+	enc, err := cw.encoder(cw.ResponseWriter)
+	if err != nil {
+		cw.passthrough()
+		return nil
+	}
+
+	cw.Header().Set("Content-Encoding", cw.coding)
+	cw.Header().Del("Content-Length")
+	cw.writeHeader()
+	cw.decided, cw.writer, cw.closer = true, enc, enc
+
+	buffered := cw.buf
+	cw.buf = nil
+	_, err = enc.Write(buffered)
+	return err
+}
+
+// passthrough sends the response uncompressed, flushing anything buffered so far.
+func (cw *compressWriter) passthrough() {
+	cw.writeHeader()
+	cw.decided, cw.writer = true, cw.ResponseWriter
+	if len(cw.buf) > 0 {
+		_, _ = cw.ResponseWriter.Write(cw.buf)
+		cw.buf = nil
+	}
+}
+
+func (cw *compressWriter) writeHeader() {
+	status := cw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	encoders := map[string]Encoder{
+		EncodingBrotli: nopEncoder,
+		EncodingGzip:   nopEncoder,
+	}
+	gzipOnly := map[string]Encoder{EncodingGzip: nopEncoder}
+
+	tests := []struct {
+		name     string
+		header   string
+		encoders map[string]Encoder
+		want     string
+	}{
+		{name: "no header", header: "", encoders: encoders, want: EncodingIdentity},
+		{name: "prefers br at equal weight", header: "gzip, br", encoders: encoders, want: EncodingBrotli},
+		{name: "honours q-values", header: "br;q=0.5, gzip;q=0.8", encoders: encoders, want: EncodingGzip},
+		{name: "skips br without encoder", header: "br, gzip", encoders: gzipOnly, want: EncodingGzip},
+		{name: "excluded coding", header: "gzip;q=0", encoders: gzipOnly, want: EncodingIdentity},
+		{name: "wildcard", header: "*", encoders: gzipOnly, want: EncodingGzip},
+		{name: "wildcard does not override explicit exclusion", header: "gzip;q=0, *", encoders: gzipOnly, want: EncodingIdentity},
+		{name: "unsupported only", header: "deflate, zstd", encoders: encoders, want: EncodingIdentity},
+		{name: "case and whitespace", header: " GZIP ; q=1 ", encoders: gzipOnly, want: EncodingGzip},
+		{name: "malformed q-value ignored", header: "br;q=x, gzip", encoders: encoders, want: EncodingGzip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateEncoding(tt.header, tt.encoders); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCompression(t *testing.T) {
+	body := strings.Repeat("order ", 400)
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, body[:len(body)/2])
+		_, _ = io.WriteString(w, body[len(body)/2:])
+	}))
+
+	t.Run("compresses with gzip when accepted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+		req.Header.Set("Accept-Encoding", "br;q=0.9, gzip")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != EncodingGzip {
+			t.Fatalf("expected gzip encoding, got %q", got)
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("expected Vary: Accept-Encoding, got %q", got)
+		}
+		reader, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		decoded, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("read gzip body: %v", err)
+		}
+		if string(decoded) != body {
+			t.Errorf("decoded body mismatch")
+		}
+	})
+
+	t.Run("falls back to identity when nothing is acceptable", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+		req.Header.Set("Accept-Encoding", "zstd")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("expected no encoding, got %q", got)
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("expected Vary: Accept-Encoding, got %q", got)
+		}
+		if rec.Body.String() != body {
+			t.Errorf("expected uncompressed body")
+		}
+	})
+
+	t.Run("uses a registered brotli encoder", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		rec := httptest.NewRecorder()
+
+		WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, body)
+		}), WithEncoder(EncodingBrotli, nopEncoder)).ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != EncodingBrotli {
+			t.Errorf("expected br encoding, got %q", got)
+		}
+	})
+
+	t.Run("leaves small responses uncompressed", func(t *testing.T) {
+		small := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"status":"ok"}`)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		small.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("expected no encoding, got %q", got)
+		}
+		if rec.Body.String() != `{"status":"ok"}` {
+			t.Errorf("unexpected body %q", rec.Body.String())
+		}
+	})
+
+	t.Run("does not re-encode already encoded responses", func(t *testing.T) {
+		encoded := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", EncodingGzip)
+			_, _ = io.WriteString(w, body)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		encoded.ServeHTTP(rec, req)
+
+		if rec.Body.String() != body {
+			t.Errorf("expected body to pass through untouched")
+		}
+	})
+}
+
+func nopEncoder(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
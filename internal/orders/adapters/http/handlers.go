@@ -1,48 +1,284 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dejobratic/tbd/internal/audit"
+	"github.com/dejobratic/tbd/internal/auth"
 	"github.com/dejobratic/tbd/internal/orders/app"
 	"github.com/dejobratic/tbd/internal/orders/domain"
 	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/dejobratic/tbd/internal/requestctx"
+	"github.com/dejobratic/tbd/internal/telemetry"
 )
 
+const (
+	defaultIdempotencyHeader  = "Idempotency-Key"
+	idempotencyReplayHeader   = "Idempotency-Replayed"
+	idempotencyDegradedHeader = "Idempotency-Degraded"
+	minIdempotencyKeyLength   = 8
+	maxIdempotencyKeyLength   = 255
+	dryRunHeader              = "X-Dry-Run"
+)
+
+// Route names identify endpoints in WithRouteScopes.
+const (
+	RouteCreateOrder      = "orders.create"
+	RouteListOrders       = "orders.list"
+	RouteGetOrder         = "orders.get"
+	RouteOrderHistory     = "orders.history"
+	RouteOrderAudit       = "orders.audit"
+	RouteCancelOrder      = "orders.cancel"
+	RouteBulkStatus       = "orders.bulk_status"
+	RouteOrderByReference = "orders.by_reference"
+	RouteCustomerOrders   = "customers.orders"
+	RouteListCustomers    = "customers.list"
+)
+
+// KnownRoute reports whether name is one of the route names above.
+func KnownRoute(name string) bool {
+	switch name {
+	case RouteCreateOrder, RouteListOrders, RouteGetOrder, RouteOrderHistory, RouteOrderAudit,
+		RouteCancelOrder, RouteBulkStatus, RouteOrderByReference, RouteCustomerOrders, RouteListCustomers:
+		return true
+	default:
+		return false
+	}
+}
+
+// errCreateInputRejected reports that an order creation payload was rejected and the error
+// response already written.
+var errCreateInputRejected = errors.New("create input rejected")
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Service is the part of the order service the handlers call. *app.Service implements it;
+// tests can pass a lightweight fake instead of wiring a repository, event bus and store.
+type Service interface {
+	CreateOrderIdempotent(ctx context.Context, key string, input func() (app.CreateOrderInput, error), opts ...app.IdempotentCreateOption) (ports.StoredResponse, bool, error)
+	ValidateOrder(ctx context.Context, input app.CreateOrderInput) (*domain.Order, error)
+	GetOrder(ctx context.Context, id string) (*domain.Order, error)
+	GetOrderByReference(ctx context.Context, reference string) (*domain.Order, error)
+	GetOrderHistory(ctx context.Context, id string) ([]domain.StatusTransition, error)
+	GetOrderAudit(ctx context.Context, id string) ([]audit.Entry, error)
+	NormalizeListFilter(filter ports.ListFilter) (ports.ListFilter, error)
+	ListOrders(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error)
+	CountOrders(ctx context.Context, filter ports.ListFilter) (int, error)
+	ListCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error)
+	CancelOrder(ctx context.Context, id string) (*domain.Order, error)
+	BulkUpdateStatus(ctx context.Context, input app.BulkStatusInput) ([]app.BulkStatusResult, error)
+	ReprocessFailedOrders(ctx context.Context, input app.ReprocessInput) (app.ReprocessSummary, error)
+	GetIdempotentResponse(ctx context.Context, key string) (*ports.StoredResponse, error)
+	SaveIdempotentResponse(ctx context.Context, key string, response ports.StoredResponse) error
+	CountIdempotencyKeysSince(ctx context.Context, since time.Time) (int64, error)
+}
+
 // Handler exposes HTTP endpoints for order operations.
 type Handler struct {
-	service *app.Service
+	service                Service
+	idempotencyHeader      string
+	idempotencyAliases     []string
+	requireUUIDIdempotency bool
+	adminToken             string
+	reprocess              app.ReprocessInput
+	routeScopes            map[string]string
+	failOpen               *failOpen
+	retryAfter             time.Duration
+	createStatus           int
+	debugErrors            bool
+	goneForTerminal        bool
+	maxCreateBodyBytes     int64
+	createMetrics          *Metrics
+}
+
+// failOpen holds where idempotency store errors are reported when they are bypassed.
+type failOpen struct {
+	logger  *slog.Logger
+	metrics *Metrics
+}
+
+// Option customizes a Handler.
+type Option func(*Handler)
+
+// WithIdempotencyHeader sets the request header the idempotency key is read from.
+func WithIdempotencyHeader(name string) Option {
+	return func(h *Handler) {
+		if name != "" {
+			h.idempotencyHeader = name
+		}
+	}
+}
+
+// WithIdempotencyHeaderAliases sets additional headers consulted, in order, when the
+// primary idempotency header is absent.
+func WithIdempotencyHeaderAliases(names ...string) Option {
+	return func(h *Handler) {
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name != "" {
+				h.idempotencyAliases = append(h.idempotencyAliases, name)
+			}
+		}
+	}
+}
+
+// WithUUIDIdempotencyKeys requires idempotency keys to be formatted as UUIDs.
+func WithUUIDIdempotencyKeys(required bool) Option {
+	return func(h *Handler) {
+		h.requireUUIDIdempotency = required
+	}
+}
+
+// WithAdminToken enables the admin endpoints, guarded by a bearer token. Admin endpoints
+// are not registered when the token is empty.
+func WithAdminToken(token string) Option {
+	return func(h *Handler) {
+		h.adminToken = token
+	}
+}
+
+// WithReprocessLimits sets the batch size and limit used by the reprocess endpoint.
+func WithReprocessLimits(batchSize, limit int) Option {
+	return func(h *Handler) {
+		h.reprocess = app.ReprocessInput{BatchSize: batchSize, Limit: limit}
+	}
+}
+
+// WithRouteScopes requires the scope mapped to a route name, such as RouteCancelOrder, on
+// that route. It relies on auth.RequireJWT having authenticated the request; routes
+// without a mapping stay open to every authenticated caller.
+func WithRouteScopes(scopes map[string]string) Option {
+	return func(h *Handler) {
+		h.routeScopes = scopes
+	}
+}
+
+// WithIdempotencyFailOpen lets order creation proceed when the idempotency store fails
+// instead of returning 500. Each bypassed error is logged to logger and counted on metrics,
+// which may be nil, and the response carries "Idempotency-Degraded: true" because a retry
+// of that request may create a second order.
+func WithIdempotencyFailOpen(logger *slog.Logger, metrics *Metrics) Option {
+	return func(h *Handler) {
+		h.failOpen = &failOpen{logger: logger, metrics: metrics}
+	}
+}
+
+// WithCreateRetryAfter sets the processing estimate advertised as Retry-After on 202
+// responses to order creation, so clients polling for the order back off sensibly. It is
+// stored with the idempotent response and replayed unchanged.
+func WithCreateRetryAfter(d time.Duration) Option {
+	return func(h *Handler) {
+		h.retryAfter = d
+	}
+}
+
+// WithCreateStatus sets the status answered, and stored for replays, when an order is
+// created: http.StatusAccepted (the default) or http.StatusCreated. Other codes are ignored.
+func WithCreateStatus(code int) Option {
+	return func(h *Handler) {
+		if code == http.StatusAccepted || code == http.StatusCreated {
+			h.createStatus = code
+		}
+	}
+}
+
+// WithMaxCreateBodyBytes caps the size of order creation payloads; larger bodies are
+// answered with 413. A non-positive limit leaves bodies unbounded.
+func WithMaxCreateBodyBytes(n int64) Option {
+	return func(h *Handler) {
+		h.maxCreateBodyBytes = n
+	}
+}
+
+// WithCreateRejectionMetrics counts order creation requests rejected for a client error
+// (bad JSON, oversized body, failed validation or a missing idempotency key) on metrics.
+func WithCreateRejectionMetrics(metrics *Metrics) Option {
+	return func(h *Handler) {
+		h.createMetrics = metrics
+	}
+}
+
+// WithDebugErrors returns the underlying error in 5xx response bodies. Leave it off in
+// production: internal errors can contain SQL or connection details.
+func WithDebugErrors(enabled bool) Option {
+	return func(h *Handler) {
+		h.debugErrors = enabled
+	}
+}
+
+// WithGoneForTerminalOrders makes GET /v1/orders/{id} answer 410 Gone, with the order still
+// in the body, for canceled and failed orders, so caches can stop polling them. Off by
+// default: those orders are served with 200 like any other.
+func WithGoneForTerminalOrders(enabled bool) Option {
+	return func(h *Handler) {
+		h.goneForTerminal = enabled
+	}
 }
 
 // NewHandler constructs a Handler.
-func NewHandler(service *app.Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service Service, opts ...Option) *Handler {
+	h := &Handler{
+		service:           service,
+		idempotencyHeader: defaultIdempotencyHeader,
+		createStatus:      http.StatusAccepted,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // Register binds the order handlers to the provided ServeMux.
 func (h *Handler) Register(mux *http.ServeMux) {
-	mux.HandleFunc("/v1/orders", h.handleOrders)
+	mux.Handle("/v1/orders", WithRouteTemplate("/v1/orders", http.HandlerFunc(h.handleOrders)))
+	mux.Handle("/v1/orders/bulk-status", WithRouteTemplate("/v1/orders/bulk-status", h.scoped(RouteBulkStatus, h.bulkUpdateStatus)))
 	mux.HandleFunc("/v1/orders/", h.handleOrderByID)
+	mux.Handle("/v1/orders/by-reference/", h.scoped(RouteOrderByReference, h.getOrderByReference))
+	mux.Handle("/v1/customers", WithRouteTemplate("/v1/customers", h.scoped(RouteListCustomers, h.listCustomers)))
+	mux.Handle("/v1/customers/", h.scoped(RouteCustomerOrders, h.listCustomerOrders))
+
+	if h.adminToken != "" {
+		mux.Handle("/admin/orders/reprocess", WithRouteTemplate("/admin/orders/reprocess",
+			RequireBearerToken(h.adminToken, http.HandlerFunc(h.reprocessFailedOrders))))
+		mux.Handle("/admin/idempotency/keys", WithRouteTemplate("/admin/idempotency/keys",
+			RequireBearerToken(h.adminToken, http.HandlerFunc(h.countIdempotencyKeys))))
+	}
+}
+
+// scoped wraps next with the scope check configured for route, if any.
+func (h *Handler) scoped(route string, next http.HandlerFunc) http.Handler {
+	scope, ok := h.routeScopes[route]
+	if !ok || scope == "" {
+		return next
+	}
+	return auth.RequireScope(scope)(next)
 }
 
 func (h *Handler) handleOrders(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
-		h.createOrder(w, r)
+		h.scoped(RouteCreateOrder, h.createOrder).ServeHTTP(w, r)
 	case http.MethodGet:
-		h.listOrders(w, r)
+		h.scoped(RouteListOrders, h.listOrders).ServeHTTP(w, r)
 	default:
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
 func (h *Handler) handleOrderByID(w http.ResponseWriter, r *http.Request) {
 	trimmed := strings.TrimPrefix(r.URL.Path, "/v1/orders/")
 	if trimmed == "" {
-		writeError(w, http.StatusNotFound, "order not found")
+		writeError(w, r, http.StatusNotFound, "order not found")
 		return
 	}
 
@@ -50,140 +286,806 @@ func (h *Handler) handleOrderByID(w http.ResponseWriter, r *http.Request) {
 		id := strings.TrimSuffix(trimmed, "/cancel")
 		id = strings.TrimSuffix(id, "/")
 		if id == "" {
-			writeError(w, http.StatusNotFound, "order not found")
+			writeError(w, r, http.StatusNotFound, "order not found")
 			return
 		}
+		setRoute(r, "/v1/orders/{id}/cancel")
 		if r.Method != http.MethodPost {
-			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.scoped(RouteCancelOrder, func(w http.ResponseWriter, r *http.Request) {
+			h.cancelOrder(w, r, id)
+		}).ServeHTTP(w, r)
+		return
+	}
+
+	if strings.HasSuffix(trimmed, "/history") {
+		id := strings.TrimSuffix(trimmed, "/history")
+		if id == "" {
+			writeError(w, r, http.StatusNotFound, "order not found")
+			return
+		}
+		setRoute(r, "/v1/orders/{id}/history")
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 			return
 		}
-		h.cancelOrder(w, r, id)
+		h.scoped(RouteOrderHistory, func(w http.ResponseWriter, r *http.Request) {
+			h.getOrderHistory(w, r, id)
+		}).ServeHTTP(w, r)
+		return
+	}
+
+	if strings.HasSuffix(trimmed, "/audit") {
+		id := strings.TrimSuffix(trimmed, "/audit")
+		if id == "" {
+			writeError(w, r, http.StatusNotFound, "order not found")
+			return
+		}
+		setRoute(r, "/v1/orders/{id}/audit")
+		if r.Method != http.MethodGet {
+			writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		h.scoped(RouteOrderAudit, func(w http.ResponseWriter, r *http.Request) {
+			h.getOrderAudit(w, r, id)
+		}).ServeHTTP(w, r)
 		return
 	}
 
 	id := strings.TrimSuffix(trimmed, "/")
 	if id == "" {
-		writeError(w, http.StatusNotFound, "order not found")
+		writeError(w, r, http.StatusNotFound, "order not found")
 		return
 	}
 
-	if r.Method != http.MethodGet {
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
+	setRoute(r, "/v1/orders/{id}")
+	switch r.Method {
+	case http.MethodGet:
+		h.scoped(RouteGetOrder, func(w http.ResponseWriter, r *http.Request) {
+			h.getOrder(w, r, id)
+		}).ServeHTTP(w, r)
+	case http.MethodHead:
+		h.scoped(RouteGetOrder, func(w http.ResponseWriter, r *http.Request) {
+			h.headOrder(w, r, id)
+		}).ServeHTTP(w, r)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 	}
-	h.getOrder(w, r, id)
 }
 
 func (h *Handler) createOrder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	idemKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
-	if idemKey == "" {
-		writeError(w, http.StatusBadRequest, "Idempotency-Key header required")
+	dryRun, err := isDryRun(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		h.validateOrder(w, r)
 		return
 	}
 
-	if stored, err := h.service.GetIdempotentResponse(ctx, idemKey); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	idemKey := h.idempotencyKey(r)
+	if idemKey == "" {
+		h.recordCreateRejected(r, CreateRejectedMissingKey)
+		writeError(w, r, http.StatusBadRequest, h.idempotencyHeader+" header required")
 		return
-	} else if stored != nil {
-		for key, values := range restoreHeaders(stored.StatusCode) {
-			for _, value := range values {
-				w.Header().Add(key, value)
-			}
-		}
-		w.WriteHeader(stored.StatusCode)
-		_, _ = w.Write(stored.Body)
+	}
+	if err := validateIdempotencyKey(idemKey, h.requireUUIDIdempotency); err != nil {
+		h.recordCreateRejected(r, CreateRejectedValidation)
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid %s header: %v", h.idempotencyHeader, err))
 		return
 	}
 
-	var payload app.CreateOrderInput
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid JSON payload")
-		return
+	ctx = requestctx.WithIdempotencyKey(ctx, idemKey)
+	// The payload is only read when the key has no stored response, so a retry is
+	// replayed even if its body differs from the original request.
+	input := func() (app.CreateOrderInput, error) {
+		payload, reason, ok := h.decodeCreateInput(w, r)
+		if !ok {
+			h.recordCreateRejected(r, reason)
+			return payload, errCreateInputRejected
+		}
+		return payload, nil
 	}
 
-	order, err := h.service.CreateOrder(ctx, payload)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
-		return
+	var degraded bool
+	opts := []app.IdempotentCreateOption{app.WithStoredResponse(h.createdResponse)}
+	if h.failOpen != nil {
+		opts = append(opts, app.WithIdempotencyFailOpen(func(operation, orderID string, err error) {
+			degraded = true
+			h.failOpen.report(r, operation, idemKey, orderID, err)
+		}))
 	}
 
-	response := map[string]any{"order": order}
-	body, err := json.Marshal(response)
+	stored, replayed, err := h.service.CreateOrderIdempotent(ctx, idemKey, input, opts...)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		if errors.Is(err, errCreateInputRejected) {
+			return
+		}
+		if serviceErrorStatus(err) == http.StatusBadRequest {
+			h.recordCreateRejected(r, CreateRejectedValidation)
+		}
+		h.writeServiceError(w, r, err)
 		return
 	}
 
-	stored := ports.StoredResponse{
-		StatusCode: http.StatusAccepted,
+	// A replay answers with the response stored by the original call, byte for byte, even
+	// if the configured create status has changed since.
+	for name, values := range restoreHeaders(stored) {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotencyReplayHeader, strconv.FormatBool(replayed))
+	if degraded {
+		w.Header().Set(idempotencyDegradedHeader, "true")
+	}
+	w.WriteHeader(stored.StatusCode)
+	_, _ = w.Write(stored.Body)
+}
+
+// createdResponse is the response answered, and stored for the idempotency key, when an
+// order is created.
+func (h *Handler) createdResponse(order *domain.Order) (ports.StoredResponse, error) {
+	body, err := json.Marshal(map[string]any{"order": order})
+	if err != nil {
+		return ports.StoredResponse{}, fmt.Errorf("marshal order: %w", err)
+	}
+	return ports.StoredResponse{
+		StatusCode: h.createStatus,
 		Body:       body,
 		OrderID:    order.ID,
+		RetryAfter: h.retryAfter,
+	}, nil
+}
+
+// validateOrder answers a dry-run create with the order that would be created. It needs no
+// idempotency key because nothing is persisted.
+func (h *Handler) validateOrder(w http.ResponseWriter, r *http.Request) {
+	payload, _, ok := h.decodeCreateInput(w, r)
+	if !ok {
+		return
 	}
 
-	if err := h.service.SaveIdempotentResponse(ctx, idemKey, stored); err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+	order, err := h.service.ValidateOrder(r.Context(), payload)
+	if err != nil {
+		h.writeServiceError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	_, _ = w.Write(body)
+	writeJSON(w, http.StatusOK, map[string]any{"order": order, "dry_run": true})
+}
+
+// decodeCreateInput decodes an order creation payload. On failure it answers 400, or 413
+// when the body exceeds the configured limit, and returns the rejection reason.
+func (h *Handler) decodeCreateInput(w http.ResponseWriter, r *http.Request) (app.CreateOrderInput, string, bool) {
+	body := r.Body
+	if h.maxCreateBodyBytes > 0 {
+		body = http.MaxBytesReader(w, body, h.maxCreateBodyBytes)
+	}
+
+	var payload app.CreateOrderInput
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, r, http.StatusRequestEntityTooLarge, "request body too large")
+			return payload, CreateRejectedTooLarge, false
+		}
+		writeError(w, r, http.StatusBadRequest, "invalid JSON payload")
+		return payload, CreateRejectedBadJSON, false
+	}
+	return payload, "", true
+}
+
+// recordCreateRejected counts a rejected creation request when metrics are configured.
+func (h *Handler) recordCreateRejected(r *http.Request, reason string) {
+	if h.createMetrics != nil {
+		h.createMetrics.RecordCreateRejected(r.Context(), reason)
+	}
+}
+
+// isDryRun reports whether the request asks for a dry run via ?dry_run= or X-Dry-Run.
+func isDryRun(r *http.Request) (bool, error) {
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid dry_run parameter: %q", value)
+		}
+		return dryRun, nil
+	}
+	if value := r.Header.Get(dryRunHeader); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s header: %q", dryRunHeader, value)
+		}
+		return dryRun, nil
+	}
+	return false, nil
 }
 
 func (h *Handler) getOrder(w http.ResponseWriter, r *http.Request, id string) {
+	query := r.URL.Query()
+	fields, err := parseOrderFields(query.Get("fields"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	includes, err := parseOrderIncludes(query.Get("include"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Embedding history exposes what the history route does, so it requires that route's scope.
+	if slices.Contains(includes, includeHistory) {
+		h.scoped(RouteOrderHistory, func(w http.ResponseWriter, r *http.Request) {
+			h.writeOrder(w, r, id, fields, true)
+		}).ServeHTTP(w, r)
+		return
+	}
+	h.writeOrder(w, r, id, fields, false)
+}
+
+// writeOrder answers with the order, projected to fields when any are given, and its
+// status history under "history" when withHistory is set.
+func (h *Handler) writeOrder(w http.ResponseWriter, r *http.Request, id string, fields []string, withHistory bool) {
 	order, err := h.service.GetOrder(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, ports.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "order not found")
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	status := http.StatusOK
+	if h.goneForTerminal && (order.Status == domain.StatusCanceled || order.Status == domain.StatusFailed) {
+		status = http.StatusGone
+	}
+
+	w.Header().Set("ETag", orderETag(*order))
+	response := newOrderResponse(*order)
+	body := map[string]any{"order": response}
+	if len(fields) > 0 {
+		projected, err := projectOrder(response, fields)
+		if err != nil {
+			h.writeInternalError(w, r, http.StatusInternalServerError, err)
 			return
 		}
-		writeError(w, http.StatusInternalServerError, err.Error())
+		body["order"] = projected
+	}
+
+	if withHistory {
+		history, err := h.service.GetOrderHistory(r.Context(), id)
+		if err != nil {
+			h.writeServiceError(w, r, err)
+			return
+		}
+		body["history"] = history
+	}
+
+	writeJSON(w, status, body)
+}
+
+// orderETag identifies an order version by its last update, which every change bumps.
+func orderETag(order domain.Order) string {
+	return `"` + strconv.FormatInt(order.UpdatedAt.UnixNano(), 36) + `"`
+}
+
+func (h *Handler) getOrderByReference(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"order": order})
+
+	reference := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/orders/by-reference/"), "/")
+	if reference == "" || strings.Contains(reference, "/") {
+		writeError(w, r, http.StatusNotFound, "order not found")
+		return
+	}
+	setRoute(r, "/v1/orders/by-reference/{reference}")
+
+	order, err := h.service.GetOrderByReference(r.Context(), reference)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"order": newOrderResponse(*order)})
+}
+
+func (h *Handler) getOrderHistory(w http.ResponseWriter, r *http.Request, id string) {
+	history, err := h.service.GetOrderHistory(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"order_id": id, "history": history})
+}
+
+func (h *Handler) getOrderAudit(w http.ResponseWriter, r *http.Request, id string) {
+	entries, err := h.service.GetOrderAudit(r.Context(), id)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"order_id": id, "audit": entries})
+}
+
+// headOrder answers with the status and headers GET would, ETag and Content-Length
+// included, by running GET and discarding its body.
+func (h *Handler) headOrder(w http.ResponseWriter, r *http.Request, id string) {
+	hw := &headWriter{ResponseWriter: w}
+	h.getOrder(hw, r, id)
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(hw.length))
+	w.WriteHeader(hw.status)
+}
+
+// headWriter counts a response body instead of sending it and holds the status back, so
+// Content-Length can be set once the length is known.
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+func (hw *headWriter) WriteHeader(status int) {
+	if hw.status == 0 {
+		hw.status = status
+	}
+}
+
+func (hw *headWriter) Write(b []byte) (int, error) {
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+	hw.length += len(b)
+	return len(b), nil
 }
 
 func (h *Handler) listOrders(w http.ResponseWriter, r *http.Request) {
-	filter := ports.ListFilter{}
-	if statusParam := r.URL.Query().Get("status"); statusParam != "" {
+	filter, err := listFilterFromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.CustomerID = r.URL.Query().Get("customer_id")
+	h.writeOrderPage(w, r, filter)
+}
+
+func (h *Handler) listCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	trimmed := strings.TrimPrefix(r.URL.Path, "/v1/customers/")
+	customerID, ok := strings.CutSuffix(trimmed, "/orders")
+	if !ok || customerID == "" || strings.Contains(customerID, "/") {
+		writeError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	setRoute(r, "/v1/customers/{id}/orders")
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	filter, err := listFilterFromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.CustomerID = customerID
+	h.writeOrderPage(w, r, filter)
+}
+
+// listCustomers responds with one page of distinct customer emails, narrowed by the shared
+// list parameters.
+func (h *Handler) listCustomers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	filter, err := listFilterFromQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err = h.service.NormalizeListFilter(filter)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	customers, err := h.service.ListCustomers(r.Context(), filter)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+	if customers == nil {
+		customers = []string{}
+	}
+
+	page, pageSize := filter.Pagination()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"customers": customers,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// listFilterFromQuery reads the status, currency, time window and pagination parameters
+// shared by list endpoints. Only malformed timestamps are rejected here; the service
+// validates the rest.
+func listFilterFromQuery(query url.Values) (ports.ListFilter, error) {
+	filter := ports.ListFilter{Currency: query.Get("currency")}
+	if statusParam := query.Get("status"); statusParam != "" {
 		status := domain.OrderStatus(statusParam)
 		filter.Status = &status
 	}
 
-	if pageParam := r.URL.Query().Get("page"); pageParam != "" {
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+	} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return ports.ListFilter{}, fmt.Errorf("%s must be an RFC 3339 timestamp", param.name)
+		}
+		*param.target = parsed
+	}
+
+	if pageParam := query.Get("page"); pageParam != "" {
 		if page, err := strconv.Atoi(pageParam); err == nil {
 			filter.Page = page
 		}
 	}
 
-	if pageSizeParam := r.URL.Query().Get("page_size"); pageSizeParam != "" {
+	if pageSizeParam := query.Get("page_size"); pageSizeParam != "" {
 		if pageSize, err := strconv.Atoi(pageSizeParam); err == nil {
 			filter.PageSize = pageSize
 		}
 	}
 
+	return filter, nil
+}
+
+// writeOrderPage responds with one page of orders matching filter plus pagination metadata.
+func (h *Handler) writeOrderPage(w http.ResponseWriter, r *http.Request, filter ports.ListFilter) {
+	filter, err := h.service.NormalizeListFilter(filter)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+
 	orders, err := h.service.ListOrders(r.Context(), filter)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	total, err := h.service.CountOrders(r.Context(), filter)
+	if err != nil {
+		h.writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"orders": orders})
+	if orders == nil {
+		orders = []domain.Order{}
+	}
+
+	meta := newPagination(filter, total)
+	if links := paginationLinks(r.URL, meta); links != "" {
+		w.Header().Set("Link", links)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"orders":     orders,
+		"pagination": meta,
+	})
+}
+
+// pagination describes the page that was actually applied to a list query.
+type pagination struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// newPagination describes a page of a normalized filter.
+func newPagination(filter ports.ListFilter, total int) pagination {
+	page, pageSize := filter.Pagination()
+	return pagination{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}
 }
 
+// cancelOrder cancels an order. The idempotency header is optional here: when present, a
+// retried cancel replays the original response instead of failing because the order is
+// already canceled.
 func (h *Handler) cancelOrder(w http.ResponseWriter, r *http.Request, id string) {
-	order, err := h.service.CancelOrder(r.Context(), id)
+	idemKey := h.idempotencyKey(r)
+	if idemKey == "" {
+		order, err := h.service.CancelOrder(r.Context(), id)
+		if err != nil {
+			h.writeServiceError(w, r, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"order": order})
+		return
+	}
+	if err := validateIdempotencyKey(idemKey, h.requireUUIDIdempotency); err != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid %s header: %v", h.idempotencyHeader, err))
+		return
+	}
+
+	// Cancel keys get their own namespace so they cannot collide with create keys.
+	storeKey := "cancel:" + id + ":" + idemKey
+	done, degraded := h.replayIdempotent(w, r, storeKey)
+	if done {
+		return
+	}
+
+	ctx := requestctx.WithIdempotencyKey(r.Context(), idemKey)
+	order, err := h.service.CancelOrder(ctx, id)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	body, err := json.Marshal(map[string]any{"order": order})
+	if err != nil {
+		h.writeInternalError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	stored := ports.StoredResponse{StatusCode: http.StatusOK, Body: body, OrderID: id}
+	saved, saveDegraded := h.saveIdempotent(w, r, storeKey, stored)
+	if !saved {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(idempotencyReplayHeader, "false")
+	if degraded || saveDegraded {
+		w.Header().Set(idempotencyDegradedHeader, "true")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// replayIdempotent writes the response stored for key, if any. done reports that the
+// request has been answered, by a replay or a store error; degraded reports a store error
+// that fail-open mode let through.
+func (h *Handler) replayIdempotent(w http.ResponseWriter, r *http.Request, key string) (done, degraded bool) {
+	stored, err := h.service.GetIdempotentResponse(r.Context(), key)
+	if err != nil {
+		if h.failOpen == nil {
+			h.writeInternalError(w, r, http.StatusInternalServerError, err)
+			return true, false
+		}
+		h.failOpen.report(r, "get", key, "", err)
+		return false, true
+	}
+	if stored == nil {
+		return false, false
+	}
+
+	for name, values := range restoreHeaders(*stored) {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.WriteHeader(stored.StatusCode)
+	_, _ = w.Write(stored.Body)
+	return true, false
+}
+
+// saveIdempotent stores the response for key. saved is false when the store failed and an
+// error response was written; degraded reports a store error that fail-open mode let
+// through.
+func (h *Handler) saveIdempotent(w http.ResponseWriter, r *http.Request, key string, stored ports.StoredResponse) (saved, degraded bool) {
+	if err := h.service.SaveIdempotentResponse(r.Context(), key, stored); err != nil {
+		if h.failOpen == nil {
+			h.writeInternalError(w, r, http.StatusInternalServerError, err)
+			return false, false
+		}
+		h.failOpen.report(r, "save", key, stored.OrderID, err)
+		return true, true
+	}
+	return true, false
+}
+
+func (h *Handler) bulkUpdateStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var payload app.BulkStatusInput
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid JSON payload")
+		return
+	}
+
+	results, err := h.service.BulkUpdateStatus(r.Context(), payload)
 	if err != nil {
-		if errors.Is(err, ports.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "order not found")
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+func (h *Handler) reprocessFailedOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	summary, err := h.service.ReprocessFailedOrders(r.Context(), h.reprocess)
+	if err != nil {
+		h.writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"summary": summary})
+}
+
+// countIdempotencyKeys reports how many idempotency keys were used since ?since= (RFC 3339),
+// defaulting to the last hour.
+func (h *Handler) countIdempotencyKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	since := time.Now().UTC().Add(-time.Hour)
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
 			return
 		}
-		writeError(w, http.StatusBadRequest, err.Error())
+		since = parsed
+	}
+
+	count, err := h.service.CountIdempotencyKeysSince(r.Context(), since)
+	if err != nil {
+		h.writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"order": order})
+	writeJSON(w, http.StatusOK, map[string]any{"since": since, "count": count})
+}
+
+// orderResponse decorates an order with flags derived from domain rules for clients.
+type orderResponse struct {
+	domain.Order
+	AmountDisplay string `json:"amount_display"`
+	Cancellable   bool   `json:"cancellable"`
+}
+
+func newOrderResponse(order domain.Order) orderResponse {
+	return orderResponse{Order: order, AmountDisplay: order.FormattedAmount(), Cancellable: order.IsCancellable()}
+}
+
+// orderFields lists the names accepted by ?fields=, matching orderResponse's JSON keys.
+var orderFields = map[string]bool{
+	"id":              true,
+	"reference":       true,
+	"customer_id":     true,
+	"customer_email":  true,
+	"amount":          true,
+	"amount_display":  true,
+	"status":          true,
+	"created_at":      true,
+	"updated_at":      true,
+	"metadata":        true,
+	"idempotency_key": true,
+	"cancellable":     true,
+}
+
+// parseOrderFields parses a comma-separated field list. An empty list selects every field.
+func parseOrderFields(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !orderFields[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// includeHistory embeds the order's status history in GET /v1/orders/{id}.
+const includeHistory = "history"
+
+// orderIncludes are the expansions accepted by ?include= on GET /v1/orders/{id}.
+var orderIncludes = map[string]bool{
+	includeHistory: true,
+}
+
+// parseOrderIncludes parses a comma-separated list of expansions. An empty list includes
+// nothing beyond the order.
+func parseOrderIncludes(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var includes []string
+	for _, include := range strings.Split(raw, ",") {
+		include = strings.TrimSpace(include)
+		if !orderIncludes[include] {
+			return nil, fmt.Errorf("unknown include %q", include)
+		}
+		includes = append(includes, include)
+	}
+	return includes, nil
+}
+
+// projectOrder keeps only the requested fields of an order response. Requested fields that
+// are omitted when empty, such as metadata, stay absent.
+func projectOrder(response orderResponse, fields []string) (map[string]json.RawMessage, error) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("marshal order: %w", err)
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(body, &all); err != nil {
+		return nil, fmt.Errorf("unmarshal order: %w", err)
+	}
+
+	projected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected, nil
+}
+
+// paginationLinks builds an RFC 8288 Link header with first, prev, next and last pages,
+// preserving the request's other query parameters.
+func paginationLinks(u *url.URL, p pagination) string {
+	link := func(page int, rel string) string {
+		query := u.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("page_size", strconv.Itoa(p.PageSize))
+		target := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		return fmt.Sprintf(`<%s>; rel="%s"`, target.String(), rel)
+	}
+
+	lastPage := max(p.TotalPages, 1)
+	links := []string{link(1, "first")}
+	if p.Page > 1 {
+		links = append(links, link(min(p.Page-1, lastPage), "prev"))
+	}
+	if p.Page < p.TotalPages {
+		links = append(links, link(p.Page+1, "next"))
+	}
+	links = append(links, link(lastPage, "last"))
+
+	return strings.Join(links, ", ")
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload any) {
@@ -192,16 +1094,137 @@ func writeJSON(w http.ResponseWriter, status int, payload any) {
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
-func writeError(w http.ResponseWriter, status int, message string) {
-	writeJSON(w, status, map[string]any{"error": message})
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeJSON(w, status, errorBody(r.Context(), message))
+}
+
+// errorBody builds an error response, adding the trace ID when the request was traced so
+// clients have something to quote to support.
+func errorBody(ctx context.Context, message string) map[string]any {
+	body := map[string]any{"error": message}
+	if id := telemetry.TraceID(ctx); id != "" {
+		body["trace_id"] = id
+	}
+	return body
+}
+
+// writeServiceError maps a service error to its HTTP status code. Client errors keep their
+// descriptive message; server errors go through writeInternalError.
+func (h *Handler) writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	status := serviceErrorStatus(err)
+	switch {
+	case status == http.StatusNotFound:
+		writeError(w, r, status, "order not found")
+	case status >= http.StatusInternalServerError:
+		h.writeInternalError(w, r, status, err)
+	default:
+		writeError(w, r, status, err.Error())
+	}
+}
+
+// writeInternalError logs err in full and answers with status. Unless debug errors are
+// enabled the body carries a generic message and the request ID to quote to support.
+func (h *Handler) writeInternalError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	ctx := r.Context()
+	slog.ErrorContext(ctx, "request failed", "status", status, "error", err)
+	httpTracer.RecordErrorSpan(ctx, "http.request.error", err)
+
+	if h.debugErrors {
+		writeError(w, r, status, err.Error())
+		return
+	}
+
+	body := errorBody(ctx, internalErrorMessage(status))
+	if id := requestctx.RequestID(ctx); id != "" {
+		body["request_id"] = id
+	}
+	writeJSON(w, status, body)
 }
 
-// restoreHeaders is a hook for replayed responses. For now it only sets content-type.
-func restoreHeaders(status int) http.Header {
+// internalErrorMessage is the generic body message for a 5xx status.
+func internalErrorMessage(status int) string {
+	if status == http.StatusGatewayTimeout {
+		return "request timed out"
+	}
+	return "internal server error"
+}
+
+func serviceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, app.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, app.ErrValidation), errors.Is(err, domain.ErrBlockedEmailDomain):
+		return http.StatusBadRequest
+	case errors.Is(err, app.ErrNotCancellable):
+		return http.StatusConflict
+	case errors.Is(err, ports.ErrQueryTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (f *failOpen) report(r *http.Request, operation, key, orderID string, err error) {
+	if f.metrics != nil {
+		f.metrics.RecordIdempotencyFailOpen(r.Context(), operation)
+	}
+	if f.logger != nil {
+		f.logger.WarnContext(r.Context(), "idempotency store unavailable, continuing without replay protection",
+			"operation", operation,
+			"idempotency_key", key,
+			"order_id", orderID,
+			"error", err,
+		)
+	}
+}
+
+// idempotencyKey reads the key from the primary header, falling back to any aliases.
+func (h *Handler) idempotencyKey(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get(h.idempotencyHeader)); key != "" {
+		return key
+	}
+	for _, alias := range h.idempotencyAliases {
+		if key := strings.TrimSpace(r.Header.Get(alias)); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// validateIdempotencyKey enforces length bounds and, optionally, UUID formatting.
+func validateIdempotencyKey(key string, requireUUID bool) error {
+	if len(key) < minIdempotencyKeyLength || len(key) > maxIdempotencyKeyLength {
+		return fmt.Errorf("must be between %d and %d characters", minIdempotencyKeyLength, maxIdempotencyKeyLength)
+	}
+	if requireUUID && !uuidPattern.MatchString(key) {
+		return errors.New("must be a UUID")
+	}
+	return nil
+}
+
+// restoreHeaders builds the headers of a replayed response, marking it as a replay. Only a
+// 202 carries Retry-After; a completed response has nothing left to wait for, and a 201
+// points at the created order with Location.
+func restoreHeaders(stored ports.StoredResponse) http.Header {
 	header := http.Header{}
 	header.Set("Content-Type", "application/json")
-	if status == http.StatusAccepted {
-		header.Set("Retry-After", "0")
+	header.Set(idempotencyReplayHeader, "true")
+	switch stored.StatusCode {
+	case http.StatusAccepted:
+		header.Set("Retry-After", retryAfterSeconds(stored.RetryAfter))
+	case http.StatusCreated:
+		if stored.OrderID != "" {
+			header.Set("Location", "/v1/orders/"+stored.OrderID)
+		}
 	}
 	return header
 }
+
+// retryAfterSeconds formats d as a Retry-After delay, rounding up to whole seconds so a
+// sub-second estimate is not advertised as "retry immediately".
+func retryAfterSeconds(d time.Duration) string {
+	if d <= 0 {
+		return "0"
+	}
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
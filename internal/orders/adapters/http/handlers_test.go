@@ -0,0 +1,1543 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/audit"
+	auditmemory "github.com/dejobratic/tbd/internal/audit/memory"
+	"github.com/dejobratic/tbd/internal/auth"
+	"github.com/dejobratic/tbd/internal/kafka"
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/app"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	ordersmetrics "github.com/dejobratic/tbd/internal/orders/metrics"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/dejobratic/tbd/internal/requestctx"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		requireUUID bool
+		wantErr     bool
+	}{
+		{"accepts key within length bounds", "order-retry-1", false, false},
+		{"rejects key shorter than minimum", "short", false, true},
+		{"rejects key longer than maximum", strings.Repeat("k", 256), false, true},
+		{"accepts key at maximum length", strings.Repeat("k", 255), false, false},
+		{"accepts UUID when UUID required", "550e8400-e29b-41d4-a716-446655440000", true, false},
+		{"rejects non-UUID when UUID required", "order-retry-1", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIdempotencyKey(tt.key, tt.requireUUID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateIdempotencyKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadIdempotencyKey(t *testing.T) {
+	t.Run("reads key from default header", func(t *testing.T) {
+		h := NewHandler(nil)
+		r := httptest.NewRequest(http.MethodPost, "/v1/orders", nil)
+		r.Header.Set("Idempotency-Key", " key-12345 ")
+
+		if got := h.idempotencyKey(r); got != "key-12345" {
+			t.Errorf("expected key-12345, got %q", got)
+		}
+	})
+
+	t.Run("reads key from configured header", func(t *testing.T) {
+		h := NewHandler(nil, WithIdempotencyHeader("X-Request-Key"))
+		r := httptest.NewRequest(http.MethodPost, "/v1/orders", nil)
+		r.Header.Set("Idempotency-Key", "ignored-key")
+		r.Header.Set("X-Request-Key", "key-12345")
+
+		if got := h.idempotencyKey(r); got != "key-12345" {
+			t.Errorf("expected key-12345, got %q", got)
+		}
+	})
+
+	t.Run("falls back to aliases when primary header is absent", func(t *testing.T) {
+		h := NewHandler(nil, WithIdempotencyHeaderAliases("X-Idempotency-Key"))
+		r := httptest.NewRequest(http.MethodPost, "/v1/orders", nil)
+		r.Header.Set("X-Idempotency-Key", "key-12345")
+
+		if got := h.idempotencyKey(r); got != "key-12345" {
+			t.Errorf("expected key-12345, got %q", got)
+		}
+	})
+
+	t.Run("returns empty key when no header is present", func(t *testing.T) {
+		h := NewHandler(nil, WithIdempotencyHeaderAliases("X-Idempotency-Key"))
+		r := httptest.NewRequest(http.MethodPost, "/v1/orders", nil)
+
+		if got := h.idempotencyKey(r); got != "" {
+			t.Errorf("expected empty key, got %q", got)
+		}
+	})
+}
+
+func TestRestoreHeaders(t *testing.T) {
+	t.Run("marks replayed responses", func(t *testing.T) {
+		header := restoreHeaders(ports.StoredResponse{StatusCode: http.StatusAccepted})
+
+		if got := header.Get("Idempotency-Replayed"); got != "true" {
+			t.Errorf("expected Idempotency-Replayed true, got %q", got)
+		}
+		if got := header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("expected application/json content type, got %q", got)
+		}
+	})
+
+	t.Run("replays the stored Retry-After estimate rounded up to seconds", func(t *testing.T) {
+		header := restoreHeaders(ports.StoredResponse{StatusCode: http.StatusAccepted, RetryAfter: 1500 * time.Millisecond})
+
+		if got := header.Get("Retry-After"); got != "2" {
+			t.Errorf("expected Retry-After 2, got %q", got)
+		}
+	})
+
+	t.Run("omits Retry-After for completed responses", func(t *testing.T) {
+		header := restoreHeaders(ports.StoredResponse{StatusCode: http.StatusCreated, RetryAfter: time.Second})
+
+		if got := header.Get("Retry-After"); got != "" {
+			t.Errorf("expected no Retry-After, got %q", got)
+		}
+	})
+
+	t.Run("points created responses at the order", func(t *testing.T) {
+		header := restoreHeaders(ports.StoredResponse{StatusCode: http.StatusCreated, OrderID: "order-1"})
+
+		if got := header.Get("Location"); got != "/v1/orders/order-1" {
+			t.Errorf("expected Location /v1/orders/order-1, got %q", got)
+		}
+	})
+}
+
+func TestOrderResponse(t *testing.T) {
+	t.Run("exposes cancellable flag alongside order fields", func(t *testing.T) {
+		order := domain.Order{ID: "order-1", Status: domain.StatusPending}
+
+		body, err := json.Marshal(newOrderResponse(order))
+		if err != nil {
+			t.Fatalf("failed to marshal response: %v", err)
+		}
+
+		var decoded map[string]any
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if decoded["id"] != "order-1" {
+			t.Errorf("expected id order-1, got %v", decoded["id"])
+		}
+		if decoded["cancellable"] != true {
+			t.Errorf("expected cancellable true, got %v", decoded["cancellable"])
+		}
+	})
+
+	t.Run("exposes the display amount next to the raw amount", func(t *testing.T) {
+		order := domain.Order{ID: "order-1", Amount: domain.NewMoney(1999, "BHD")}
+
+		body, err := json.Marshal(newOrderResponse(order))
+		if err != nil {
+			t.Fatalf("failed to marshal response: %v", err)
+		}
+
+		var decoded struct {
+			Amount        domain.Money `json:"amount"`
+			AmountDisplay string       `json:"amount_display"`
+		}
+		if err := json.Unmarshal(body, &decoded); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+		if decoded.AmountDisplay != "BHD 1.999" {
+			t.Errorf("expected amount_display BHD 1.999, got %q", decoded.AmountDisplay)
+		}
+		if decoded.Amount.AmountCents != 1999 {
+			t.Errorf("expected amount_cents 1999, got %d", decoded.Amount.AmountCents)
+		}
+	})
+}
+
+func TestNewPagination(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter ports.ListFilter
+		total  int
+		want   pagination
+	}{
+		{"applies defaults", ports.ListFilter{}, 45, pagination{Page: 1, PageSize: 20, Total: 45, TotalPages: 3}},
+		{"uses the normalized page size", ports.ListFilter{Page: 2, PageSize: 100}, 150, pagination{Page: 2, PageSize: 100, Total: 150, TotalPages: 2}},
+		{"reports zero pages when empty", ports.ListFilter{Page: 1, PageSize: 10}, 0, pagination{Page: 1, PageSize: 10, Total: 0, TotalPages: 0}},
+		{"rounds partial page up", ports.ListFilter{PageSize: 10}, 11, pagination{Page: 1, PageSize: 10, Total: 11, TotalPages: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newPagination(tt.filter, tt.total); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPaginationLinks(t *testing.T) {
+	t.Run("includes prev and next on a middle page and preserves filters", func(t *testing.T) {
+		u, _ := url.Parse("/v1/orders?status=pending&page=2&page_size=10")
+
+		links := paginationLinks(u, pagination{Page: 2, PageSize: 10, Total: 35, TotalPages: 4})
+
+		for _, want := range []string{
+			`</v1/orders?page=1&page_size=10&status=pending>; rel="first"`,
+			`</v1/orders?page=1&page_size=10&status=pending>; rel="prev"`,
+			`</v1/orders?page=3&page_size=10&status=pending>; rel="next"`,
+			`</v1/orders?page=4&page_size=10&status=pending>; rel="last"`,
+		} {
+			if !strings.Contains(links, want) {
+				t.Errorf("expected %s in %s", want, links)
+			}
+		}
+	})
+
+	t.Run("omits next on the last page", func(t *testing.T) {
+		u, _ := url.Parse("/v1/orders")
+
+		links := paginationLinks(u, pagination{Page: 4, PageSize: 10, Total: 35, TotalPages: 4})
+
+		if strings.Contains(links, `rel="next"`) {
+			t.Errorf("expected no next link, got %s", links)
+		}
+		if !strings.Contains(links, `rel="prev"`) {
+			t.Errorf("expected prev link, got %s", links)
+		}
+	})
+
+	t.Run("omits prev on the first page", func(t *testing.T) {
+		u, _ := url.Parse("/v1/orders")
+
+		links := paginationLinks(u, pagination{Page: 1, PageSize: 20, Total: 0, TotalPages: 0})
+
+		if strings.Contains(links, `rel="prev"`) || strings.Contains(links, `rel="next"`) {
+			t.Errorf("expected only first and last links, got %s", links)
+		}
+	})
+}
+
+func TestHeadOrder(t *testing.T) {
+	repo := memory.NewRepository()
+	order := domain.Order{ID: "order-1", Status: domain.StatusPending, UpdatedAt: time.Now().UTC()}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	t.Run("returns the GET headers without body when order exists", func(t *testing.T) {
+		get := serve(http.MethodGet, "/v1/orders/order-1")
+		rec := serve(http.MethodHead, "/v1/orders/order-1")
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("expected empty body, got %q", rec.Body.String())
+		}
+		if etag := rec.Header().Get("ETag"); etag == "" || etag != get.Header().Get("ETag") {
+			t.Errorf("expected the GET ETag %q, got %q", get.Header().Get("ETag"), etag)
+		}
+		if got, want := rec.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+			t.Errorf("expected Content-Length %s, got %s", want, got)
+		}
+	})
+
+	t.Run("changes the ETag when the order is updated", func(t *testing.T) {
+		before := serve(http.MethodHead, "/v1/orders/order-1").Header().Get("ETag")
+		if err := repo.UpdateStatus(context.Background(), "order-1", domain.StatusProcessing); err != nil {
+			t.Fatalf("failed to update order: %v", err)
+		}
+		if after := serve(http.MethodHead, "/v1/orders/order-1").Header().Get("ETag"); after == before {
+			t.Errorf("expected a new ETag after the update, got %q", after)
+		}
+	})
+
+	t.Run("returns 404 with the JSON error headers when order does not exist", func(t *testing.T) {
+		get := serve(http.MethodGet, "/v1/orders/missing")
+		rec := serve(http.MethodHead, "/v1/orders/missing")
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected JSON content type, got %q", ct)
+		}
+		if got, want := rec.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+			t.Errorf("expected Content-Length %s, got %s", want, got)
+		}
+	})
+}
+
+// fakeService stubs the Service calls a test exercises; any other call panics through the
+// nil embedded interface.
+type fakeService struct {
+	Service
+	getOrder func(ctx context.Context, id string) (*domain.Order, error)
+}
+
+func (f fakeService) GetOrder(ctx context.Context, id string) (*domain.Order, error) {
+	return f.getOrder(ctx, id)
+}
+
+func TestHandlerServiceErrors(t *testing.T) {
+	timeout := fmt.Errorf("%w: get order", ports.ErrQueryTimeout)
+	service := fakeService{
+		getOrder: func(context.Context, string) (*domain.Order, error) { return nil, timeout },
+	}
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		t.Run(method+" maps a query timeout to 504", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(method, "/v1/orders/order-1", nil))
+
+			if rec.Code != http.StatusGatewayTimeout {
+				t.Errorf("expected status 504, got %d", rec.Code)
+			}
+		})
+	}
+}
+
+func TestGetOrderGoneForTerminal(t *testing.T) {
+	repo := memory.NewRepository()
+	for _, order := range []domain.Order{
+		{ID: "order-pending", Status: domain.StatusPending},
+		{ID: "order-canceled", Status: domain.StatusCanceled},
+		{ID: "order-failed", Status: domain.StatusFailed},
+		{ID: "order-completed", Status: domain.StatusCompleted},
+	} {
+		if err := repo.Create(context.Background(), order); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+
+	tests := []struct {
+		id      string
+		enabled bool
+		want    int
+	}{
+		{"order-canceled", false, http.StatusOK},
+		{"order-canceled", true, http.StatusGone},
+		{"order-failed", true, http.StatusGone},
+		{"order-completed", true, http.StatusOK},
+		{"order-pending", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s with flag %t", tt.id, tt.enabled), func(t *testing.T) {
+			mux := http.NewServeMux()
+			NewHandler(service, WithGoneForTerminalOrders(tt.enabled)).Register(mux)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/"+tt.id, nil))
+
+			if rec.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, rec.Code)
+			}
+			var body struct {
+				Order domain.Order `json:"order"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Order.ID != tt.id {
+				t.Errorf("expected %s in the body, got %s (%v)", tt.id, rec.Body.String(), err)
+			}
+		})
+	}
+}
+
+func TestGetOrderFields(t *testing.T) {
+	repo := memory.NewRepository()
+	if err := repo.Create(context.Background(), domain.Order{ID: "order-1", CustomerEmail: "user@example.com", Status: domain.StatusPending}); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	t.Run("returns only the requested fields", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/order-1?fields=id,status", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		var body struct {
+			Order map[string]any `json:"order"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(body.Order) != 2 || body.Order["id"] != "order-1" || body.Order["status"] != "pending" {
+			t.Errorf("expected only id and status, got %v", body.Order)
+		}
+	})
+
+	t.Run("returns the full order when fields is absent", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/order-1", nil))
+
+		var body struct {
+			Order map[string]any `json:"order"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body.Order["customer_email"] != "user@example.com" {
+			t.Errorf("expected full order, got %v", body.Order)
+		}
+	})
+
+	t.Run("rejects unknown fields with 400", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/order-1?fields=id,secret", nil))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestGetOrderIncludeHistory(t *testing.T) {
+	repo := memory.NewRepository()
+	if err := repo.Create(context.Background(), domain.Order{ID: "order-1", CustomerEmail: "user@example.com", Status: domain.StatusPending}); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+	if err := repo.UpdateStatus(context.Background(), "order-1", domain.StatusCompleted); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	get := func(target string) (*httptest.ResponseRecorder, map[string]json.RawMessage) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var body map[string]json.RawMessage
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec, body
+	}
+
+	t.Run("embeds the history when requested", func(t *testing.T) {
+		rec, body := get("/v1/orders/order-1?include=history")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		var history []domain.StatusTransition
+		if err := json.Unmarshal(body["history"], &history); err != nil {
+			t.Fatalf("failed to decode history: %v", err)
+		}
+		if len(history) == 0 || history[len(history)-1].To != domain.StatusCompleted {
+			t.Errorf("expected history ending in completed, got %s", body["history"])
+		}
+		if _, ok := body["order"]; !ok {
+			t.Error("expected the order in the response")
+		}
+	})
+
+	t.Run("omits the history by default", func(t *testing.T) {
+		_, body := get("/v1/orders/order-1")
+
+		if _, ok := body["history"]; ok {
+			t.Errorf("expected no history key, got %s", body["history"])
+		}
+	})
+
+	t.Run("rejects unknown includes with 400", func(t *testing.T) {
+		rec, _ := get("/v1/orders/order-1?include=history,payments")
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestRouteScopes(t *testing.T) {
+	repo := memory.NewRepository()
+	if err := repo.Create(context.Background(), domain.Order{ID: "order-1", Status: domain.StatusPending}); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service, WithRouteScopes(map[string]string{RouteCancelOrder: "orders:admin"})).Register(mux)
+
+	serve := func(method, path, scope string) int {
+		req := httptest.NewRequest(method, path, nil)
+		claims := &auth.Claims{Subject: "user-1", Extra: map[string]any{"scope": scope}}
+		req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("returns 403 when the caller lacks the route scope", func(t *testing.T) {
+		if got := serve(http.MethodPost, "/v1/orders/order-1/cancel", "orders:read"); got != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", got)
+		}
+	})
+
+	t.Run("allows callers with the route scope", func(t *testing.T) {
+		if got := serve(http.MethodPost, "/v1/orders/order-1/cancel", "orders:admin"); got != http.StatusOK {
+			t.Errorf("expected status 200, got %d", got)
+		}
+	})
+
+	t.Run("leaves unmapped routes open", func(t *testing.T) {
+		if got := serve(http.MethodGet, "/v1/orders/order-1", ""); got != http.StatusOK {
+			t.Errorf("expected status 200, got %d", got)
+		}
+	})
+}
+
+// failingIdempotencyStore fails every call, simulating an unavailable store.
+type failingIdempotencyStore struct{}
+
+func (failingIdempotencyStore) Get(context.Context, string) (*ports.StoredResponse, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingIdempotencyStore) Save(context.Context, string, ports.StoredResponse) error {
+	return errors.New("connection refused")
+}
+
+func (failingIdempotencyStore) CountSince(context.Context, time.Time) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func TestCreateOrderDryRun(t *testing.T) {
+	newRequest := func(target string) *http.Request {
+		body := `{"customer_id":"customer-1","customer_email":"user@example.com","amount_cents":1999}`
+		return httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	}
+
+	tests := []struct {
+		name    string
+		request *http.Request
+	}{
+		{name: "query parameter", request: newRequest("/v1/orders?dry_run=true")},
+		{name: "header", request: func() *http.Request {
+			req := newRequest("/v1/orders")
+			req.Header.Set(dryRunHeader, "true")
+			return req
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := memory.NewRepository()
+			events := kafka.NewSpyEventBus()
+			service := app.NewService(repo, events, nil, slog.Default(), nil)
+			mux := http.NewServeMux()
+			NewHandler(service).Register(mux)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, tt.request)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var body struct {
+				Order  domain.Order `json:"order"`
+				DryRun bool         `json:"dry_run"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if !body.DryRun || body.Order.ID != "" || body.Order.CustomerID != "customer-1" {
+				t.Errorf("unexpected dry-run response: %s", rec.Body.String())
+			}
+			if count, _ := repo.Count(context.Background(), ports.ListFilter{}); count != 0 {
+				t.Errorf("expected nothing persisted, got %d orders", count)
+			}
+			if published := events.PublishedCreated(); len(published) != 0 {
+				t.Errorf("expected no events, got %v", published)
+			}
+		})
+	}
+
+	t.Run("rejects an invalid dry_run value", func(t *testing.T) {
+		service := app.NewService(memory.NewRepository(), kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+		mux := http.NewServeMux()
+		NewHandler(service).Register(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, newRequest("/v1/orders?dry_run=maybe"))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestCreateOrderIdempotencyFailOpen(t *testing.T) {
+	businessMetrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	newRequest := func() *http.Request {
+		body := `{"customer_id":"customer-1","customer_email":"user@example.com","amount_cents":1999}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-12345")
+		return req
+	}
+
+	t.Run("returns 500 when the store fails in fail-closed mode", func(t *testing.T) {
+		repo := memory.NewRepository()
+		service := app.NewService(repo, kafka.NewSpyEventBus(), failingIdempotencyStore{}, slog.Default(), businessMetrics)
+		mux := http.NewServeMux()
+		NewHandler(service).Register(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, newRequest())
+
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", rec.Code)
+		}
+	})
+
+	t.Run("creates the order and flags the response in fail-open mode", func(t *testing.T) {
+		repo := memory.NewRepository()
+		service := app.NewService(repo, kafka.NewSpyEventBus(), failingIdempotencyStore{}, slog.Default(), businessMetrics)
+		mux := http.NewServeMux()
+		NewHandler(service, WithIdempotencyFailOpen(slog.Default(), nil)).Register(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, newRequest())
+
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("expected status 202, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get(idempotencyDegradedHeader); got != "true" {
+			t.Errorf("expected %s: true, got %q", idempotencyDegradedHeader, got)
+		}
+	})
+}
+
+func TestCreateOrderStatus(t *testing.T) {
+	businessMetrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	store := &mapIdempotencyStore{responses: map[string]ports.StoredResponse{}}
+	service := app.NewService(memory.NewRepository(), kafka.NewSpyEventBus(), store, slog.Default(), businessMetrics)
+	create := func(handler *Handler, key string) *httptest.ResponseRecorder {
+		mux := http.NewServeMux()
+		handler.Register(mux)
+		body := `{"customer_id":"customer-1","customer_email":"user@example.com","amount_cents":1999}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("answers 201 with Location when configured", func(t *testing.T) {
+		rec := create(NewHandler(service, WithCreateStatus(http.StatusCreated), WithCreateRetryAfter(time.Second)), "created-key")
+
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Location"); !strings.HasPrefix(got, "/v1/orders/") {
+			t.Errorf("expected Location of the order, got %q", got)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "" {
+			t.Errorf("expected no Retry-After, got %q", got)
+		}
+	})
+
+	t.Run("replays the originally stored status", func(t *testing.T) {
+		first := create(NewHandler(service), "accepted-key")
+		retry := create(NewHandler(service, WithCreateStatus(http.StatusCreated)), "accepted-key")
+
+		if first.Code != http.StatusAccepted || retry.Code != http.StatusAccepted {
+			t.Fatalf("expected both attempts to return 202, got %d and %d", first.Code, retry.Code)
+		}
+		if got := retry.Header().Get(idempotencyReplayHeader); got != "true" {
+			t.Errorf("expected retry to be a replay, got %q", got)
+		}
+		if got := retry.Header().Get("Location"); got != "" {
+			t.Errorf("expected no Location on a replayed 202, got %q", got)
+		}
+	})
+
+	t.Run("ignores unsupported codes", func(t *testing.T) {
+		rec := create(NewHandler(service, WithCreateStatus(http.StatusOK)), "unsupported-key")
+
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("expected status 202, got %d", rec.Code)
+		}
+	})
+}
+
+func TestCreateOrderIdempotent(t *testing.T) {
+	businessMetrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	setup := func() (*http.ServeMux, *memory.Repository) {
+		repo := memory.NewRepository()
+		store := &lockedIdempotencyStore{
+			mapIdempotencyStore: mapIdempotencyStore{responses: map[string]ports.StoredResponse{}},
+			getDelay:            5 * time.Millisecond,
+		}
+		service := app.NewService(repo, kafka.NewSpyEventBus(), store, slog.Default(), businessMetrics)
+		mux := http.NewServeMux()
+		NewHandler(service).Register(mux)
+		return mux, repo
+	}
+	create := func(mux *http.ServeMux) *httptest.ResponseRecorder {
+		body := `{"customer_id":"customer-1","customer_email":"user@example.com","amount_cents":1999}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-12345")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	orderID := func(t *testing.T, rec *httptest.ResponseRecorder) string {
+		t.Helper()
+		var body struct {
+			Order domain.Order `json:"order"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return body.Order.ID
+	}
+
+	t.Run("returns the first order for a retried key", func(t *testing.T) {
+		mux, repo := setup()
+
+		first := create(mux)
+		retry := create(mux)
+
+		if first.Code != http.StatusAccepted || retry.Code != http.StatusAccepted {
+			t.Fatalf("expected both attempts to return 202, got %d and %d", first.Code, retry.Code)
+		}
+		if got := first.Header().Get(idempotencyReplayHeader); got != "false" {
+			t.Errorf("expected first attempt not to be a replay, got %q", got)
+		}
+		if got := retry.Header().Get(idempotencyReplayHeader); got != "true" {
+			t.Errorf("expected retry to be a replay, got %q", got)
+		}
+		if orderID(t, first) != orderID(t, retry) {
+			t.Errorf("expected the same order, got %s and %s", orderID(t, first), orderID(t, retry))
+		}
+		if count, _ := repo.Count(context.Background(), ports.ListFilter{}); count != 1 {
+			t.Errorf("expected 1 order, got %d", count)
+		}
+	})
+
+	t.Run("replays the stored body even after the order changes", func(t *testing.T) {
+		mux, repo := setup()
+
+		first := create(mux)
+		if err := repo.UpdateStatus(context.Background(), orderID(t, first), domain.StatusCompleted); err != nil {
+			t.Fatalf("failed to update order: %v", err)
+		}
+		retry := create(mux)
+
+		if retry.Body.String() != first.Body.String() {
+			t.Errorf("expected the stored body %s, got %s", first.Body.String(), retry.Body.String())
+		}
+	})
+
+	t.Run("replays a retry without reading its body", func(t *testing.T) {
+		mux, repo := setup()
+
+		first := create(mux)
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders", strings.NewReader(`{"amount_cents":`))
+		req.Header.Set("Idempotency-Key", "key-12345")
+		retry := httptest.NewRecorder()
+		mux.ServeHTTP(retry, req)
+
+		if retry.Code != http.StatusAccepted {
+			t.Fatalf("expected the replayed 202, got %d: %s", retry.Code, retry.Body.String())
+		}
+		if retry.Body.String() != first.Body.String() {
+			t.Errorf("expected the stored body %s, got %s", first.Body.String(), retry.Body.String())
+		}
+		if count, _ := repo.Count(context.Background(), ports.ListFilter{}); count != 1 {
+			t.Errorf("expected 1 order, got %d", count)
+		}
+	})
+
+	t.Run("creates one order for concurrent retries", func(t *testing.T) {
+		mux, repo := setup()
+
+		responses := make([]*httptest.ResponseRecorder, 8)
+		var wg sync.WaitGroup
+		for i := range responses {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				responses[i] = create(mux)
+			}()
+		}
+		wg.Wait()
+
+		for _, rec := range responses {
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("expected every attempt to return 202, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if orderID(t, rec) != orderID(t, responses[0]) {
+				t.Errorf("expected the same order, got %s and %s", orderID(t, rec), orderID(t, responses[0]))
+			}
+		}
+		if count, _ := repo.Count(context.Background(), ports.ListFilter{}); count != 1 {
+			t.Errorf("expected 1 order, got %d", count)
+		}
+	})
+}
+
+func TestCreateOrderRejectionMetrics(t *testing.T) {
+	businessMetrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	validBody := `{"customer_id":"customer-1","customer_email":"user@example.com","amount_cents":1999}`
+
+	tests := []struct {
+		name       string
+		body       string
+		key        string
+		wantStatus int
+		wantReason string
+	}{
+		{name: "missing key", body: validBody, wantStatus: http.StatusBadRequest, wantReason: CreateRejectedMissingKey},
+		{name: "bad json", body: `{"customer_id":`, key: "key-12345", wantStatus: http.StatusBadRequest, wantReason: CreateRejectedBadJSON},
+		{name: "too large", body: `{"customer_id":"` + strings.Repeat("c", 256) + `"}`, key: "key-12345", wantStatus: http.StatusRequestEntityTooLarge, wantReason: CreateRejectedTooLarge},
+		{name: "validation", body: `{"customer_id":"customer-1","customer_email":"user@example.com","amount_cents":-1}`, key: "key-12345", wantStatus: http.StatusBadRequest, wantReason: CreateRejectedValidation},
+		{name: "accepted", body: validBody, key: "key-12345", wantStatus: http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			metrics, err := NewMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+			if err != nil {
+				t.Fatalf("NewMetrics() failed: %v", err)
+			}
+			store := &mapIdempotencyStore{responses: map[string]ports.StoredResponse{}}
+			service := app.NewService(memory.NewRepository(), kafka.NewSpyEventBus(), store, slog.Default(), businessMetrics)
+			mux := http.NewServeMux()
+			NewHandler(service, WithMaxCreateBodyBytes(int64(len(validBody)+64)), WithCreateRejectionMetrics(metrics)).Register(mux)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/orders", strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set("Idempotency-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+
+			var rm metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &rm); err != nil {
+				t.Fatalf("Failed to collect metrics: %v", err)
+			}
+			got := map[string]int64{}
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					if m.Name != "orders_create_rejected_total" {
+						continue
+					}
+					for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+						reason, _ := dp.Attributes.Value("reason")
+						got[reason.AsString()] += dp.Value
+					}
+				}
+			}
+			if tt.wantReason == "" && len(got) != 0 {
+				t.Errorf("expected no rejections, got %v", got)
+			}
+			if tt.wantReason != "" && (len(got) != 1 || got[tt.wantReason] != 1) {
+				t.Errorf("expected one %s rejection, got %v", tt.wantReason, got)
+			}
+		})
+	}
+}
+
+// lockedIdempotencyStore is a mapIdempotencyStore safe for concurrent use. Each Get waits
+// getDelay after reading, widening the window in which concurrent creates could race.
+type lockedIdempotencyStore struct {
+	mu sync.Mutex
+	mapIdempotencyStore
+	getDelay time.Duration
+}
+
+func (s *lockedIdempotencyStore) Get(ctx context.Context, key string) (*ports.StoredResponse, error) {
+	s.mu.Lock()
+	stored, err := s.mapIdempotencyStore.Get(ctx, key)
+	s.mu.Unlock()
+	time.Sleep(s.getDelay)
+	return stored, err
+}
+
+func (s *lockedIdempotencyStore) Save(ctx context.Context, key string, response ports.StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mapIdempotencyStore.Save(ctx, key, response)
+}
+
+// mapIdempotencyStore keeps stored responses in a map.
+type mapIdempotencyStore struct {
+	responses map[string]ports.StoredResponse
+}
+
+func (s *mapIdempotencyStore) Get(_ context.Context, key string) (*ports.StoredResponse, error) {
+	stored, ok := s.responses[key]
+	if !ok {
+		return nil, nil
+	}
+	return &stored, nil
+}
+
+func (s *mapIdempotencyStore) Save(_ context.Context, key string, response ports.StoredResponse) error {
+	s.responses[key] = response
+	return nil
+}
+
+func (s *mapIdempotencyStore) CountSince(context.Context, time.Time) (int64, error) {
+	return int64(len(s.responses)), nil
+}
+
+func TestCancelOrderIdempotency(t *testing.T) {
+	setup := func(t *testing.T) (*http.ServeMux, *mapIdempotencyStore) {
+		t.Helper()
+		repo := memory.NewRepository()
+		if err := repo.Create(context.Background(), domain.Order{ID: "order-1", Status: domain.StatusPending}); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+		store := &mapIdempotencyStore{responses: map[string]ports.StoredResponse{}}
+		service := app.NewService(repo, kafka.NewSpyEventBus(), store, slog.Default(), nil)
+		mux := http.NewServeMux()
+		NewHandler(service).Register(mux)
+		return mux, store
+	}
+
+	cancel := func(mux *http.ServeMux, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders/order-1/cancel", nil)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("replays the original response for a retried cancel", func(t *testing.T) {
+		mux, store := setup(t)
+
+		first := cancel(mux, "cancel-key-1")
+		retry := cancel(mux, "cancel-key-1")
+
+		if first.Code != http.StatusOK || retry.Code != http.StatusOK {
+			t.Fatalf("expected both attempts to return 200, got %d and %d", first.Code, retry.Code)
+		}
+		if got := retry.Header().Get(idempotencyReplayHeader); got != "true" {
+			t.Errorf("expected retry to be a replay, got %q", got)
+		}
+		if retry.Body.String() != first.Body.String() {
+			t.Errorf("expected replayed body %q, got %q", first.Body.String(), retry.Body.String())
+		}
+		if _, ok := store.responses["cancel:order-1:cancel-key-1"]; !ok {
+			t.Error("expected response stored under the cancel namespace")
+		}
+	})
+
+	t.Run("keeps non-idempotent behavior without the header", func(t *testing.T) {
+		mux, store := setup(t)
+
+		if got := cancel(mux, "").Code; got != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", got)
+		}
+		if got := cancel(mux, "").Code; got != http.StatusConflict {
+			t.Errorf("expected repeated cancel to conflict, got %d", got)
+		}
+		if len(store.responses) != 0 {
+			t.Errorf("expected nothing stored, got %d responses", len(store.responses))
+		}
+	})
+}
+
+func TestRouteTemplates(t *testing.T) {
+	repo := memory.NewRepository()
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/v1/orders", "/v1/orders"},
+		{http.MethodGet, "/v1/orders/abc123", "/v1/orders/{id}"},
+		{http.MethodGet, "/v1/orders/def456", "/v1/orders/{id}"},
+		{http.MethodGet, "/v1/orders/abc123/history", "/v1/orders/{id}/history"},
+		{http.MethodPost, "/v1/orders/abc123/cancel", "/v1/orders/{id}/cancel"},
+		{http.MethodGet, "/v1/orders/by-reference/ORD-2025-000001", "/v1/orders/by-reference/{reference}"},
+		{http.MethodGet, "/v1/customers/customer-1/orders", "/v1/customers/{id}/orders"},
+		{http.MethodGet, "/v1/customers/customer-1", UnmatchedRoute},
+		{http.MethodGet, "/unknown", UnmatchedRoute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			ctx, _ := requestctx.WithRoute(context.Background())
+			req := httptest.NewRequest(tt.method, tt.path, nil).WithContext(ctx)
+
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got := routeTemplate(ctx); got != tt.want {
+				t.Errorf("expected route %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	repo := memory.NewRepository()
+	ctx := context.Background()
+	if err := repo.Create(ctx, domain.Order{ID: "order-1", Status: domain.StatusPending}); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, "order-1", domain.StatusProcessing); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	t.Run("returns transitions", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/order-1/history", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		var response struct {
+			History []domain.StatusTransition `json:"history"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.History) != 1 || response.History[0].To != domain.StatusProcessing {
+			t.Errorf("expected one transition to processing, got %+v", response.History)
+		}
+	})
+
+	t.Run("returns 404 for unknown order", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/missing/history", nil))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rec.Code)
+		}
+	})
+}
+
+func TestListCustomerOrders(t *testing.T) {
+	repo := memory.NewRepository()
+	for _, order := range []domain.Order{
+		{ID: "order-1", CustomerID: "customer-1", Status: domain.StatusPending},
+		{ID: "order-2", CustomerID: "customer-2", Status: domain.StatusPending},
+	} {
+		if err := repo.Create(context.Background(), order); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	t.Run("lists only the customer's orders", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers/customer-1/orders", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		var response struct {
+			Orders     []domain.Order `json:"orders"`
+			Pagination pagination     `json:"pagination"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Orders) != 1 || response.Orders[0].ID != "order-1" {
+			t.Errorf("expected only order-1, got %+v", response.Orders)
+		}
+		if response.Pagination.Total != 1 {
+			t.Errorf("expected total 1, got %d", response.Pagination.Total)
+		}
+	})
+
+	t.Run("returns 404 for paths other than orders", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers/customer-1", nil))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rec.Code)
+		}
+	})
+}
+
+func TestListOrdersUpdatedAfter(t *testing.T) {
+	repo := memory.NewRepository()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, id := range []string{"order-1", "order-2", "order-3"} {
+		at := base.Add(time.Duration(i) * time.Hour)
+		order := domain.Order{ID: id, CustomerID: "customer-1", Status: domain.StatusPending, CreatedAt: at, UpdatedAt: at}
+		if err := repo.Create(context.Background(), order); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	t.Run("lists changes since the timestamp, oldest first", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders?updated_after=2025-01-01T01:00:00Z", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Orders []domain.Order `json:"orders"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		var got []string
+		for _, order := range response.Orders {
+			got = append(got, order.ID)
+		}
+		if !slices.Equal(got, []string{"order-2", "order-3"}) {
+			t.Errorf("expected order-2 then order-3, got %v", got)
+		}
+	})
+
+	t.Run("rejects a malformed timestamp", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders?updated_after=yesterday", nil))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestListOrdersCurrency(t *testing.T) {
+	service := app.NewService(memory.NewRepository(), kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	for _, currency := range []string{"ZZZ", "EURO"} {
+		t.Run("rejects "+currency, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders?currency="+currency, nil))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", rec.Code)
+			}
+		})
+	}
+
+	t.Run("accepts an ISO 4217 code", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders?currency=eur", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestListCustomers(t *testing.T) {
+	repo := memory.NewRepository()
+	for _, order := range []domain.Order{
+		{ID: "order-1", CustomerID: "customer-1", CustomerEmail: "alice@example.com", Status: domain.StatusPending},
+		{ID: "order-2", CustomerID: "customer-1", CustomerEmail: "Alice@Example.com", Status: domain.StatusCompleted},
+		{ID: "order-3", CustomerID: "customer-2", CustomerEmail: "bob@example.com", Status: domain.StatusCompleted},
+	} {
+		if err := repo.Create(context.Background(), order); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	t.Run("lists each customer once", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers?status=completed", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Customers []string `json:"customers"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if !slices.Equal(response.Customers, []string{"alice@example.com", "bob@example.com"}) {
+			t.Errorf("expected alice and bob, got %v", response.Customers)
+		}
+	})
+
+	t.Run("rejects a malformed time window", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/customers?created_after=yesterday", nil))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestBulkUpdateStatus(t *testing.T) {
+	repo := memory.NewRepository()
+	for id, status := range map[string]domain.OrderStatus{
+		"order-1": domain.StatusProcessing,
+		"order-2": domain.StatusCompleted,
+		"order-3": domain.StatusFailed,
+	} {
+		if err := repo.Create(context.Background(), domain.Order{ID: id, Status: status}); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	t.Run("reports per-order results", func(t *testing.T) {
+		body := `{"ids":["order-1","order-2","order-3","missing"],"status":"failed","reason":"bad batch"}`
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/orders/bulk-status", strings.NewReader(body)))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Results []app.BulkStatusResult `json:"results"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		want := []string{app.BulkResultSucceeded, app.BulkResultSkipped, app.BulkResultSkipped, app.BulkResultErrored}
+		if len(response.Results) != len(want) {
+			t.Fatalf("expected %d results, got %d", len(want), len(response.Results))
+		}
+		for i, result := range response.Results {
+			if result.Result != want[i] {
+				t.Errorf("result %d (%s): expected %s, got %s", i, result.OrderID, want[i], result.Result)
+			}
+		}
+	})
+
+	t.Run("rejects unknown status", func(t *testing.T) {
+		body := `{"ids":["order-1"],"status":"archived"}`
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/orders/bulk-status", strings.NewReader(body)))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestReprocessFailedOrders(t *testing.T) {
+	repo := memory.NewRepository()
+	orders := []domain.Order{
+		{ID: "order-1", CustomerID: "customer-1", CustomerEmail: "a@b.com", Amount: domain.NewMoney(100, "USD"), Status: domain.StatusFailed},
+		{ID: "order-2", CustomerID: "customer-1", CustomerEmail: "a@b.com", Amount: domain.NewMoney(100, "USD"), Status: domain.StatusFailed},
+		{ID: "order-3", CustomerID: "customer-1", CustomerEmail: "a@b.com", Status: domain.StatusFailed},
+		{ID: "order-4", CustomerID: "customer-1", CustomerEmail: "a@b.com", Amount: domain.NewMoney(100, "USD"), Status: domain.StatusCompleted},
+	}
+	base := time.Now().UTC()
+	for i, order := range orders {
+		order.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(context.Background(), order); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	events := kafka.NewSpyEventBus()
+	service := app.NewService(repo, events, nil, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service, WithAdminToken("secret"), WithReprocessLimits(1, 10)).Register(mux)
+
+	t.Run("rejects missing token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/orders/reprocess", nil))
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rec.Code)
+		}
+	})
+
+	t.Run("reprocesses eligible failed orders", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/admin/orders/reprocess", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Summary app.ReprocessSummary `json:"summary"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		want := app.ReprocessSummary{Scanned: 3, Reprocessed: 2, Skipped: 1}
+		if response.Summary != want {
+			t.Errorf("expected summary %+v, got %+v", want, response.Summary)
+		}
+		if got := len(events.PublishedCreated()); got != 2 {
+			t.Errorf("expected 2 order.created events, got %d", got)
+		}
+	})
+
+	t.Run("is not registered without a token", func(t *testing.T) {
+		mux := http.NewServeMux()
+		NewHandler(service).Register(mux)
+		req := httptest.NewRequest(http.MethodPost, "/admin/orders/reprocess", nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rec.Code)
+		}
+	})
+}
+
+func TestCountIdempotencyKeys(t *testing.T) {
+	store := &mapIdempotencyStore{responses: map[string]ports.StoredResponse{
+		"key-1": {StatusCode: http.StatusAccepted},
+		"key-2": {StatusCode: http.StatusAccepted},
+	}}
+	service := app.NewService(memory.NewRepository(), kafka.NewSpyEventBus(), store, slog.Default(), nil)
+	mux := http.NewServeMux()
+	NewHandler(service, WithAdminToken("secret")).Register(mux)
+
+	newRequest := func(target string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		return req
+	}
+
+	t.Run("reports the key count", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, newRequest("/admin/idempotency/keys?since=2025-01-01T00:00:00Z"))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Since time.Time `json:"since"`
+			Count int64     `json:"count"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.Count != 2 || !response.Since.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected 2 keys since 2025-01-01, got %+v", response)
+		}
+	})
+
+	t.Run("rejects a malformed since", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, newRequest("/admin/idempotency/keys?since=yesterday"))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rec.Code)
+		}
+	})
+}
+
+func TestServiceErrorStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not found", fmt.Errorf("get order: %w", app.ErrNotFound), http.StatusNotFound},
+		{"validation", domain.NewValidationError("amount_cents must be positive"), http.StatusBadRequest},
+		{"blocked domain", fmt.Errorf("%w: example.com", domain.ErrBlockedEmailDomain), http.StatusBadRequest},
+		{"not cancellable", fmt.Errorf("%w: order is completed", app.ErrNotCancellable), http.StatusConflict},
+		{"query timeout", fmt.Errorf("%w: list", ports.ErrQueryTimeout), http.StatusGatewayTimeout},
+		{"unexpected", errors.New("connection reset"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serviceErrorStatus(tt.err); got != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteServiceError(t *testing.T) {
+	internal := errors.New(`query orders: dial tcp 10.0.0.5:5432: password authentication failed for user "app"`)
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+		return req.WithContext(requestctx.WithRequestID(req.Context(), "req-123"))
+	}
+
+	t.Run("hides internal details behind a generic message and request ID", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewHandler(nil).writeServiceError(rec, newRequest(), internal)
+
+		var body map[string]string
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if rec.Code != http.StatusInternalServerError || body["error"] != "internal server error" {
+			t.Errorf("expected generic 500, got %d %v", rec.Code, body)
+		}
+		if body["request_id"] != "req-123" {
+			t.Errorf("expected request_id req-123, got %q", body["request_id"])
+		}
+	})
+
+	t.Run("returns internal details when debug errors are enabled", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewHandler(nil, WithDebugErrors(true)).writeServiceError(rec, newRequest(), internal)
+
+		if !strings.Contains(rec.Body.String(), "password authentication failed") {
+			t.Errorf("expected detailed error, got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("keeps client error messages descriptive", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewHandler(nil).writeServiceError(rec, newRequest(), domain.NewValidationError("amount_cents must be positive"))
+
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "amount_cents must be positive") {
+			t.Errorf("expected descriptive 400, got %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestErrorBody(t *testing.T) {
+	t.Run("includes the trace ID of a traced request", func(t *testing.T) {
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  spanID,
+		}))
+
+		body := errorBody(ctx, "internal server error")
+
+		if body["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("expected trace_id, got %v", body)
+		}
+	})
+
+	t.Run("omits the trace ID when the request was not traced", func(t *testing.T) {
+		body := errorBody(context.Background(), "order not found")
+
+		if _, ok := body["trace_id"]; ok {
+			t.Errorf("expected no trace_id, got %v", body)
+		}
+	})
+}
+
+func TestGetOrderAudit(t *testing.T) {
+	businessMetrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	auditLog := auditmemory.NewStore()
+	service := app.NewService(memory.NewRepository(), kafka.NewSpyEventBus(), &mapIdempotencyStore{responses: map[string]ports.StoredResponse{}},
+		slog.Default(), businessMetrics, app.WithAuditLog(auditLog))
+	mux := http.NewServeMux()
+	NewHandler(service).Register(mux)
+
+	order, err := service.CreateOrder(context.Background(), app.CreateOrderInput{CustomerID: "customer-1", CustomerEmail: "user@example.com", AmountCents: 1000})
+	if err != nil {
+		t.Fatalf("failed to create order: %v", err)
+	}
+	if _, err := service.CancelOrder(context.Background(), order.ID); err != nil {
+		t.Fatalf("failed to cancel order: %v", err)
+	}
+
+	t.Run("returns the order's audit trail oldest first", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/"+order.ID+"/audit", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rec.Code)
+		}
+		var body struct {
+			Audit []audit.Entry `json:"audit"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if len(body.Audit) != 2 {
+			t.Fatalf("expected 2 entries, got %+v", body.Audit)
+		}
+		if body.Audit[0].Action != audit.ActionCreate || body.Audit[1].Action != audit.ActionCancel {
+			t.Errorf("expected create then cancel, got %s then %s", body.Audit[0].Action, body.Audit[1].Action)
+		}
+		if body.Audit[1].FromStatus != domain.StatusPending || body.Audit[1].Actor != audit.AnonymousActor {
+			t.Errorf("expected anonymous cancel from pending, got %+v", body.Audit[1])
+		}
+	})
+
+	t.Run("returns 404 for unknown orders", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/missing/audit", nil))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rec.Code)
+		}
+	})
+}
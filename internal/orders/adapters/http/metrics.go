@@ -11,8 +11,21 @@ import (
 type Metrics struct {
 	requestDuration metric.Float64Histogram
 	requestsTotal   metric.Int64Counter
+	rejectedTotal   metric.Int64Counter
+	failOpenTotal   metric.Int64Counter
+	badTraceTotal   metric.Int64Counter
+	panicsTotal     metric.Int64Counter
+	createRejected  metric.Int64Counter
 }
 
+// Reasons recorded by RecordCreateRejected.
+const (
+	CreateRejectedBadJSON    = "bad_json"
+	CreateRejectedTooLarge   = "too_large"
+	CreateRejectedValidation = "validation"
+	CreateRejectedMissingKey = "missing_key"
+)
+
 func NewMetrics(meter metric.Meter) (*Metrics, error) {
 	m := &Metrics{}
 
@@ -36,6 +49,51 @@ func NewMetrics(meter metric.Meter) (*Metrics, error) {
 		return nil, fmt.Errorf("create http_requests_total counter: %w", err)
 	}
 
+	m.rejectedTotal, err = meter.Int64Counter(
+		"http_requests_rejected_total",
+		metric.WithDescription("HTTP requests rejected because the concurrency limit was reached"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create http_requests_rejected_total counter: %w", err)
+	}
+
+	m.failOpenTotal, err = meter.Int64Counter(
+		"idempotency_fail_open_total",
+		metric.WithDescription("Idempotency store errors bypassed because fail-open mode is enabled"),
+		metric.WithUnit("{error}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create idempotency_fail_open_total counter: %w", err)
+	}
+
+	m.badTraceTotal, err = meter.Int64Counter(
+		"http_trace_context_invalid_total",
+		metric.WithDescription("Requests whose traceparent header was present but could not be parsed"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create http_trace_context_invalid_total counter: %w", err)
+	}
+
+	m.panicsTotal, err = meter.Int64Counter(
+		"panics_total",
+		metric.WithDescription("Panics recovered while serving HTTP requests"),
+		metric.WithUnit("{panic}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create panics_total counter: %w", err)
+	}
+
+	m.createRejected, err = meter.Int64Counter(
+		"orders_create_rejected_total",
+		metric.WithDescription("Order creation requests rejected for a client error before an order was created"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create orders_create_rejected_total counter: %w", err)
+	}
+
 	return m, nil
 }
 
@@ -50,3 +108,33 @@ func (m *Metrics) RecordRequest(ctx context.Context, method, path string, status
 		attribute.String("path", path),
 	))
 }
+
+func (m *Metrics) RecordRejection(ctx context.Context, method, path string) {
+	m.rejectedTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("method", method),
+		attribute.String("path", path),
+	))
+}
+
+func (m *Metrics) RecordIdempotencyFailOpen(ctx context.Context, operation string) {
+	m.failOpenTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("operation", operation),
+	))
+}
+
+func (m *Metrics) RecordInvalidTraceContext(ctx context.Context) {
+	m.badTraceTotal.Add(ctx, 1)
+}
+
+func (m *Metrics) RecordPanic(ctx context.Context, method, path string) {
+	m.panicsTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("method", method),
+		attribute.String("path", path),
+	))
+}
+
+func (m *Metrics) RecordCreateRejected(ctx context.Context, reason string) {
+	m.createRejected.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("reason", reason),
+	))
+}
@@ -2,6 +2,8 @@ package http
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
@@ -90,3 +92,37 @@ func TestRecordHTTPRequest(t *testing.T) {
 		}
 	})
 }
+
+func TestWithMetrics(t *testing.T) {
+	t.Run("leaves excluded paths out of request metrics", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		metrics, err := NewMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+		if err != nil {
+			t.Fatalf("NewMetrics() failed: %v", err)
+		}
+		handler := WithMetrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), metrics,
+			WithMetricsExclusions("/metrics", "/readyz"))
+
+		for _, path := range []string{"/metrics", "/readyz", "/v1/orders"} {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatalf("Failed to collect metrics: %v", err)
+		}
+		var total int64
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "http_requests_total" {
+					for _, dp := range sum.DataPoints {
+						total += dp.Value
+					}
+				}
+			}
+		}
+		if total != 1 {
+			t.Errorf("expected 1 counted request, got %d", total)
+		}
+	})
+}
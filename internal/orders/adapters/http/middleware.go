@@ -1,17 +1,33 @@
 package http
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/dejobratic/tbd/internal/requestctx"
 )
 
+// RequestIDHeader carries the request ID; an incoming value is reused, otherwise one is
+// generated. The ID is echoed on the response.
+const RequestIDHeader = "X-Request-ID"
+
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	bytesWritten int64
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
-	return &responseWriter{w, http.StatusOK}
+	return &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -19,14 +35,330 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-func WithMetrics(next http.Handler, metrics *Metrics) http.Handler {
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesWritten += int64(n)
+	return n, err
+}
+
+// LoggingOption customizes WithLogging.
+type LoggingOption func(*accessLogger)
+
+// WithSlowRequestThreshold logs requests that take longer than threshold at WARN instead of
+// INFO. A non-positive threshold disables the check.
+func WithSlowRequestThreshold(threshold time.Duration) LoggingOption {
+	return func(l *accessLogger) {
+		l.slowThreshold = threshold
+	}
+}
+
+// WithLoggingExclusions skips the access log for requests to the given paths, such as
+// health probes, matched exactly. Excluded requests still get a request ID.
+func WithLoggingExclusions(paths ...string) LoggingOption {
+	return func(l *accessLogger) {
+		l.excluded = pathSet(paths)
+	}
+}
+
+type accessLogger struct {
+	slowThreshold time.Duration
+	excluded      map[string]bool
+}
+
+// WithLogging writes one access log line per request with its status, size, and timing.
+func WithLogging(next http.Handler, logger *slog.Logger, opts ...LoggingOption) http.Handler {
+	access := &accessLogger{}
+	for _, opt := range opts {
+		opt(access)
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = generateRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
 		rw := newResponseWriter(w)
+		next.ServeHTTP(rw, r.WithContext(requestctx.WithRequestID(r.Context(), requestID)))
+		if access.excluded[r.URL.Path] {
+			return
+		}
+
+		duration := time.Since(start)
+		level := slog.LevelInfo
+		if access.slowThreshold > 0 && duration > access.slowThreshold {
+			level = slog.LevelWarn
+		}
+
+		logger.Log(r.Context(), level, "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rw.statusCode,
+			"bytes", rw.bytesWritten,
+			"duration", duration,
+			"remote_ip", remoteIP(r),
+			"user_agent", r.UserAgent(),
+			"request_id", requestID,
+		)
+	})
+}
 
-		next.ServeHTTP(rw, r)
+// WithWriteTimeouts replaces the server's WriteTimeout for requests to the given paths, so
+// long-running responses such as streams are not cut off. A zero timeout removes the
+// deadline. It must wrap the server's own ResponseWriter, so install it outermost.
+func WithWriteTimeouts(next http.Handler, timeouts map[string]time.Duration) http.Handler {
+	if len(timeouts) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if timeout, ok := timeouts[r.URL.Path]; ok {
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
+			if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+				slog.WarnContext(r.Context(), "failed to override write timeout", "path", r.URL.Path, "error", err)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func pathSet(paths []string) map[string]bool {
+	set := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		set[path] = true
+	}
+	return set
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func generateRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// MetricsOption customizes WithMetrics.
+type MetricsOption func(*requestMetrics)
+
+// WithMetricsExclusions leaves requests to the given paths, such as scrapes and health
+// probes, out of the request metrics. Paths are matched exactly.
+func WithMetricsExclusions(paths ...string) MetricsOption {
+	return func(m *requestMetrics) {
+		m.excluded = pathSet(paths)
+	}
+}
+
+type requestMetrics struct {
+	excluded map[string]bool
+}
+
+func WithMetrics(next http.Handler, metrics *Metrics, opts ...MetricsOption) http.Handler {
+	options := &requestMetrics{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if options.excluded[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rw := newResponseWriter(w)
+		ctx, _ := requestctx.WithRoute(r.Context())
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
 
 		duration := time.Since(start).Seconds()
-		metrics.RecordRequest(r.Context(), r.Method, r.URL.Path, rw.statusCode, duration)
+		metrics.RecordRequest(ctx, r.Method, routeTemplate(ctx), rw.statusCode, duration)
+	})
+}
+
+// UnmatchedRoute labels requests that no handler claimed, such as 404s for unknown paths,
+// so arbitrary URLs cannot grow metric cardinality.
+const UnmatchedRoute = "unmatched"
+
+// WithRouteTemplate records template, e.g. "/healthz", as the route of every request next
+// serves. Handlers that parse path parameters call setRoute themselves instead.
+func WithRouteTemplate(template string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setRoute(r, template)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setRoute records the route template serving r for the metrics and tracing middleware.
+func setRoute(r *http.Request, template string) {
+	requestctx.SetRoute(r.Context(), template)
+}
+
+// routeTemplate returns the recorded route template, or UnmatchedRoute.
+func routeTemplate(ctx context.Context) string {
+	if template := requestctx.RouteTemplate(ctx); template != "" {
+		return template
+	}
+	return UnmatchedRoute
+}
+
+// RequireBearerToken rejects requests whose Authorization header does not carry token as a
+// bearer credential.
+func RequireBearerToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, r, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
+
+// concurrencyRetryAfter is the Retry-After hint, in seconds, sent with 503 responses from
+// WithMaxConcurrency.
+const concurrencyRetryAfter = 1
+
+// ConcurrencyOption customizes WithMaxConcurrency.
+type ConcurrencyOption func(*concurrencyLimiter)
+
+// WithRejectionMetrics records every rejected request on metrics.
+func WithRejectionMetrics(metrics *Metrics) ConcurrencyOption {
+	return func(l *concurrencyLimiter) {
+		l.metrics = metrics
+	}
+}
+
+type concurrencyLimiter struct {
+	slots   chan struct{}
+	metrics *Metrics
+}
+
+// WithMaxConcurrency caps the number of requests served at once at n. Requests beyond the
+// limit are rejected immediately with 503 and a Retry-After header instead of queueing for
+// a database connection. A non-positive n disables the limit.
+func WithMaxConcurrency(next http.Handler, n int, opts ...ConcurrencyOption) http.Handler {
+	if n <= 0 {
+		return next
+	}
+
+	limiter := &concurrencyLimiter{slots: make(chan struct{}, n)}
+	for _, opt := range opts {
+		opt(limiter)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case limiter.slots <- struct{}{}:
+		default:
+			if limiter.metrics != nil {
+				limiter.metrics.RecordRejection(r.Context(), r.Method, routeTemplate(r.Context()))
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(concurrencyRetryAfter))
+			writeError(w, r, http.StatusServiceUnavailable, "server is at capacity, retry later")
+			return
+		}
+		defer func() { <-limiter.slots }()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CORSConfig configures WithCORS.
+type CORSConfig struct {
+	// AllowedOrigins lists origins allowed to call the API, e.g. "https://app.example.com".
+	// "*" allows any origin but cannot be combined with AllowCredentials.
+	AllowedOrigins []string
+	// AllowCredentials lets browsers send cookies and Authorization headers cross-origin.
+	AllowCredentials bool
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration
+}
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", defaultIdempotencyHeader}
+	defaultCORSExposed = []string{"Link", RequestIDHeader, "Retry-After", idempotencyReplayHeader, idempotencyDegradedHeader}
+)
+
+// WithCORS answers preflight requests and adds CORS headers for allowed origins. Matching
+// origins are echoed back exactly; a wildcard is only sent when "*" is allowed and
+// credentials are not, so credentials are never shared with arbitrary origins. Requests
+// from other origins get no CORS headers, and their preflights are rejected with 403.
+func WithCORS(next http.Handler, cfg CORSConfig) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return next
+	}
+
+	wildcard := slices.Contains(cfg.AllowedOrigins, "*")
+	methods := strings.Join(orDefault(cfg.AllowedMethods, defaultCORSMethods), ", ")
+	headers := strings.Join(orDefault(cfg.AllowedHeaders, defaultCORSHeaders), ", ")
+	exposed := strings.Join(orDefault(cfg.ExposedHeaders, defaultCORSExposed), ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		allowed := slices.Contains(cfg.AllowedOrigins, origin)
+		if !allowed && !(wildcard && !cfg.AllowCredentials) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", exposed)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", methods)
+		w.Header().Set("Access-Control-Allow-Headers", headers)
+		if cfg.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// orDefault returns values, or fallback when values is empty.
+func orDefault(values, fallback []string) []string {
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
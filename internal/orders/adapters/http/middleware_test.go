@@ -0,0 +1,336 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/requestctx"
+)
+
+func TestWithMaxConcurrency(t *testing.T) {
+	t.Run("rejects requests beyond the limit with 503 and Retry-After", func(t *testing.T) {
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entered <- struct{}{}
+			<-release
+			w.WriteHeader(http.StatusOK)
+		})
+		handler := WithMaxConcurrency(blocking, 1)
+
+		var wg sync.WaitGroup
+		first := httptest.NewRecorder()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+		}()
+		<-entered
+
+		rejected := httptest.NewRecorder()
+		handler.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+
+		close(release)
+		wg.Wait()
+
+		if rejected.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", rejected.Code)
+		}
+		if rejected.Header().Get("Retry-After") == "" {
+			t.Error("expected Retry-After header")
+		}
+		if first.Code != http.StatusOK {
+			t.Errorf("expected first request to succeed, got %d", first.Code)
+		}
+	})
+
+	t.Run("releases the slot when the handler panics", func(t *testing.T) {
+		panicking := true
+		handler := WithMaxConcurrency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if panicking {
+				panic("boom")
+			}
+			w.WriteHeader(http.StatusOK)
+		}), 1)
+
+		func() {
+			defer func() { _ = recover() }()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+
+		panicking = false
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status 200 after panic, got %d", rec.Code)
+		}
+	})
+}
+
+func TestWithLogging(t *testing.T) {
+	t.Run("logs status, bytes, client details, and request id", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		handler := WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("hello"))
+		}), logger)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders", nil)
+		req.RemoteAddr = "10.0.0.1:5555"
+		req.Header.Set("User-Agent", "test-agent")
+		req.Header.Set(RequestIDHeader, "req-123")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("failed to decode log entry: %v", err)
+		}
+
+		want := map[string]any{
+			"status":     float64(http.StatusCreated),
+			"bytes":      float64(5),
+			"remote_ip":  "10.0.0.1",
+			"user_agent": "test-agent",
+			"request_id": "req-123",
+		}
+		for key, value := range want {
+			if entry[key] != value {
+				t.Errorf("expected %s=%v, got %v", key, value, entry[key])
+			}
+		}
+		if got := rec.Header().Get(RequestIDHeader); got != "req-123" {
+			t.Errorf("expected request id echoed on response, got %q", got)
+		}
+	})
+
+	t.Run("stores the request id in the request context", func(t *testing.T) {
+		var got string
+		handler := WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = requestctx.RequestID(r.Context())
+		}), slog.New(slog.NewJSONHandler(io.Discard, nil)))
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+		req.Header.Set(RequestIDHeader, "req-123")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got != "req-123" {
+			t.Errorf("expected request id req-123 in context, got %q", got)
+		}
+	})
+
+	t.Run("logs requests over the slow threshold at WARN", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			threshold time.Duration
+			sleep     time.Duration
+			wantLevel string
+		}{
+			{"fast request", time.Hour, 0, "INFO"},
+			{"slow request", time.Millisecond, 5 * time.Millisecond, "WARN"},
+			{"threshold disabled", 0, 5 * time.Millisecond, "INFO"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var buf bytes.Buffer
+				logger := slog.New(slog.NewJSONHandler(&buf, nil))
+				handler := WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					time.Sleep(tt.sleep)
+				}), logger, WithSlowRequestThreshold(tt.threshold))
+
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+
+				var entry map[string]any
+				if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+					t.Fatalf("failed to decode log entry: %v", err)
+				}
+				if entry["level"] != tt.wantLevel {
+					t.Errorf("expected level %s, got %v", tt.wantLevel, entry["level"])
+				}
+			})
+		}
+	})
+
+	t.Run("skips excluded paths but still assigns a request id", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		handler := WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), logger,
+			WithLoggingExclusions("/healthz", "/metrics"))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if buf.Len() != 0 {
+			t.Errorf("expected no log entry, got %s", buf.String())
+		}
+		if rec.Header().Get(RequestIDHeader) == "" {
+			t.Error("expected request id on excluded response")
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+		if buf.Len() == 0 {
+			t.Error("expected log entry for API request")
+		}
+	})
+
+	t.Run("generates a request id when none is supplied", func(t *testing.T) {
+		logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+		handler := WithLogging(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), logger)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Header().Get(RequestIDHeader) == "" {
+			t.Error("expected generated request id on response")
+		}
+	})
+}
+
+func TestWithWriteTimeouts(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	})
+	handler := WithWriteTimeouts(slow, map[string]time.Duration{"/stream": 0})
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Config.WriteTimeout = 20 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	get := func(path string) (string, error) {
+		resp, err := srv.Client().Get(srv.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	t.Run("lifts the write deadline for overridden paths", func(t *testing.T) {
+		body, err := get("/stream")
+		if err != nil || body != "done" {
+			t.Errorf("expected full response, got %q, %v", body, err)
+		}
+	})
+
+	t.Run("keeps the server write timeout elsewhere", func(t *testing.T) {
+		if body, err := get("/orders"); err == nil && body == "done" {
+			t.Error("expected the server write timeout to cut off the response")
+		}
+	})
+}
+
+func TestWithCORS(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	corsHeaders := []string{
+		"Access-Control-Allow-Origin",
+		"Access-Control-Allow-Credentials",
+		"Access-Control-Allow-Methods",
+		"Access-Control-Expose-Headers",
+	}
+
+	request := func(method, origin string, preflight bool) *http.Request {
+		req := httptest.NewRequest(method, "/v1/orders", nil)
+		req.Header.Set("Origin", origin)
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		return req
+	}
+
+	t.Run("echoes an allowed origin with credentials", func(t *testing.T) {
+		handler := WithCORS(ok, CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, request(http.MethodGet, "https://app.example.com", false))
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("expected origin echoed, got %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+			t.Errorf("expected credentials allowed, got %q", got)
+		}
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rec.Code)
+		}
+	})
+
+	t.Run("answers preflight for an allowed origin", func(t *testing.T) {
+		handler := WithCORS(ok, CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: time.Hour})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, request(http.MethodOptions, "https://app.example.com", true))
+
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", rec.Code)
+		}
+		if rec.Header().Get("Access-Control-Allow-Methods") == "" {
+			t.Error("expected Access-Control-Allow-Methods header")
+		}
+		if got := rec.Header().Get("Access-Control-Max-Age"); got != "3600" {
+			t.Errorf("expected max age 3600, got %q", got)
+		}
+	})
+
+	t.Run("sends no CORS headers to disallowed origins", func(t *testing.T) {
+		handler := WithCORS(ok, CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true})
+
+		for _, preflight := range []bool{false, true} {
+			method := http.MethodGet
+			if preflight {
+				method = http.MethodOptions
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, request(method, "https://evil.example.com", preflight))
+
+			for _, header := range corsHeaders {
+				if got := rec.Header().Get(header); got != "" {
+					t.Errorf("preflight=%v: expected no %s, got %q", preflight, header, got)
+				}
+			}
+			if preflight && rec.Code != http.StatusForbidden {
+				t.Errorf("expected disallowed preflight to get 403, got %d", rec.Code)
+			}
+		}
+	})
+
+	t.Run("never pairs a wildcard origin with credentials", func(t *testing.T) {
+		handler := WithCORS(ok, CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, request(http.MethodGet, "https://any.example.com", false))
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("expected no allow-origin header, got %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("expected no credentials header, got %q", got)
+		}
+	})
+
+	t.Run("sends a wildcard origin without credentials", func(t *testing.T) {
+		handler := WithCORS(ok, CORSConfig{AllowedOrigins: []string{"*"}})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, request(http.MethodGet, "https://any.example.com", false))
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("expected wildcard origin, got %q", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("expected no credentials header, got %q", got)
+		}
+	})
+}
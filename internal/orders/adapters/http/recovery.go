@@ -0,0 +1,70 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/dejobratic/tbd/internal/telemetry"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecoveryOption customizes WithRecovery.
+type RecoveryOption func(*recoverer)
+
+type recoverer struct {
+	metrics *Metrics
+	repanic bool
+}
+
+// WithPanicMetrics counts every recovered panic on metrics.
+func WithPanicMetrics(metrics *Metrics) RecoveryOption {
+	return func(r *recoverer) {
+		r.metrics = metrics
+	}
+}
+
+// WithRepanic re-raises a panic once it has been logged and recorded, so the server aborts
+// the connection instead of answering 500. Use it outside production to fail fast.
+func WithRepanic(enabled bool) RecoveryOption {
+	return func(r *recoverer) {
+		r.repanic = enabled
+	}
+}
+
+// WithRecovery turns a panic in next into a 500 response. The panic and its stack are
+// logged at ERROR and recorded on the request span, so install it inside WithTracing.
+func WithRecovery(next http.Handler, logger *slog.Logger, opts ...RecoveryOption) http.Handler {
+	rec := &recoverer{}
+	for _, opt := range opts {
+		opt(rec)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			value := recover()
+			if value == nil {
+				return
+			}
+			if value == http.ErrAbortHandler {
+				panic(value)
+			}
+
+			ctx := r.Context()
+			err := fmt.Errorf("panic: %v", value)
+			logger.ErrorContext(ctx, "panic recovered", "error", err, "stack", string(debug.Stack()))
+			telemetry.RecordSpanError(trace.SpanFromContext(ctx), err)
+			httpTracer.RecordErrorSpan(ctx, "http.panic", err)
+			if rec.metrics != nil {
+				rec.metrics.RecordPanic(ctx, r.Method, routeTemplate(ctx))
+			}
+
+			if rec.repanic {
+				panic(value)
+			}
+			writeError(w, r, http.StatusInternalServerError, "internal server error")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dejobratic/tbd/internal/auth"
+	"github.com/dejobratic/tbd/internal/requestctx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TenantAttributeKey names the tenant span attribute and the baggage member WithTenant
+// sets, so telemetry.WithSpanBaggageKeys(TenantAttributeKey) tags downstream spans too.
+const TenantAttributeKey = "tenant.id"
+
+// TenantOption customizes WithTenant.
+type TenantOption func(*tenantResolver)
+
+type tenantResolver struct {
+	header string
+	claim  string
+}
+
+// WithTenantHeader reads the tenant ID from the named request header.
+func WithTenantHeader(name string) TenantOption {
+	return func(t *tenantResolver) {
+		t.header = name
+	}
+}
+
+// WithTenantClaim reads the tenant ID from the named JWT claim. A claim, which the
+// caller cannot forge, takes precedence over the header when both are present.
+func WithTenantClaim(name string) TenantOption {
+	return func(t *tenantResolver) {
+		t.claim = name
+	}
+}
+
+// WithTenant resolves the caller's tenant ID and stores it in the request context and
+// baggage, and on the server span as TenantAttributeKey. Requests without a tenant pass
+// through untouched. Install it inside auth.RequireJWT when reading a claim and inside
+// WithTracing so the server span is current.
+func WithTenant(next http.Handler, opts ...TenantOption) http.Handler {
+	resolver := &tenantResolver{}
+	for _, opt := range opts {
+		opt(resolver)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := resolver.resolve(r)
+		if tenant == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := requestctx.WithTenantID(r.Context(), tenant)
+		if member, err := baggage.NewMemberRaw(TenantAttributeKey, tenant); err == nil {
+			if bag, err := baggage.FromContext(ctx).SetMember(member); err == nil {
+				ctx = baggage.ContextWithBaggage(ctx, bag)
+			}
+		}
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String(TenantAttributeKey, tenant))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (t *tenantResolver) resolve(r *http.Request) string {
+	if t.claim != "" {
+		if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+			if tenant, ok := claims.Extra[t.claim].(string); ok && strings.TrimSpace(tenant) != "" {
+				return strings.TrimSpace(tenant)
+			}
+		}
+	}
+	if t.header != "" {
+		return strings.TrimSpace(r.Header.Get(t.header))
+	}
+	return ""
+}
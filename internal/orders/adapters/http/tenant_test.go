@@ -0,0 +1,79 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/auth"
+	"github.com/dejobratic/tbd/internal/requestctx"
+	"github.com/dejobratic/tbd/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTenant(t *testing.T) {
+	newRequest := func(header string, claims *auth.Claims) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+		if header != "" {
+			req.Header.Set("X-Tenant-ID", header)
+		}
+		if claims != nil {
+			req = req.WithContext(auth.ContextWithClaims(req.Context(), claims))
+		}
+		return req
+	}
+	tenantClaims := &auth.Claims{Extra: map[string]any{"tenant": "acme"}}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{name: "reads the header", req: newRequest("globex", nil), want: "globex"},
+		{name: "prefers the claim over the header", req: newRequest("globex", tenantClaims), want: "acme"},
+		{name: "falls back to the header without a claim", req: newRequest("globex", &auth.Claims{}), want: "globex"},
+		{name: "leaves requests without a tenant untouched", req: newRequest("", nil), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(
+				sdktrace.WithSpanProcessor(telemetry.NewBaggageSpanProcessor(TenantAttributeKey)),
+				sdktrace.WithSpanProcessor(spans),
+			)
+			tracer := provider.Tracer("test")
+
+			var got string
+			handler := WithTenant(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = requestctx.TenantID(r.Context())
+				_, span := tracer.Start(r.Context(), "repository")
+				span.End()
+			}), WithTenantHeader("X-Tenant-ID"), WithTenantClaim("tenant"))
+
+			ctx, server := tracer.Start(tt.req.Context(), "server")
+			handler.ServeHTTP(httptest.NewRecorder(), tt.req.WithContext(ctx))
+			server.End()
+
+			if got != tt.want {
+				t.Errorf("expected tenant %q in context, got %q", tt.want, got)
+			}
+			for _, span := range spans.Ended() {
+				if value := spanAttribute(span.Attributes(), TenantAttributeKey); value != tt.want {
+					t.Errorf("expected %s=%q on span %s, got %q", TenantAttributeKey, tt.want, span.Name(), value)
+				}
+			}
+		})
+	}
+}
+
+func spanAttribute(attrs []attribute.KeyValue, key string) string {
+	for _, attr := range attrs {
+		if string(attr.Key) == key {
+			return attr.Value.AsString()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/dejobratic/tbd/internal/requestctx"
+	"github.com/dejobratic/tbd/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of HTTP server spans and metrics.
+const ScopeName = "github.com/dejobratic/tbd/internal/orders/adapters/http"
+
+var httpTracer = telemetry.NewTracer(ScopeName)
+
+// traceparentHeader is the W3C Trace Context header checked by WithTraceContextReporting.
+const traceparentHeader = "traceparent"
+
+// TracingOption customizes WithTracing.
+type TracingOption func(*tracer)
+
+// WithTraceContextReporting counts and debug-logs requests whose traceparent header was
+// present but invalid, which otherwise silently start a new trace. Either argument may be
+// nil.
+func WithTraceContextReporting(logger *slog.Logger, metrics *Metrics) TracingOption {
+	return func(t *tracer) {
+		t.logger = logger
+		t.metrics = metrics
+	}
+}
+
+type tracer struct {
+	logger  *slog.Logger
+	metrics *Metrics
+}
+
+// WithTracing starts a server span per request, continuing any trace propagated in the
+// request headers. Once the handler returns the span is named "METHOD template" after the
+// matched route, or UnmatchedRoute, so span names stay bounded like metric labels.
+func WithTracing(next http.Handler, opts ...TracingOption) http.Handler {
+	t := &tracer{}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		if header := r.Header.Get(traceparentHeader); header != "" && !trace.SpanContextFromContext(ctx).IsRemote() {
+			t.reportInvalid(ctx, r, header)
+		}
+		ctx, _ = requestctx.WithRoute(ctx)
+		ctx, span := httpTracer.StartSpan(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		rw := newResponseWriter(w)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		route := routeTemplate(ctx)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", r.URL.Path),
+			attribute.Int("http.response.status_code", rw.statusCode),
+		)
+		if rw.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.statusCode))
+		}
+	})
+}
+
+func (t *tracer) reportInvalid(ctx context.Context, r *http.Request, header string) {
+	if t.metrics != nil {
+		t.metrics.RecordInvalidTraceContext(ctx)
+	}
+	if t.logger != nil {
+		t.logger.DebugContext(ctx, "ignoring malformed trace context header",
+			"traceparent", header,
+			"remote_ip", remoteIP(r),
+			"user_agent", r.UserAgent(),
+		)
+	}
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestWithTracingInvalidTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	tests := []struct {
+		name        string
+		traceparent string
+		want        int64
+	}{
+		{name: "counts a malformed traceparent", traceparent: "00-not-a-trace-01", want: 1},
+		{name: "ignores a valid traceparent", traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: 0},
+		{name: "ignores a missing traceparent", traceparent: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			metrics, err := NewMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test"))
+			if err != nil {
+				t.Fatalf("NewMetrics() failed: %v", err)
+			}
+			handler := WithTracing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), WithTraceContextReporting(nil, metrics))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+			if tt.traceparent != "" {
+				req.Header.Set(traceparentHeader, tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			var rm metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &rm); err != nil {
+				t.Fatalf("Failed to collect metrics: %v", err)
+			}
+
+			var got int64
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					if m.Name != "http_trace_context_invalid_total" {
+						continue
+					}
+					for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+						got += dp.Value
+					}
+				}
+			}
+			if got != tt.want {
+				t.Errorf("expected %d invalid trace contexts, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// OutboxStore keeps outbox messages in memory in insertion order.
+type OutboxStore struct {
+	mu       sync.Mutex
+	messages []ports.OutboxMessage
+}
+
+func NewOutboxStore() *OutboxStore {
+	return &OutboxStore{}
+}
+
+func (s *OutboxStore) Add(_ context.Context, msg ports.OutboxMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of all recorded messages.
+func (s *OutboxStore) Messages() []ports.OutboxMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ports.OutboxMessage(nil), s.messages...)
+}
@@ -0,0 +1,19 @@
+package memory
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// ReferenceSequence is an in-process ReferenceSequence counting up from 1.
+type ReferenceSequence struct {
+	last atomic.Int64
+}
+
+func NewReferenceSequence() *ReferenceSequence {
+	return &ReferenceSequence{}
+}
+
+func (s *ReferenceSequence) NextReference(_ context.Context) (int64, error) {
+	return s.last.Add(1), nil
+}
@@ -0,0 +1,248 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// Repository is an in-process OrderRepository mirroring the postgres semantics.
+// Useful for tests and local runs without a database.
+type Repository struct {
+	mu      sync.RWMutex
+	orders  map[string]domain.Order
+	history map[string][]domain.StatusTransition
+	clock   ports.Clock
+	maxRows int
+	logger  *slog.Logger
+}
+
+// Option customizes a Repository.
+type Option func(*Repository)
+
+// WithClock sets the clock status changes are stamped with.
+func WithClock(clock ports.Clock) Option {
+	return func(r *Repository) {
+		r.clock = clock
+	}
+}
+
+// WithMaxRows caps the orders a single List call returns, whatever page size it asks for,
+// logging a warning when a call is truncated. Pages are then maxRows long, so later pages
+// start where the capped one ended. A non-positive limit disables the cap.
+func WithMaxRows(n int) Option {
+	return func(r *Repository) {
+		r.maxRows = n
+	}
+}
+
+// WithLogger sets the logger truncated List calls are reported to. The default is
+// slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Repository) {
+		r.logger = logger
+	}
+}
+
+func NewRepository(opts ...Option) *Repository {
+	r := &Repository{
+		orders:  make(map[string]domain.Order),
+		history: make(map[string][]domain.StatusTransition),
+		clock:   ports.SystemClock{},
+		logger:  slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Repository) Create(_ context.Context, order domain.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.orders[order.ID]; exists {
+		return fmt.Errorf("insert order: duplicate id %s", order.ID)
+	}
+	if order.Reference != "" {
+		for _, existing := range r.orders {
+			if existing.Reference == order.Reference {
+				return fmt.Errorf("insert order: duplicate reference %s", order.Reference)
+			}
+		}
+	}
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *Repository) GetByID(_ context.Context, id string) (*domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, exists := r.orders[id]
+	if !exists {
+		return nil, ports.ErrNotFound
+	}
+	return &order, nil
+}
+
+func (r *Repository) GetByIDs(_ context.Context, ids []string) (map[string]domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make(map[string]domain.Order, len(ids))
+	for _, id := range ids {
+		if order, exists := r.orders[id]; exists {
+			orders[id] = order
+		}
+	}
+	return orders, nil
+}
+
+func (r *Repository) GetByReference(_ context.Context, reference string) (*domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, order := range r.orders {
+		if reference != "" && order.Reference == reference {
+			return &order, nil
+		}
+	}
+	return nil, ports.ErrNotFound
+}
+
+func (r *Repository) Exists(_ context.Context, id string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.orders[id]
+	return exists, nil
+}
+
+func (r *Repository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
+	page, pageSize := filter.Pagination()
+
+	r.mu.RLock()
+	matched := make([]domain.Order, 0, len(r.orders))
+	for _, order := range r.orders {
+		if !filter.Matches(order) {
+			continue
+		}
+		matched = append(matched, order)
+	}
+	r.mu.RUnlock()
+
+	if filter.UpdatedAfter.IsZero() {
+		sort.Slice(matched, func(i, j int) bool {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		})
+	} else {
+		sort.Slice(matched, func(i, j int) bool {
+			if !matched[i].UpdatedAt.Equal(matched[j].UpdatedAt) {
+				return matched[i].UpdatedAt.Before(matched[j].UpdatedAt)
+			}
+			return matched[i].ID < matched[j].ID
+		})
+	}
+
+	limit := pageSize
+	if r.maxRows > 0 && limit > r.maxRows {
+		limit = r.maxRows
+	}
+	offset := (page - 1) * limit
+	if offset >= len(matched) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	if limit < pageSize && end < len(matched) {
+		r.logger.WarnContext(ctx, "order list truncated", "page_size", pageSize, "max_rows", r.maxRows)
+	}
+
+	return matched[offset:end], nil
+}
+
+func (r *Repository) Count(_ context.Context, filter ports.ListFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, order := range r.orders {
+		if filter.Matches(order) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *Repository) DistinctCustomers(_ context.Context, filter ports.ListFilter) ([]string, error) {
+	page, pageSize := filter.Pagination()
+
+	r.mu.RLock()
+	seen := make(map[string]bool)
+	for _, order := range r.orders {
+		if filter.Matches(order) {
+			seen[strings.ToLower(order.CustomerEmail)] = true
+		}
+	}
+	r.mu.RUnlock()
+
+	emails := slices.Sorted(maps.Keys(seen))
+
+	offset := (page - 1) * pageSize
+	if offset >= len(emails) {
+		return nil, nil
+	}
+	end := min(offset+pageSize, len(emails))
+
+	return emails[offset:end], nil
+}
+
+func (r *Repository) UpdateStatus(_ context.Context, id string, status domain.OrderStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, exists := r.orders[id]
+	if !exists {
+		return ports.ErrNotFound
+	}
+	now := r.clock.Now()
+	r.history[id] = append(r.history[id], domain.StatusTransition{From: order.Status, To: status, ChangedAt: now})
+	order.Status = status
+	order.UpdatedAt = now
+	r.orders[id] = order
+	return nil
+}
+
+func (r *Repository) AppendStatusHistory(_ context.Context, id string, transition domain.StatusTransition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.orders[id]; !exists {
+		return ports.ErrNotFound
+	}
+	r.history[id] = append(r.history[id], transition)
+	return nil
+}
+
+func (r *Repository) GetStatusHistory(_ context.Context, id string) ([]domain.StatusTransition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.orders[id]; !exists {
+		return nil, ports.ErrNotFound
+	}
+	history := make([]domain.StatusTransition, len(r.history[id]))
+	copy(history, r.history[id])
+	return history, nil
+}
@@ -0,0 +1,121 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/dejobratic/tbd/internal/orders/ports/portstest"
+)
+
+func TestOrderRepositoryConformance(t *testing.T) {
+	portstest.RunOrderRepositoryTests(t, func(t *testing.T) ports.OrderRepository {
+		return memory.NewRepository()
+	})
+}
+
+func TestRepositoryClock(t *testing.T) {
+	t.Run("stamps status changes with the injected clock", func(t *testing.T) {
+		ctx := context.Background()
+		created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := portstest.NewFakeClock(created)
+		repo := memory.NewRepository(memory.WithClock(clock))
+
+		order := domain.Order{ID: "order-1", CustomerID: "customer-1", Status: domain.StatusPending, CreatedAt: created, UpdatedAt: created}
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+
+		clock.Advance(time.Minute)
+		if err := repo.UpdateStatus(ctx, order.ID, domain.StatusCanceled); err != nil {
+			t.Fatalf("failed to update status: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		want := created.Add(time.Minute)
+		if !got.UpdatedAt.Equal(want) {
+			t.Errorf("expected updated_at %v, got %v", want, got.UpdatedAt)
+		}
+
+		history, err := repo.GetStatusHistory(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("failed to get history: %v", err)
+		}
+		if len(history) != 1 || !history[0].ChangedAt.Equal(want) {
+			t.Errorf("expected one transition at %v, got %+v", want, history)
+		}
+	})
+}
+
+func TestRepositoryMaxRows(t *testing.T) {
+	ctx := context.Background()
+	var logs bytes.Buffer
+	repo := memory.NewRepository(memory.WithMaxRows(3), memory.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		order := domain.Order{ID: fmt.Sprintf("order-%d", i), Status: domain.StatusPending, CreatedAt: created.Add(time.Duration(i) * time.Minute)}
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+	}
+	ids := func(orders []domain.Order) []string {
+		ids := make([]string, len(orders))
+		for i, order := range orders {
+			ids[i] = order.ID
+		}
+		return ids
+	}
+
+	t.Run("truncates a page larger than the cap", func(t *testing.T) {
+		logs.Reset()
+		orders, err := repo.List(ctx, ports.ListFilter{Page: 1, PageSize: 10})
+		if err != nil {
+			t.Fatalf("failed to list orders: %v", err)
+		}
+		if got := ids(orders); !slices.Equal(got, []string{"order-4", "order-3", "order-2"}) {
+			t.Errorf("expected order-4, order-3 and order-2, got %v", got)
+		}
+		if !strings.Contains(logs.String(), "order list truncated") {
+			t.Errorf("expected a truncation warning, got %q", logs.String())
+		}
+	})
+
+	t.Run("starts the next capped page where the previous one ended", func(t *testing.T) {
+		logs.Reset()
+		orders, err := repo.List(ctx, ports.ListFilter{Page: 2, PageSize: 10})
+		if err != nil {
+			t.Fatalf("failed to list orders: %v", err)
+		}
+		if got := ids(orders); !slices.Equal(got, []string{"order-1", "order-0"}) {
+			t.Errorf("expected order-1 and order-0, got %v", got)
+		}
+		if logs.Len() != 0 {
+			t.Errorf("expected no warning for a page the cap did not cut short, got %q", logs.String())
+		}
+	})
+
+	t.Run("leaves smaller pages alone", func(t *testing.T) {
+		logs.Reset()
+		orders, err := repo.List(ctx, ports.ListFilter{Page: 2, PageSize: 2})
+		if err != nil {
+			t.Fatalf("failed to list orders: %v", err)
+		}
+		if got := ids(orders); !slices.Equal(got, []string{"order-2", "order-1"}) {
+			t.Errorf("expected order-2 and order-1, got %v", got)
+		}
+		if logs.Len() != 0 {
+			t.Errorf("expected no warning, got %q", logs.String())
+		}
+	})
+}
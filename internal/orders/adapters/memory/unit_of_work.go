@@ -0,0 +1,22 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// UnitOfWork runs callbacks directly against the in-memory stores. It offers no rollback;
+// writes made before a failing step remain visible.
+type UnitOfWork struct {
+	repo   ports.OrderRepository
+	outbox ports.OutboxStore
+}
+
+func NewUnitOfWork(repo ports.OrderRepository, outbox ports.OutboxStore) *UnitOfWork {
+	return &UnitOfWork{repo: repo, outbox: outbox}
+}
+
+func (u *UnitOfWork) Do(_ context.Context, fn func(repo ports.OrderRepository, outbox ports.OutboxStore) error) error {
+	return fn(u.repo, u.outbox)
+}
@@ -0,0 +1,60 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	t.Run("runs callback against provided stores", func(t *testing.T) {
+		repo := memory.NewRepository()
+		outbox := memory.NewOutboxStore()
+		uow := memory.NewUnitOfWork(repo, outbox)
+		ctx := context.Background()
+
+		order := domain.Order{
+			ID:            "order-1",
+			CustomerEmail: "user@example.com",
+			Amount:        domain.NewMoney(1000, "USD"),
+			Status:        domain.StatusPending,
+			CreatedAt:     time.Now().UTC(),
+			UpdatedAt:     time.Now().UTC(),
+		}
+
+		err := uow.Do(ctx, func(txRepo ports.OrderRepository, txOutbox ports.OutboxStore) error {
+			if err := txRepo.Create(ctx, order); err != nil {
+				return err
+			}
+			return txOutbox.Add(ctx, ports.OutboxMessage{ID: "msg-1", Topic: "order.created", Key: order.ID})
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if _, err := repo.GetByID(ctx, order.ID); err != nil {
+			t.Errorf("expected order to be stored, got %v", err)
+		}
+		if got := len(outbox.Messages()); got != 1 {
+			t.Errorf("expected 1 outbox message, got %d", got)
+		}
+	})
+
+	t.Run("returns callback error", func(t *testing.T) {
+		uow := memory.NewUnitOfWork(memory.NewRepository(), memory.NewOutboxStore())
+		callbackErr := errors.New("boom")
+
+		err := uow.Do(context.Background(), func(ports.OrderRepository, ports.OutboxStore) error {
+			return callbackErr
+		})
+
+		if !errors.Is(err, callbackErr) {
+			t.Errorf("expected callback error, got %v", err)
+		}
+	})
+}
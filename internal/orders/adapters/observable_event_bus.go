@@ -10,33 +10,57 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 )
 
+// ScopeName is the instrumentation scope of the spans started by the observable decorators.
+const ScopeName = "github.com/dejobratic/tbd/internal/orders/adapters"
+
+var tracer = telemetry.NewTracer(ScopeName)
+
 type ObservableEventBus struct {
 	bus     ports.EventBus
 	metrics *kafka.Metrics
+	topics  kafka.Topics
+}
+
+// ObservableEventBusOption customizes an ObservableEventBus.
+type ObservableEventBusOption func(*ObservableEventBus)
+
+// WithEventTopics sets the topics reported on spans and metrics. It should match the
+// topics the wrapped bus publishes to. Defaults to kafka.DefaultTopics.
+func WithEventTopics(topics kafka.Topics) ObservableEventBusOption {
+	return func(e *ObservableEventBus) {
+		e.topics = topics
+	}
 }
 
-func NewObservableEventBus(bus ports.EventBus, metrics *kafka.Metrics) *ObservableEventBus {
-	return &ObservableEventBus{
+func NewObservableEventBus(bus ports.EventBus, metrics *kafka.Metrics, opts ...ObservableEventBusOption) *ObservableEventBus {
+	e := &ObservableEventBus{
 		bus:     bus,
 		metrics: metrics,
+		topics:  kafka.DefaultTopics(),
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
 }
 
 func (e *ObservableEventBus) PublishOrderCreated(ctx context.Context, orderID string) error {
-	ctx, span := telemetry.StartSpan(ctx, "EventBus.PublishOrderCreated")
+	ctx, span := tracer.StartSpan(ctx, "EventBus.PublishOrderCreated")
 	defer span.End()
 
+	topic := e.topics.For(kafka.EventOrderCreated)
+
 	telemetry.AddSpanAttributes(span,
 		attribute.String("order.id", orderID),
 		attribute.String("event.type", "order.created"),
-		attribute.String("topic", "order.created"),
+		attribute.String("topic", topic),
 	)
 
 	start := time.Now()
 	err := e.bus.PublishOrderCreated(ctx, orderID)
 	duration := time.Since(start).Seconds()
 
-	e.metrics.RecordPublish(ctx, "order.created", duration, err == nil)
+	e.metrics.RecordPublish(ctx, topic, duration, err == nil)
 
 	if err != nil {
 		telemetry.RecordSpanError(span, err)
@@ -48,20 +72,22 @@ func (e *ObservableEventBus) PublishOrderCreated(ctx context.Context, orderID st
 }
 
 func (e *ObservableEventBus) PublishOrderProcessed(ctx context.Context, orderID string) error {
-	ctx, span := telemetry.StartSpan(ctx, "EventBus.PublishOrderProcessed")
+	ctx, span := tracer.StartSpan(ctx, "EventBus.PublishOrderProcessed")
 	defer span.End()
 
+	topic := e.topics.For(kafka.EventOrderProcessed)
+
 	telemetry.AddSpanAttributes(span,
 		attribute.String("order.id", orderID),
 		attribute.String("event.type", "order.processed"),
-		attribute.String("topic", "order.processed"),
+		attribute.String("topic", topic),
 	)
 
 	start := time.Now()
 	err := e.bus.PublishOrderProcessed(ctx, orderID)
 	duration := time.Since(start).Seconds()
 
-	e.metrics.RecordPublish(ctx, "order.processed", duration, err == nil)
+	e.metrics.RecordPublish(ctx, topic, duration, err == nil)
 
 	if err != nil {
 		telemetry.RecordSpanError(span, err)
@@ -73,13 +99,15 @@ func (e *ObservableEventBus) PublishOrderProcessed(ctx context.Context, orderID
 }
 
 func (e *ObservableEventBus) PublishOrderFailed(ctx context.Context, orderID string, reason string) error {
-	ctx, span := telemetry.StartSpan(ctx, "EventBus.PublishOrderFailed")
+	ctx, span := tracer.StartSpan(ctx, "EventBus.PublishOrderFailed")
 	defer span.End()
 
+	topic := e.topics.For(kafka.EventOrderFailed)
+
 	telemetry.AddSpanAttributes(span,
 		attribute.String("order.id", orderID),
 		attribute.String("event.type", "order.failed"),
-		attribute.String("topic", "order.failed"),
+		attribute.String("topic", topic),
 		attribute.String("failure.reason", reason),
 	)
 
@@ -87,7 +115,7 @@ func (e *ObservableEventBus) PublishOrderFailed(ctx context.Context, orderID str
 	err := e.bus.PublishOrderFailed(ctx, orderID, reason)
 	duration := time.Since(start).Seconds()
 
-	e.metrics.RecordPublish(ctx, "order.failed", duration, err == nil)
+	e.metrics.RecordPublish(ctx, topic, duration, err == nil)
 
 	if err != nil {
 		telemetry.RecordSpanError(span, err)
@@ -97,3 +125,16 @@ func (e *ObservableEventBus) PublishOrderFailed(ctx context.Context, orderID str
 	telemetry.SetSpanSuccess(span)
 	return nil
 }
+
+func (e *ObservableEventBus) HealthCheck(ctx context.Context) error {
+	ctx, span := tracer.StartSpan(ctx, "EventBus.HealthCheck")
+	defer span.End()
+
+	if err := e.bus.HealthCheck(ctx); err != nil {
+		telemetry.RecordSpanError(span, err)
+		return err
+	}
+
+	telemetry.SetSpanSuccess(span)
+	return nil
+}
@@ -24,7 +24,7 @@ func NewObservableRepository(repo ports.OrderRepository, metrics *database.Metri
 }
 
 func (r *ObservableRepository) Create(ctx context.Context, order domain.Order) error {
-	ctx, span := telemetry.StartSpan(ctx, "OrderRepository.Create")
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.Create")
 	defer span.End()
 
 	telemetry.AddSpanAttributes(span,
@@ -36,7 +36,7 @@ func (r *ObservableRepository) Create(ctx context.Context, order domain.Order) e
 	err := r.repo.Create(ctx, order)
 	duration := time.Since(start).Seconds()
 
-	r.metrics.RecordQuery(ctx, "create_order", duration)
+	r.metrics.RecordQuery(ctx, "create_order", duration, err == nil)
 
 	if err != nil {
 		telemetry.RecordSpanError(span, err)
@@ -48,7 +48,7 @@ func (r *ObservableRepository) Create(ctx context.Context, order domain.Order) e
 }
 
 func (r *ObservableRepository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
-	ctx, span := telemetry.StartSpan(ctx, "OrderRepository.GetByID")
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.GetByID")
 	defer span.End()
 
 	telemetry.AddSpanAttributes(span,
@@ -60,7 +60,7 @@ func (r *ObservableRepository) GetByID(ctx context.Context, id string) (*domain.
 	order, err := r.repo.GetByID(ctx, id)
 	duration := time.Since(start).Seconds()
 
-	r.metrics.RecordQuery(ctx, "get_order_by_id", duration)
+	r.metrics.RecordQuery(ctx, "get_order_by_id", duration, err == nil)
 
 	if err != nil {
 		telemetry.RecordSpanError(span, err)
@@ -71,8 +71,83 @@ func (r *ObservableRepository) GetByID(ctx context.Context, id string) (*domain.
 	return order, nil
 }
 
+func (r *ObservableRepository) GetByReference(ctx context.Context, reference string) (*domain.Order, error) {
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.GetByReference")
+	defer span.End()
+
+	telemetry.AddSpanAttributes(span,
+		attribute.String("order.reference", reference),
+		attribute.String("operation", "get_by_reference"),
+	)
+
+	start := time.Now()
+	order, err := r.repo.GetByReference(ctx, reference)
+	duration := time.Since(start).Seconds()
+
+	r.metrics.RecordQuery(ctx, "get_order_by_reference", duration, err == nil)
+
+	if err != nil {
+		telemetry.RecordSpanError(span, err)
+		return nil, err
+	}
+
+	telemetry.AddSpanAttributes(span, attribute.String("order.id", order.ID))
+	telemetry.SetSpanSuccess(span)
+	return order, nil
+}
+
+func (r *ObservableRepository) GetByIDs(ctx context.Context, ids []string) (map[string]domain.Order, error) {
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.GetByIDs")
+	defer span.End()
+
+	telemetry.AddSpanAttributes(span,
+		attribute.Int("order.requested", len(ids)),
+		attribute.String("operation", "get_by_ids"),
+	)
+
+	start := time.Now()
+	orders, err := r.repo.GetByIDs(ctx, ids)
+	duration := time.Since(start).Seconds()
+
+	r.metrics.RecordQuery(ctx, "get_orders_by_ids", duration, err == nil)
+
+	if err != nil {
+		telemetry.RecordSpanError(span, err)
+		return nil, err
+	}
+
+	telemetry.AddSpanAttributes(span, attribute.Int("result.count", len(orders)))
+	telemetry.SetSpanSuccess(span)
+	return orders, nil
+}
+
+func (r *ObservableRepository) Exists(ctx context.Context, id string) (bool, error) {
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.Exists")
+	defer span.End()
+
+	telemetry.AddSpanAttributes(span,
+		attribute.String("order.id", id),
+		attribute.String("operation", "exists"),
+	)
+
+	start := time.Now()
+	exists, err := r.repo.Exists(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	r.metrics.RecordQuery(ctx, "order_exists", duration, err == nil)
+
+	if err != nil {
+		telemetry.RecordSpanError(span, err)
+		return false, err
+	}
+
+	telemetry.AddSpanAttributes(span, attribute.Bool("result.exists", exists))
+	telemetry.SetSpanSuccess(span)
+	return exists, nil
+}
+
 func (r *ObservableRepository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
-	ctx, span := telemetry.StartSpan(ctx, "OrderRepository.List")
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.List")
 	defer span.End()
 
 	attrs := []attribute.KeyValue{
@@ -89,7 +164,7 @@ func (r *ObservableRepository) List(ctx context.Context, filter ports.ListFilter
 	orders, err := r.repo.List(ctx, filter)
 	duration := time.Since(start).Seconds()
 
-	r.metrics.RecordQuery(ctx, "list_orders", duration)
+	r.metrics.RecordQuery(ctx, "list_orders", duration, err == nil)
 
 	if err != nil {
 		telemetry.RecordSpanError(span, err)
@@ -101,8 +176,64 @@ func (r *ObservableRepository) List(ctx context.Context, filter ports.ListFilter
 	return orders, nil
 }
 
+func (r *ObservableRepository) Count(ctx context.Context, filter ports.ListFilter) (int, error) {
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.Count")
+	defer span.End()
+
+	attrs := []attribute.KeyValue{
+		attribute.String("operation", "count"),
+	}
+	if filter.Status != nil {
+		attrs = append(attrs, attribute.String("filter.status", string(*filter.Status)))
+	}
+	telemetry.AddSpanAttributes(span, attrs...)
+
+	start := time.Now()
+	count, err := r.repo.Count(ctx, filter)
+	duration := time.Since(start).Seconds()
+
+	r.metrics.RecordQuery(ctx, "count_orders", duration, err == nil)
+
+	if err != nil {
+		telemetry.RecordSpanError(span, err)
+		return 0, err
+	}
+
+	telemetry.AddSpanAttributes(span, attribute.Int("result.count", count))
+	telemetry.SetSpanSuccess(span)
+	return count, nil
+}
+
+func (r *ObservableRepository) DistinctCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error) {
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.DistinctCustomers")
+	defer span.End()
+
+	attrs := []attribute.KeyValue{
+		attribute.String("operation", "distinct_customers"),
+	}
+	if filter.Status != nil {
+		attrs = append(attrs, attribute.String("filter.status", string(*filter.Status)))
+	}
+	telemetry.AddSpanAttributes(span, attrs...)
+
+	start := time.Now()
+	emails, err := r.repo.DistinctCustomers(ctx, filter)
+	duration := time.Since(start).Seconds()
+
+	r.metrics.RecordQuery(ctx, "list_distinct_customers", duration, err == nil)
+
+	if err != nil {
+		telemetry.RecordSpanError(span, err)
+		return nil, err
+	}
+
+	telemetry.AddSpanAttributes(span, attribute.Int("result.count", len(emails)))
+	telemetry.SetSpanSuccess(span)
+	return emails, nil
+}
+
 func (r *ObservableRepository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
-	ctx, span := telemetry.StartSpan(ctx, "OrderRepository.UpdateStatus")
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.UpdateStatus")
 	defer span.End()
 
 	telemetry.AddSpanAttributes(span,
@@ -115,7 +246,32 @@ func (r *ObservableRepository) UpdateStatus(ctx context.Context, id string, stat
 	err := r.repo.UpdateStatus(ctx, id, status)
 	duration := time.Since(start).Seconds()
 
-	r.metrics.RecordQuery(ctx, "update_order_status", duration)
+	r.metrics.RecordQuery(ctx, "update_order_status", duration, err == nil)
+
+	if err != nil {
+		telemetry.RecordSpanError(span, err)
+		return err
+	}
+
+	telemetry.SetSpanSuccess(span)
+	return nil
+}
+
+func (r *ObservableRepository) AppendStatusHistory(ctx context.Context, id string, transition domain.StatusTransition) error {
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.AppendStatusHistory")
+	defer span.End()
+
+	telemetry.AddSpanAttributes(span,
+		attribute.String("order.id", id),
+		attribute.String("order.new_status", string(transition.To)),
+		attribute.String("operation", "append_status_history"),
+	)
+
+	start := time.Now()
+	err := r.repo.AppendStatusHistory(ctx, id, transition)
+	duration := time.Since(start).Seconds()
+
+	r.metrics.RecordQuery(ctx, "append_order_status_history", duration, err == nil)
 
 	if err != nil {
 		telemetry.RecordSpanError(span, err)
@@ -125,3 +281,28 @@ func (r *ObservableRepository) UpdateStatus(ctx context.Context, id string, stat
 	telemetry.SetSpanSuccess(span)
 	return nil
 }
+
+func (r *ObservableRepository) GetStatusHistory(ctx context.Context, id string) ([]domain.StatusTransition, error) {
+	ctx, span := tracer.StartSpan(ctx, "OrderRepository.GetStatusHistory")
+	defer span.End()
+
+	telemetry.AddSpanAttributes(span,
+		attribute.String("order.id", id),
+		attribute.String("operation", "get_status_history"),
+	)
+
+	start := time.Now()
+	history, err := r.repo.GetStatusHistory(ctx, id)
+	duration := time.Since(start).Seconds()
+
+	r.metrics.RecordQuery(ctx, "get_order_status_history", duration, err == nil)
+
+	if err != nil {
+		telemetry.RecordSpanError(span, err)
+		return nil, err
+	}
+
+	telemetry.AddSpanAttributes(span, attribute.Int("result.count", len(history)))
+	telemetry.SetSpanSuccess(span)
+	return history, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/dejobratic/tbd/internal/database"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultSchema is the schema queried unless WithSchema overrides it.
+const DefaultSchema = "public"
+
+// Option customizes the Repository, UnitOfWork, OutboxStore, and ReferenceSequence.
+type Option func(*options)
+
+type options struct {
+	schema     string
+	statements bool
+	clock      ports.Clock
+	maxRows    int
+	logger     *slog.Logger
+}
+
+func newOptions(opts []Option) options {
+	o := options{schema: DefaultSchema, clock: ports.SystemClock{}, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithSchema qualifies every table and sequence with schema, e.g. tenant_a.orders, so each
+// tenant or blue/green deployment can live in its own schema. It panics if schema is not a
+// plain identifier; validate configured values with database.ValidateIdentifier first.
+func WithSchema(schema string) Option {
+	if err := database.ValidateIdentifier(schema); err != nil {
+		panic(fmt.Sprintf("postgres.WithSchema: %v", err))
+	}
+	return func(o *options) {
+		o.schema = schema
+	}
+}
+
+// WithClock sets the clock status changes are stamped with.
+func WithClock(clock ports.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithMaxRows caps the rows a single List call returns, whatever page size it asks for,
+// logging a warning when a call is truncated. Pages are then maxRows long, so later pages
+// start where the capped one ended. A non-positive limit disables the cap.
+func WithMaxRows(n int) Option {
+	return func(o *options) {
+		o.maxRows = n
+	}
+}
+
+// WithLogger sets the logger truncated List calls are reported to. The default is
+// slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// qualify returns name qualified with the configured schema, quoted for use in SQL.
+func (o options) qualify(name string) string {
+	return o.identifier(name).Sanitize()
+}
+
+func (o options) identifier(name string) pgx.Identifier {
+	schema := o.schema
+	if schema == "" {
+		schema = DefaultSchema
+	}
+	return pgx.Identifier{schema, name}
+}
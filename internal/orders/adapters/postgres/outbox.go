@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OutboxStore struct {
+	db querier
+	options
+}
+
+func NewOutboxStore(pool *pgxpool.Pool, opts ...Option) *OutboxStore {
+	return &OutboxStore{db: pool, options: newOptions(opts)}
+}
+
+func (s *OutboxStore) Add(ctx context.Context, msg ports.OutboxMessage) error {
+	query := `
+		INSERT INTO ` + s.qualify("outbox") + ` (id, topic, key, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := s.db.Exec(ctx, query, msg.ID, msg.Topic, msg.Key, msg.Payload, msg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert outbox message: %w", err)
+	}
+
+	return nil
+}
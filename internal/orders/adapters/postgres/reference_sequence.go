@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReferenceSequence draws order reference numbers from the order_reference_seq sequence,
+// so references stay unique across API instances.
+type ReferenceSequence struct {
+	db querier
+	options
+}
+
+func NewReferenceSequence(pool *pgxpool.Pool, opts ...Option) *ReferenceSequence {
+	return &ReferenceSequence{db: pool, options: newOptions(opts)}
+}
+
+func (s *ReferenceSequence) NextReference(ctx context.Context) (int64, error) {
+	var next int64
+	if err := s.db.QueryRow(ctx, `SELECT nextval($1::regclass)`, s.qualify("order_reference_seq")).Scan(&next); err != nil {
+		return 0, fmt.Errorf("next order reference: %w", err)
+	}
+	return next, nil
+}
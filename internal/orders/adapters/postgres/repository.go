@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -9,30 +10,51 @@ import (
 	"github.com/dejobratic/tbd/internal/orders/domain"
 	"github.com/dejobratic/tbd/internal/orders/ports"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx so the repository can run
+// standalone or inside a unit of work.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
 type Repository struct {
-	pool *pgxpool.Pool
+	db querier
+	options
 }
 
-func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+func NewRepository(pool *pgxpool.Pool, opts ...Option) *Repository {
+	return &Repository{db: pool, options: newOptions(opts)}
 }
 
 func (r *Repository) Create(ctx context.Context, order domain.Order) error {
 	query := `
-		INSERT INTO orders (id, customer_email, amount_cents, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO ` + r.qualify("orders") + ` (id, reference, customer_id, customer_email, amount_cents, currency, status, created_at, updated_at, metadata, idempotency_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	metadata, err := metadataJSON(order.Metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.exec(ctx, query,
 		order.ID,
+		nullableString(order.Reference),
+		order.CustomerID,
 		order.CustomerEmail,
-		order.AmountCents,
+		order.Amount.AmountCents,
+		order.Amount.Currency,
 		order.Status,
 		order.CreatedAt,
 		order.UpdatedAt,
+		metadata,
+		nullableString(order.IdempotencyKey),
 	)
 	if err != nil {
 		return fmt.Errorf("insert order: %w", err)
@@ -41,22 +63,77 @@ func (r *Repository) Create(ctx context.Context, order domain.Order) error {
 	return nil
 }
 
-func (r *Repository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
-	query := `
-		SELECT id, customer_email, amount_cents, status, created_at, updated_at
-		FROM orders
-		WHERE id = $1
-	`
+// CreateBatch inserts many orders at once using the COPY protocol, which is significantly
+// faster than issuing one INSERT per order.
+func (r *Repository) CreateBatch(ctx context.Context, orders []domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
 
+	copied, err := r.db.CopyFrom(ctx,
+		r.identifier("orders"),
+		[]string{"id", "reference", "customer_id", "customer_email", "amount_cents", "currency", "status", "created_at", "updated_at", "metadata", "idempotency_key"},
+		pgx.CopyFromSlice(len(orders), func(i int) ([]any, error) {
+			order := orders[i]
+			metadata, err := metadataJSON(order.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			return []any{
+				order.ID,
+				nullableString(order.Reference),
+				order.CustomerID,
+				order.CustomerEmail,
+				order.Amount.AmountCents,
+				order.Amount.Currency,
+				string(order.Status),
+				order.CreatedAt,
+				order.UpdatedAt,
+				metadata,
+				nullableString(order.IdempotencyKey),
+			}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("copy orders: %w", err)
+	}
+	recordRowsAffected(ctx, copied)
+
+	return nil
+}
+
+// orderColumns lists the columns read by scanOrder, in scan order.
+const orderColumns = `id, COALESCE(reference, ''), customer_id, customer_email, amount_cents, currency, status, created_at, updated_at, metadata, COALESCE(idempotency_key, '')`
+
+func scanOrder(row pgx.Row) (domain.Order, error) {
 	var order domain.Order
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := row.Scan(
 		&order.ID,
+		&order.Reference,
+		&order.CustomerID,
 		&order.CustomerEmail,
-		&order.AmountCents,
+		&order.Amount.AmountCents,
+		&order.Amount.Currency,
 		&order.Status,
 		&order.CreatedAt,
 		&order.UpdatedAt,
+		&order.Metadata,
+		&order.IdempotencyKey,
 	)
+	if len(order.Metadata) == 0 {
+		order.Metadata = nil
+	}
+	return order, err
+}
+
+func (r *Repository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM ` + r.qualify("orders") + `
+		WHERE id = $1
+	`
+
+	order, err := scanOrder(r.queryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ports.ErrNotFound
@@ -67,33 +144,142 @@ func (r *Repository) GetByID(ctx context.Context, id string) (*domain.Order, err
 	return &order, nil
 }
 
-func (r *Repository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
-	page := filter.Page
-	if page <= 0 {
-		page = 1
+func (r *Repository) GetByIDs(ctx context.Context, ids []string) (map[string]domain.Order, error) {
+	if len(ids) == 0 {
+		return map[string]domain.Order{}, nil
+	}
+
+	query := `
+		SELECT ` + orderColumns + `
+		FROM ` + r.qualify("orders") + `
+		WHERE id = ANY($1)
+	`
+
+	rows, err := r.query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("query orders by ids: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make(map[string]domain.Order, len(ids))
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		orders[order.ID] = order
 	}
-	pageSize := filter.PageSize
-	if pageSize <= 0 {
-		pageSize = 20
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate orders: %w", err)
 	}
+	recordRowCount(ctx, len(orders))
+
+	return orders, nil
+}
 
+func (r *Repository) GetByReference(ctx context.Context, reference string) (*domain.Order, error) {
 	query := `
-		SELECT id, customer_email, amount_cents, status, created_at, updated_at
-		FROM orders
-		WHERE ($1::text IS NULL OR status = $1)
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		SELECT ` + orderColumns + `
+		FROM ` + r.qualify("orders") + `
+		WHERE reference = $1
 	`
 
+	order, err := scanOrder(r.queryRow(ctx, query, reference))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ports.ErrNotFound
+		}
+		return nil, fmt.Errorf("select order by reference: %w", err)
+	}
+
+	return &order, nil
+}
+
+func (r *Repository) Exists(ctx context.Context, id string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM ` + r.qualify("orders") + ` WHERE id = $1)`
+
+	var exists bool
+	if err := r.queryRow(ctx, query, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check order exists: %w", err)
+	}
+
+	return exists, nil
+}
+
+// listFilterWhere is shared by List and Count; its parameters are built by listFilterArgs.
+const listFilterWhere = `
+		WHERE ($1::text IS NULL OR status = $1)
+			AND (cardinality($2::text[]) = 0 OR status = ANY($2))
+			AND ($3::text IS NULL OR lower(customer_email) = lower($3))
+			AND ($4::timestamptz IS NULL OR created_at >= $4)
+			AND ($5::timestamptz IS NULL OR created_at < $5)
+			AND ($6::text IS NULL OR customer_id = $6)
+			AND ($7::text IS NULL OR currency = upper(trim($7)))
+			AND ($8::timestamptz IS NULL OR updated_at >= $8)
+`
+
+func listFilterArgs(filter ports.ListFilter) []any {
 	var statusFilter *string
 	if filter.Status != nil {
 		s := string(*filter.Status)
 		statusFilter = &s
 	}
 
-	offset := (page - 1) * pageSize
+	statusesFilter := make([]string, 0, len(filter.Statuses))
+	for _, status := range filter.Statuses {
+		statusesFilter = append(statusesFilter, string(status))
+	}
 
-	rows, err := r.pool.Query(ctx, query, statusFilter, pageSize, offset)
+	var emailFilter *string
+	if filter.CustomerEmail != "" {
+		emailFilter = &filter.CustomerEmail
+	}
+
+	return []any{
+		statusFilter,
+		statusesFilter,
+		emailFilter,
+		nullableTime(filter.CreatedAfter),
+		nullableTime(filter.CreatedBefore),
+		nullableString(filter.CustomerID),
+		nullableString(filter.Currency),
+		nullableTime(filter.UpdatedAfter),
+	}
+}
+
+// listOrderBy sorts newest orders first, or oldest changes first when the filter asks for
+// orders updated since a timestamp.
+func listOrderBy(filter ports.ListFilter) string {
+	if !filter.UpdatedAfter.IsZero() {
+		return "updated_at ASC, id ASC"
+	}
+	return "created_at DESC"
+}
+
+func (r *Repository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
+	page, pageSize := filter.Pagination()
+
+	query := `
+		SELECT ` + orderColumns + `
+		FROM ` + r.qualify("orders") + listFilterWhere + `
+		ORDER BY ` + listOrderBy(filter) + `
+		LIMIT $9 OFFSET $10
+	`
+
+	limit := pageSize
+	if r.maxRows > 0 && limit > r.maxRows {
+		limit = r.maxRows
+	}
+	offset := (page - 1) * limit
+	// A capped page fetches one extra row to tell whether the cap actually cut it short.
+	fetch := limit
+	if limit < pageSize {
+		fetch++
+	}
+	args := append(listFilterArgs(filter), fetch, offset)
+
+	rows, err := r.query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query orders: %w", err)
 	}
@@ -101,15 +287,8 @@ func (r *Repository) List(ctx context.Context, filter ports.ListFilter) ([]domai
 
 	var orders []domain.Order
 	for rows.Next() {
-		var order domain.Order
-		if err := rows.Scan(
-			&order.ID,
-			&order.CustomerEmail,
-			&order.AmountCents,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-		); err != nil {
+		order, err := scanOrder(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan order: %w", err)
 		}
 		orders = append(orders, order)
@@ -118,18 +297,82 @@ func (r *Repository) List(ctx context.Context, filter ports.ListFilter) ([]domai
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate orders: %w", err)
 	}
+	if len(orders) > limit {
+		r.logger.WarnContext(ctx, "order list truncated", "page_size", pageSize, "max_rows", r.maxRows)
+		orders = orders[:limit]
+	}
+	recordRowCount(ctx, len(orders))
 
 	return orders, nil
 }
 
+func (r *Repository) Count(ctx context.Context, filter ports.ListFilter) (int, error) {
+	query := `
+		SELECT count(*)
+		FROM ` + r.qualify("orders") + listFilterWhere
+
+	var count int
+	if err := r.queryRow(ctx, query, listFilterArgs(filter)...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count orders: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *Repository) DistinctCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error) {
+	page, pageSize := filter.Pagination()
+
+	query := `
+		SELECT DISTINCT lower(customer_email) AS email
+		FROM ` + r.qualify("orders") + listFilterWhere + `
+		ORDER BY email
+		LIMIT $9 OFFSET $10
+	`
+
+	offset := (page - 1) * pageSize
+	args := append(listFilterArgs(filter), pageSize, offset)
+
+	rows, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query distinct customers: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, fmt.Errorf("scan customer email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate customer emails: %w", err)
+	}
+	recordRowCount(ctx, len(emails))
+
+	return emails, nil
+}
+
+// UpdateStatus changes the status and inserts the history row in a single statement, so
+// both are written atomically. The previous CTE reads the pre-update snapshot.
 func (r *Repository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
 	query := `
-		UPDATE orders
-		SET status = $1, updated_at = $2
-		WHERE id = $3
+		WITH previous AS (
+			SELECT status FROM ` + r.qualify("orders") + ` WHERE id = $3
+		), updated AS (
+			UPDATE ` + r.qualify("orders") + `
+			SET status = $1, updated_at = $2
+			WHERE id = $3
+			RETURNING id
+		)
+		INSERT INTO ` + r.qualify("order_status_history") + ` (order_id, from_status, to_status, changed_at)
+		SELECT updated.id, previous.status, $1, $2
+		FROM updated, previous
 	`
 
-	result, err := r.pool.Exec(ctx, query, status, time.Now().UTC(), id)
+	result, err := r.exec(ctx, query, status, r.clock.Now(), id)
 	if err != nil {
 		return fmt.Errorf("update order status: %w", err)
 	}
@@ -140,3 +383,88 @@ func (r *Repository) UpdateStatus(ctx context.Context, id string, status domain.
 
 	return nil
 }
+
+func (r *Repository) AppendStatusHistory(ctx context.Context, id string, transition domain.StatusTransition) error {
+	query := `
+		INSERT INTO ` + r.qualify("order_status_history") + ` (order_id, from_status, to_status, changed_at)
+		SELECT id, $2, $3, $4 FROM ` + r.qualify("orders") + ` WHERE id = $1
+	`
+
+	result, err := r.exec(ctx, query, id, transition.From, transition.To, transition.ChangedAt)
+	if err != nil {
+		return fmt.Errorf("insert order status history: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) GetStatusHistory(ctx context.Context, id string) ([]domain.StatusTransition, error) {
+	query := `
+		SELECT from_status, to_status, changed_at
+		FROM ` + r.qualify("order_status_history") + `
+		WHERE order_id = $1
+		ORDER BY changed_at, id
+	`
+
+	rows, err := r.query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("query order status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []domain.StatusTransition{}
+	for rows.Next() {
+		var transition domain.StatusTransition
+		if err := rows.Scan(&transition.From, &transition.To, &transition.ChangedAt); err != nil {
+			return nil, fmt.Errorf("scan order status history: %w", err)
+		}
+		history = append(history, transition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate order status history: %w", err)
+	}
+	recordRowCount(ctx, len(history))
+
+	if len(history) == 0 {
+		exists, err := r.Exists(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ports.ErrNotFound
+		}
+	}
+
+	return history, nil
+}
+
+// metadataJSON encodes metadata for the jsonb column, storing an empty object when unset.
+func metadataJSON(metadata map[string]string) ([]byte, error) {
+	if len(metadata) == 0 {
+		return []byte("{}"), nil
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("encode order metadata: %w", err)
+	}
+	return encoded, nil
+}
+
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -3,9 +3,14 @@
 package postgres_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,12 +18,13 @@ import (
 	"github.com/dejobratic/tbd/internal/orders/adapters/postgres"
 	"github.com/dejobratic/tbd/internal/orders/domain"
 	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/dejobratic/tbd/internal/orders/ports/portstest"
 	"github.com/jackc/pgx/v5/pgxpool"
 	testpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-func setupTestDB(t *testing.T) *pgxpool.Pool {
+func setupTestDB(t testing.TB) *pgxpool.Pool {
 	t.Helper()
 	ctx := context.Background()
 
@@ -64,7 +70,7 @@ func setupTestDB(t *testing.T) *pgxpool.Pool {
 	return pool
 }
 
-func findProjectRoot(t *testing.T) string {
+func findProjectRoot(t testing.TB) string {
 	t.Helper()
 	dir, err := os.Getwd()
 	if err != nil {
@@ -83,6 +89,16 @@ func findProjectRoot(t *testing.T) string {
 	}
 }
 
+func TestSchemaVersion(t *testing.T) {
+	pool := setupTestDB(t)
+
+	t.Run("migrated schema satisfies the required version", func(t *testing.T) {
+		if err := database.CheckSchemaVersion(context.Background(), pool); err != nil {
+			t.Errorf("expected schema check to pass, got %v", err)
+		}
+	})
+}
+
 func TestCreateOrder(t *testing.T) {
 	pool := setupTestDB(t)
 	repo := postgres.NewRepository(pool)
@@ -91,7 +107,7 @@ func TestCreateOrder(t *testing.T) {
 	order := domain.Order{
 		ID:            "test-order-1",
 		CustomerEmail: "user@example.com",
-		AmountCents:   1999,
+		Amount:        domain.NewMoney(1999, "USD"),
 		Status:        domain.StatusPending,
 		CreatedAt:     time.Now().UTC(),
 		UpdatedAt:     time.Now().UTC(),
@@ -113,8 +129,8 @@ func TestCreateOrder(t *testing.T) {
 	if retrieved.CustomerEmail != order.CustomerEmail {
 		t.Errorf("expected email %s, got %s", order.CustomerEmail, retrieved.CustomerEmail)
 	}
-	if retrieved.AmountCents != order.AmountCents {
-		t.Errorf("expected amount %d, got %d", order.AmountCents, retrieved.AmountCents)
+	if retrieved.Amount.AmountCents != order.Amount.AmountCents {
+		t.Errorf("expected amount %d, got %d", order.Amount.AmountCents, retrieved.Amount.AmountCents)
 	}
 	if retrieved.Status != order.Status {
 		t.Errorf("expected status %s, got %s", order.Status, retrieved.Status)
@@ -143,7 +159,7 @@ func TestListOrders(t *testing.T) {
 		{
 			ID:            "order-1",
 			CustomerEmail: "user1@example.com",
-			AmountCents:   1000,
+			Amount:        domain.NewMoney(1000, "USD"),
 			Status:        domain.StatusPending,
 			CreatedAt:     time.Now().UTC(),
 			UpdatedAt:     time.Now().UTC(),
@@ -151,7 +167,7 @@ func TestListOrders(t *testing.T) {
 		{
 			ID:            "order-2",
 			CustomerEmail: "user2@example.com",
-			AmountCents:   2000,
+			Amount:        domain.NewMoney(2000, "USD"),
 			Status:        domain.StatusCompleted,
 			CreatedAt:     time.Now().UTC().Add(1 * time.Second),
 			UpdatedAt:     time.Now().UTC().Add(1 * time.Second),
@@ -159,7 +175,7 @@ func TestListOrders(t *testing.T) {
 		{
 			ID:            "order-3",
 			CustomerEmail: "user3@example.com",
-			AmountCents:   3000,
+			Amount:        domain.NewMoney(3000, "USD"),
 			Status:        domain.StatusPending,
 			CreatedAt:     time.Now().UTC().Add(2 * time.Second),
 			UpdatedAt:     time.Now().UTC().Add(2 * time.Second),
@@ -224,6 +240,34 @@ func TestListOrders(t *testing.T) {
 			t.Errorf("expected 1 order (page 2), got %d", len(result))
 		}
 	})
+
+	t.Run("caps pages at the row limit", func(t *testing.T) {
+		var logs bytes.Buffer
+		capped := postgres.NewRepository(pool, postgres.WithMaxRows(2), postgres.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+		result, err := capped.List(ctx, ports.ListFilter{Page: 1, PageSize: 10})
+		if err != nil {
+			t.Fatalf("failed to list orders: %v", err)
+		}
+		if len(result) != 2 || result[0].ID != "order-3" || result[1].ID != "order-2" {
+			t.Errorf("expected order-3 and order-2, got %+v", result)
+		}
+		if !strings.Contains(logs.String(), "order list truncated") {
+			t.Errorf("expected a truncation warning, got %q", logs.String())
+		}
+
+		logs.Reset()
+		result, err = capped.List(ctx, ports.ListFilter{Page: 2, PageSize: 10})
+		if err != nil {
+			t.Fatalf("failed to list orders: %v", err)
+		}
+		if len(result) != 1 || result[0].ID != "order-1" {
+			t.Errorf("expected order-1, got %+v", result)
+		}
+		if logs.Len() != 0 {
+			t.Errorf("expected no warning for a page the cap did not cut short, got %q", logs.String())
+		}
+	})
 }
 
 func TestUpdateOrderStatus(t *testing.T) {
@@ -235,7 +279,7 @@ func TestUpdateOrderStatus(t *testing.T) {
 		order := domain.Order{
 			ID:            "test-order-update",
 			CustomerEmail: "user@example.com",
-			AmountCents:   1500,
+			Amount:        domain.NewMoney(1500, "USD"),
 			Status:        domain.StatusPending,
 			CreatedAt:     time.Now().UTC(),
 			UpdatedAt:     time.Now().UTC(),
@@ -271,3 +315,174 @@ func TestUpdateOrderStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestCreateOrderBatch(t *testing.T) {
+	pool := setupTestDB(t)
+	repo := postgres.NewRepository(pool)
+	ctx := context.Background()
+
+	t.Run("inserts all orders in the batch", func(t *testing.T) {
+		orders := newTestOrders("batch", 50)
+
+		if err := repo.CreateBatch(ctx, orders); err != nil {
+			t.Fatalf("failed to create batch: %v", err)
+		}
+
+		for _, order := range orders {
+			retrieved, err := repo.GetByID(ctx, order.ID)
+			if err != nil {
+				t.Fatalf("failed to retrieve order %s: %v", order.ID, err)
+			}
+			if retrieved.Status != order.Status {
+				t.Errorf("expected status %s, got %s", order.Status, retrieved.Status)
+			}
+		}
+	})
+
+	t.Run("accepts empty batch", func(t *testing.T) {
+		if err := repo.CreateBatch(ctx, nil); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
+}
+
+func BenchmarkCreateOrders(b *testing.B) {
+	pool := setupTestDB(b)
+	repo := postgres.NewRepository(pool)
+	ctx := context.Background()
+
+	const batchSize = 10000
+
+	b.Run("copy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			orders := newTestOrders(fmt.Sprintf("copy-%d", i), batchSize)
+			if err := repo.CreateBatch(ctx, orders); err != nil {
+				b.Fatalf("failed to create batch: %v", err)
+			}
+		}
+	})
+
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			orders := newTestOrders(fmt.Sprintf("loop-%d", i), batchSize)
+			for _, order := range orders {
+				if err := repo.Create(ctx, order); err != nil {
+					b.Fatalf("failed to create order: %v", err)
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkGetByID(b *testing.B) {
+	pool := setupTestDB(b)
+	ctx := context.Background()
+
+	order := newTestOrders("bench-get", 1)[0]
+	if err := postgres.NewRepository(pool).Create(ctx, order); err != nil {
+		b.Fatalf("failed to create order: %v", err)
+	}
+
+	for _, mode := range []string{database.QueryExecModeCacheStatement, database.QueryExecModeExec} {
+		b.Run(mode, func(b *testing.B) {
+			modePool, err := database.NewPool(ctx, pool.Config().ConnString(), database.WithQueryExecMode(mode))
+			if err != nil {
+				b.Fatalf("failed to create pool: %v", err)
+			}
+			defer modePool.Close()
+
+			repo := postgres.NewRepository(modePool)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.GetByID(ctx, order.ID); err != nil {
+					b.Fatalf("failed to get order: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func newTestOrders(prefix string, n int) []domain.Order {
+	now := time.Now().UTC()
+	orders := make([]domain.Order, n)
+	for i := range orders {
+		orders[i] = domain.Order{
+			ID:            fmt.Sprintf("%s-order-%d", prefix, i),
+			CustomerEmail: fmt.Sprintf("user%d@example.com", i),
+			Amount:        domain.NewMoney(int64(1000+i), "USD"),
+			Status:        domain.StatusPending,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+	}
+	return orders
+}
+
+func TestUnitOfWork(t *testing.T) {
+	pool := setupTestDB(t)
+	repo := postgres.NewRepository(pool)
+	uow := postgres.NewUnitOfWork(pool)
+	ctx := context.Background()
+
+	t.Run("commits order and outbox message together", func(t *testing.T) {
+		order := newTestOrders("uow-commit", 1)[0]
+
+		err := uow.Do(ctx, func(txRepo ports.OrderRepository, outbox ports.OutboxStore) error {
+			if err := txRepo.Create(ctx, order); err != nil {
+				return err
+			}
+			return outbox.Add(ctx, ports.OutboxMessage{
+				ID:        "msg-" + order.ID,
+				Topic:     "order.created",
+				Key:       order.ID,
+				Payload:   []byte(`{}`),
+				CreatedAt: time.Now().UTC(),
+			})
+		})
+		if err != nil {
+			t.Fatalf("unit of work failed: %v", err)
+		}
+
+		if _, err := repo.GetByID(ctx, order.ID); err != nil {
+			t.Errorf("expected committed order, got %v", err)
+		}
+
+		var count int
+		if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM outbox WHERE key = $1", order.ID).Scan(&count); err != nil {
+			t.Fatalf("failed to count outbox messages: %v", err)
+		}
+		if count != 1 {
+			t.Errorf("expected 1 outbox message, got %d", count)
+		}
+	})
+
+	t.Run("rolls back all writes when callback fails", func(t *testing.T) {
+		order := newTestOrders("uow-rollback", 1)[0]
+		callbackErr := errors.New("outbox unavailable")
+
+		err := uow.Do(ctx, func(txRepo ports.OrderRepository, outbox ports.OutboxStore) error {
+			if err := txRepo.Create(ctx, order); err != nil {
+				return err
+			}
+			return callbackErr
+		})
+		if !errors.Is(err, callbackErr) {
+			t.Fatalf("expected callback error, got %v", err)
+		}
+
+		if _, err := repo.GetByID(ctx, order.ID); !errors.Is(err, ports.ErrNotFound) {
+			t.Errorf("expected rolled back order to be missing, got %v", err)
+		}
+	})
+}
+
+func TestOrderRepositoryConformance(t *testing.T) {
+	pool := setupTestDB(t)
+
+	portstest.RunOrderRepositoryTests(t, func(t *testing.T) ports.OrderRepository {
+		if _, err := pool.Exec(context.Background(), "TRUNCATE orders CASCADE"); err != nil {
+			t.Fatalf("failed to truncate orders: %v", err)
+		}
+		return postgres.NewRepository(pool)
+	})
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithStatementAttributes records each query's parameterized SQL as the db.statement
+// attribute of the active span. Bound values are never recorded, so no customer data
+// reaches traces, but the attribute makes spans noticeably larger.
+func WithStatementAttributes(enabled bool) Option {
+	return func(o *options) {
+		o.statements = enabled
+	}
+}
+
+func (r *Repository) exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	r.annotate(ctx, sql)
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err == nil {
+		recordRowsAffected(ctx, tag.RowsAffected())
+	}
+	return tag, err
+}
+
+func (r *Repository) query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	r.annotate(ctx, sql)
+	return r.db.Query(ctx, sql, args...)
+}
+
+func (r *Repository) queryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	r.annotate(ctx, sql)
+	return r.db.QueryRow(ctx, sql, args...)
+}
+
+func (r *Repository) annotate(ctx context.Context, sql string) {
+	if r.statements {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("db.statement", sql))
+	}
+}
+
+func recordRowsAffected(ctx context.Context, n int64) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.rows_affected", n))
+}
+
+func recordRowCount(ctx context.Context, n int) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("db.row_count", n))
+}
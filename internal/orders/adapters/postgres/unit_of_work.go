@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// UnitOfWork runs callbacks inside a single database transaction.
+type UnitOfWork struct {
+	pool *pgxpool.Pool
+	options
+}
+
+func NewUnitOfWork(pool *pgxpool.Pool, opts ...Option) *UnitOfWork {
+	return &UnitOfWork{pool: pool, options: newOptions(opts)}
+}
+
+// Do commits when fn succeeds and rolls back when it returns an error.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repo ports.OrderRepository, outbox ports.OutboxStore) error) error {
+	tx, err := u.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed.
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := fn(&Repository{db: tx, options: u.options}, &OutboxStore{db: tx, options: u.options}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
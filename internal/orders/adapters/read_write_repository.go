@@ -0,0 +1,78 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// ReadWriteRepository routes reads to a replica and writes to the primary. Reads fall back
+// to the primary when no replica is configured, and go to it when their context is marked
+// with ports.WithPrimaryReads.
+type ReadWriteRepository struct {
+	primary ports.OrderRepository
+	replica ports.OrderRepository
+}
+
+func NewReadWriteRepository(primary, replica ports.OrderRepository) *ReadWriteRepository {
+	if replica == nil {
+		replica = primary
+	}
+	return &ReadWriteRepository{
+		primary: primary,
+		replica: replica,
+	}
+}
+
+// reader returns the repository reads made with ctx go to.
+func (r *ReadWriteRepository) reader(ctx context.Context) ports.OrderRepository {
+	if ports.PrimaryReads(ctx) {
+		return r.primary
+	}
+	return r.replica
+}
+
+func (r *ReadWriteRepository) Create(ctx context.Context, order domain.Order) error {
+	return r.primary.Create(ctx, order)
+}
+
+func (r *ReadWriteRepository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	return r.reader(ctx).GetByID(ctx, id)
+}
+
+func (r *ReadWriteRepository) GetByIDs(ctx context.Context, ids []string) (map[string]domain.Order, error) {
+	return r.reader(ctx).GetByIDs(ctx, ids)
+}
+
+func (r *ReadWriteRepository) GetByReference(ctx context.Context, reference string) (*domain.Order, error) {
+	return r.reader(ctx).GetByReference(ctx, reference)
+}
+
+func (r *ReadWriteRepository) Exists(ctx context.Context, id string) (bool, error) {
+	return r.reader(ctx).Exists(ctx, id)
+}
+
+func (r *ReadWriteRepository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
+	return r.reader(ctx).List(ctx, filter)
+}
+
+func (r *ReadWriteRepository) Count(ctx context.Context, filter ports.ListFilter) (int, error) {
+	return r.reader(ctx).Count(ctx, filter)
+}
+
+func (r *ReadWriteRepository) DistinctCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error) {
+	return r.reader(ctx).DistinctCustomers(ctx, filter)
+}
+
+func (r *ReadWriteRepository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
+	return r.primary.UpdateStatus(ctx, id, status)
+}
+
+func (r *ReadWriteRepository) AppendStatusHistory(ctx context.Context, id string, transition domain.StatusTransition) error {
+	return r.primary.AppendStatusHistory(ctx, id, transition)
+}
+
+func (r *ReadWriteRepository) GetStatusHistory(ctx context.Context, id string) ([]domain.StatusTransition, error) {
+	return r.reader(ctx).GetStatusHistory(ctx, id)
+}
@@ -0,0 +1,92 @@
+package adapters_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/orders/adapters"
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+func TestReadWriteRepository(t *testing.T) {
+	ctx := context.Background()
+	// The replica lags: it still has order-1 pending while the primary has completed it.
+	setup := func(t *testing.T) (primary, replica *memory.Repository) {
+		t.Helper()
+		primary, replica = memory.NewRepository(), memory.NewRepository()
+		if err := primary.Create(ctx, domain.Order{ID: "order-1", Status: domain.StatusCompleted}); err != nil {
+			t.Fatalf("failed to seed primary: %v", err)
+		}
+		if err := replica.Create(ctx, domain.Order{ID: "order-1", Status: domain.StatusPending}); err != nil {
+			t.Fatalf("failed to seed replica: %v", err)
+		}
+		return primary, replica
+	}
+	status := func(t *testing.T, repo ports.OrderRepository, ctx context.Context) domain.OrderStatus {
+		t.Helper()
+		order, err := repo.GetByID(ctx, "order-1")
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		return order.Status
+	}
+
+	t.Run("reads from the replica", func(t *testing.T) {
+		primary, replica := setup(t)
+		repo := adapters.NewReadWriteRepository(primary, replica)
+
+		if got := status(t, repo, ctx); got != domain.StatusPending {
+			t.Errorf("expected the replica status, got %s", got)
+		}
+		orders, err := repo.List(ctx, ports.ListFilter{})
+		if err != nil || len(orders) != 1 || orders[0].Status != domain.StatusPending {
+			t.Errorf("expected the replica's orders, got %+v %v", orders, err)
+		}
+	})
+
+	t.Run("reads from the primary when asked to", func(t *testing.T) {
+		primary, replica := setup(t)
+		repo := adapters.NewReadWriteRepository(primary, replica)
+
+		if got := status(t, repo, ports.WithPrimaryReads(ctx)); got != domain.StatusCompleted {
+			t.Errorf("expected the primary status, got %s", got)
+		}
+	})
+
+	t.Run("writes to the primary", func(t *testing.T) {
+		primary, replica := setup(t)
+		repo := adapters.NewReadWriteRepository(primary, replica)
+
+		if err := repo.Create(ctx, domain.Order{ID: "order-2", Status: domain.StatusPending}); err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+		if err := repo.UpdateStatus(ctx, "order-1", domain.StatusFailed); err != nil {
+			t.Fatalf("failed to update status: %v", err)
+		}
+
+		if _, err := primary.GetByID(ctx, "order-2"); err != nil {
+			t.Errorf("expected the created order on the primary, got %v", err)
+		}
+		if _, err := replica.GetByID(ctx, "order-2"); !errors.Is(err, ports.ErrNotFound) {
+			t.Errorf("expected the created order to be absent from the replica, got %v", err)
+		}
+		if got := status(t, primary, ctx); got != domain.StatusFailed {
+			t.Errorf("expected the primary to be updated, got %s", got)
+		}
+		if got := status(t, replica, ctx); got != domain.StatusPending {
+			t.Errorf("expected the replica to be untouched, got %s", got)
+		}
+	})
+
+	t.Run("falls back to the primary without a replica", func(t *testing.T) {
+		primary, _ := setup(t)
+		repo := adapters.NewReadWriteRepository(primary, nil)
+
+		if got := status(t, repo, ctx); got != domain.StatusCompleted {
+			t.Errorf("expected the primary status, got %s", got)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// Repository operation names accepted by WithOperationTimeout.
+const (
+	OperationCreate       = "create"
+	OperationGetByID      = "get_by_id"
+	OperationGetByIDs     = "get_by_ids"
+	OperationGetByRef     = "get_by_reference"
+	OperationExists       = "exists"
+	OperationList         = "list"
+	OperationCount        = "count"
+	OperationDistinct     = "distinct_customers"
+	OperationUpdateStatus = "update_status"
+
+	OperationAppendStatusHistory = "append_status_history"
+	OperationGetStatusHistory    = "get_status_history"
+)
+
+// TimeoutRepository bounds every repository call with a deadline so a slow query cannot
+// outlive the request. Calls that run out of time fail with ports.ErrQueryTimeout.
+type TimeoutRepository struct {
+	repo      ports.OrderRepository
+	timeout   time.Duration
+	overrides map[string]time.Duration
+}
+
+// TimeoutOption customizes a TimeoutRepository.
+type TimeoutOption func(*TimeoutRepository)
+
+// WithOperationTimeout overrides the default timeout for a single operation. A non-positive
+// timeout disables the deadline for that operation.
+func WithOperationTimeout(operation string, timeout time.Duration) TimeoutOption {
+	return func(r *TimeoutRepository) {
+		r.overrides[operation] = timeout
+	}
+}
+
+// NewTimeoutRepository wraps repo, applying timeout to each call. A non-positive timeout
+// disables the default deadline.
+func NewTimeoutRepository(repo ports.OrderRepository, timeout time.Duration, opts ...TimeoutOption) *TimeoutRepository {
+	r := &TimeoutRepository{
+		repo:      repo,
+		timeout:   timeout,
+		overrides: map[string]time.Duration{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *TimeoutRepository) Create(ctx context.Context, order domain.Order) error {
+	return r.run(ctx, OperationCreate, func(ctx context.Context) error {
+		return r.repo.Create(ctx, order)
+	})
+}
+
+func (r *TimeoutRepository) GetByID(ctx context.Context, id string) (*domain.Order, error) {
+	var order *domain.Order
+	err := r.run(ctx, OperationGetByID, func(ctx context.Context) error {
+		var err error
+		order, err = r.repo.GetByID(ctx, id)
+		return err
+	})
+	return order, err
+}
+
+func (r *TimeoutRepository) GetByIDs(ctx context.Context, ids []string) (map[string]domain.Order, error) {
+	var orders map[string]domain.Order
+	err := r.run(ctx, OperationGetByIDs, func(ctx context.Context) error {
+		var err error
+		orders, err = r.repo.GetByIDs(ctx, ids)
+		return err
+	})
+	return orders, err
+}
+
+func (r *TimeoutRepository) GetByReference(ctx context.Context, reference string) (*domain.Order, error) {
+	var order *domain.Order
+	err := r.run(ctx, OperationGetByRef, func(ctx context.Context) error {
+		var err error
+		order, err = r.repo.GetByReference(ctx, reference)
+		return err
+	})
+	return order, err
+}
+
+func (r *TimeoutRepository) Exists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := r.run(ctx, OperationExists, func(ctx context.Context) error {
+		var err error
+		exists, err = r.repo.Exists(ctx, id)
+		return err
+	})
+	return exists, err
+}
+
+func (r *TimeoutRepository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := r.run(ctx, OperationList, func(ctx context.Context) error {
+		var err error
+		orders, err = r.repo.List(ctx, filter)
+		return err
+	})
+	return orders, err
+}
+
+func (r *TimeoutRepository) Count(ctx context.Context, filter ports.ListFilter) (int, error) {
+	var count int
+	err := r.run(ctx, OperationCount, func(ctx context.Context) error {
+		var err error
+		count, err = r.repo.Count(ctx, filter)
+		return err
+	})
+	return count, err
+}
+
+func (r *TimeoutRepository) DistinctCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error) {
+	var emails []string
+	err := r.run(ctx, OperationDistinct, func(ctx context.Context) error {
+		var err error
+		emails, err = r.repo.DistinctCustomers(ctx, filter)
+		return err
+	})
+	return emails, err
+}
+
+func (r *TimeoutRepository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
+	return r.run(ctx, OperationUpdateStatus, func(ctx context.Context) error {
+		return r.repo.UpdateStatus(ctx, id, status)
+	})
+}
+
+func (r *TimeoutRepository) AppendStatusHistory(ctx context.Context, id string, transition domain.StatusTransition) error {
+	return r.run(ctx, OperationAppendStatusHistory, func(ctx context.Context) error {
+		return r.repo.AppendStatusHistory(ctx, id, transition)
+	})
+}
+
+func (r *TimeoutRepository) GetStatusHistory(ctx context.Context, id string) ([]domain.StatusTransition, error) {
+	var history []domain.StatusTransition
+	err := r.run(ctx, OperationGetStatusHistory, func(ctx context.Context) error {
+		var err error
+		history, err = r.repo.GetStatusHistory(ctx, id)
+		return err
+	})
+	return history, err
+}
+
+func (r *TimeoutRepository) run(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	timeout := r.timeout
+	if override, ok := r.overrides[operation]; ok {
+		timeout = override
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := fn(ctx)
+	if err != nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+		return fmt.Errorf("%w: %s: %w", ports.ErrQueryTimeout, operation, err)
+	}
+	return err
+}
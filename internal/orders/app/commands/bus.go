@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownCommand is returned when no handler is registered for a dispatched command.
+var ErrUnknownCommand = errors.New("no handler registered for command")
+
+// Command is implemented by every command dispatched through the CommandBus.
+type Command interface {
+	CommandName() string
+}
+
+// HandlerFunc handles a single command type.
+type HandlerFunc func(ctx context.Context, cmd Command) (any, error)
+
+// Middleware decorates every handler registered on a CommandBus.
+type Middleware func(name string, next HandlerFunc) HandlerFunc
+
+// Handle adapts a strongly typed handler into a HandlerFunc.
+func Handle[C Command, R any](fn func(ctx context.Context, cmd C) (R, error)) HandlerFunc {
+	return func(ctx context.Context, cmd Command) (any, error) {
+		typed, ok := cmd.(C)
+		if !ok {
+			return nil, fmt.Errorf("unexpected command type %T", cmd)
+		}
+		return fn(ctx, typed)
+	}
+}
+
+// CommandBus dispatches commands to handlers registered by command name.
+type CommandBus struct {
+	mu         sync.RWMutex
+	handlers   map[string]HandlerFunc
+	middleware []Middleware
+}
+
+// NewCommandBus constructs a CommandBus. Middleware is applied to every handler, the
+// first one being the outermost.
+func NewCommandBus(middleware ...Middleware) *CommandBus {
+	return &CommandBus{
+		handlers:   make(map[string]HandlerFunc),
+		middleware: middleware,
+	}
+}
+
+// Register binds a handler to the named command, replacing any previous handler.
+func (b *CommandBus) Register(name string, handler HandlerFunc) {
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		handler = b.middleware[i](name, handler)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = handler
+}
+
+// Dispatch routes cmd to its registered handler.
+func (b *CommandBus) Dispatch(ctx context.Context, cmd Command) (any, error) {
+	b.mu.RLock()
+	handler, ok := b.handlers[cmd.CommandName()]
+	b.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCommand, cmd.CommandName())
+	}
+
+	return handler(ctx, cmd)
+}
@@ -0,0 +1,89 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/orders/app/commands"
+)
+
+type pingCommand struct {
+	Value string
+}
+
+func (pingCommand) CommandName() string { return "PingCommand" }
+
+func TestDispatchCommand(t *testing.T) {
+	t.Run("routes command to registered handler", func(t *testing.T) {
+		bus := commands.NewCommandBus()
+		bus.Register("PingCommand", commands.Handle(func(ctx context.Context, cmd pingCommand) (string, error) {
+			return "pong:" + cmd.Value, nil
+		}))
+
+		result, err := bus.Dispatch(context.Background(), pingCommand{Value: "a"})
+
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if result != "pong:a" {
+			t.Errorf("expected result %q, got %v", "pong:a", result)
+		}
+	})
+
+	t.Run("returns unknown command error when no handler is registered", func(t *testing.T) {
+		bus := commands.NewCommandBus()
+
+		_, err := bus.Dispatch(context.Background(), pingCommand{})
+
+		if !errors.Is(err, commands.ErrUnknownCommand) {
+			t.Errorf("expected ErrUnknownCommand, got %v", err)
+		}
+	})
+
+	t.Run("applies middleware in registration order", func(t *testing.T) {
+		var calls []string
+		trace := func(label string) commands.Middleware {
+			return func(name string, next commands.HandlerFunc) commands.HandlerFunc {
+				return func(ctx context.Context, cmd commands.Command) (any, error) {
+					calls = append(calls, label+":"+name)
+					return next(ctx, cmd)
+				}
+			}
+		}
+
+		bus := commands.NewCommandBus(trace("outer"), trace("inner"))
+		bus.Register("PingCommand", commands.Handle(func(ctx context.Context, cmd pingCommand) (string, error) {
+			calls = append(calls, "handler")
+			return "", nil
+		}))
+
+		if _, err := bus.Dispatch(context.Background(), pingCommand{}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		want := []string{"outer:PingCommand", "inner:PingCommand", "handler"}
+		if len(calls) != len(want) {
+			t.Fatalf("expected calls %v, got %v", want, calls)
+		}
+		for i := range want {
+			if calls[i] != want[i] {
+				t.Errorf("expected call %d to be %q, got %q", i, want[i], calls[i])
+			}
+		}
+	})
+
+	t.Run("propagates handler errors", func(t *testing.T) {
+		handlerErr := errors.New("boom")
+		bus := commands.NewCommandBus()
+		bus.Register("PingCommand", commands.Handle(func(ctx context.Context, cmd pingCommand) (string, error) {
+			return "", handlerErr
+		}))
+
+		_, err := bus.Dispatch(context.Background(), pingCommand{})
+
+		if !errors.Is(err, handlerErr) {
+			t.Errorf("expected handler error, got %v", err)
+		}
+	})
+}
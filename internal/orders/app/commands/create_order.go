@@ -2,33 +2,56 @@ package commands
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/metrics"
 	"github.com/dejobratic/tbd/internal/orders/ports"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Phases of order creation reported as span events and phase duration metrics.
+const (
+	PhaseValidate = "validate"
+	PhasePersist  = "persist"
+	PhasePublish  = "publish"
 )
 
 type CreateOrderCommand struct {
+	CustomerID    string
 	CustomerEmail string
-	AmountCents   int64
+	Amount        domain.Money
+	Metadata      map[string]string
+	// IdempotencyKey is recorded on the order when the request carried one.
+	IdempotencyKey string
+	// DryRun validates the order and returns it without an ID or reference, skipping
+	// persistence and event publishing.
+	DryRun bool
+}
+
+func (c CreateOrderCommand) CommandName() string {
+	return "CreateOrderCommand"
 }
 
-func (c CreateOrderCommand) Validate() error {
-	if strings.TrimSpace(c.CustomerEmail) == "" {
-		return errors.New("customer_email is required")
+func (c CreateOrderCommand) Validate(opts ...domain.ValidateOption) error {
+	if strings.TrimSpace(c.CustomerID) == "" {
+		return domain.NewValidationError("customer_id is required")
 	}
-	if !strings.Contains(c.CustomerEmail, "@") {
-		return errors.New("customer_email must be valid")
+	if err := domain.ValidateEmail(c.CustomerEmail, opts...); err != nil {
+		return err
 	}
-	if c.AmountCents <= 0 {
-		return errors.New("amount_cents must be positive")
+	if err := domain.ValidateAmount(c.Amount.AmountCents, opts...); err != nil {
+		return err
 	}
-	return nil
+	if err := domain.ValidateCurrency(c.Amount.Currency); err != nil {
+		return err
+	}
+	return domain.ValidateMetadata(c.Metadata)
 }
 
 type CommandHandler interface {
@@ -36,58 +59,186 @@ type CommandHandler interface {
 }
 
 type CreateOrderCommandHandler struct {
-	repo   ports.OrderRepository
-	events ports.EventBus
+	repo           ports.OrderRepository
+	events         ports.EventBus
+	blockedDomains domain.EmailDomainBlocklist
+	references     ports.ReferenceSequence
+	ids            IDGenerator
+	validateOpts   []domain.ValidateOption
+	metrics        *metrics.Metrics
+	clock          ports.Clock
+	transitions    ports.TransitionRecorder
+}
+
+// CreateOrderOption customizes a CreateOrderCommandHandler.
+type CreateOrderOption func(*CreateOrderCommandHandler)
+
+// WithClock sets the clock CreatedAt and UpdatedAt are taken from.
+func WithClock(clock ports.Clock) CreateOrderOption {
+	return func(h *CreateOrderCommandHandler) {
+		h.clock = clock
+	}
+}
+
+// WithBlockedEmailDomains rejects orders whose customer email uses one of the given domains.
+func WithBlockedEmailDomains(domains []string) CreateOrderOption {
+	return func(h *CreateOrderCommandHandler) {
+		h.blockedDomains = domain.NewEmailDomainBlocklist(domains)
+	}
+}
+
+// WithReferenceSequence sets the sequence order references are numbered from. Without it
+// references come from a process-local counter, which is only unique for a single instance.
+func WithReferenceSequence(references ports.ReferenceSequence) CreateOrderOption {
+	return func(h *CreateOrderCommandHandler) {
+		h.references = references
+	}
+}
+
+// WithMaxEmailLength rejects customer emails longer than length characters. The default is
+// domain.DefaultMaxEmailLength.
+func WithMaxEmailLength(length int) CreateOrderOption {
+	return func(h *CreateOrderCommandHandler) {
+		h.validateOpts = append(h.validateOpts, domain.MaxEmailLength(length))
+	}
+}
+
+// WithIDGenerator sets how order IDs are generated. The default is a 32-character hex string.
+func WithIDGenerator(ids IDGenerator) CreateOrderOption {
+	return func(h *CreateOrderCommandHandler) {
+		h.ids = ids
+	}
+}
+
+// WithAllowZeroAmount accepts free orders with amount_cents == 0. Negative amounts are
+// always rejected.
+func WithAllowZeroAmount(allow bool) CreateOrderOption {
+	return func(h *CreateOrderCommandHandler) {
+		h.validateOpts = append(h.validateOpts, domain.AllowZeroAmount(allow))
+	}
+}
+
+// WithTransitionRecorder reports every created order to recorder. A recording failure
+// fails the command, although the order is saved and its event published.
+func WithTransitionRecorder(recorder ports.TransitionRecorder) CreateOrderOption {
+	return func(h *CreateOrderCommandHandler) {
+		h.transitions = recorder
+	}
+}
+
+// WithPhaseMetrics records the duration of each creation phase on m.
+func WithPhaseMetrics(m *metrics.Metrics) CreateOrderOption {
+	return func(h *CreateOrderCommandHandler) {
+		h.metrics = m
+	}
 }
 
 func NewCreateOrderCommandHandler(
 	repo ports.OrderRepository,
 	events ports.EventBus,
+	opts ...CreateOrderOption,
 ) *CreateOrderCommandHandler {
-	return &CreateOrderCommandHandler{
-		repo:   repo,
-		events: events,
+	h := &CreateOrderCommandHandler{
+		repo:       repo,
+		events:     events,
+		references: &localReferenceSequence{},
+		ids:        IDGeneratorFunc(generateHexID),
+		clock:      ports.SystemClock{},
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ValidateOptions returns the options orders are validated with on creation.
+func (h *CreateOrderCommandHandler) ValidateOptions() []domain.ValidateOption {
+	return h.validateOpts
 }
 
 func (h *CreateOrderCommandHandler) Handle(ctx context.Context, cmd CreateOrderCommand) (*domain.Order, error) {
-	if err := cmd.Validate(); err != nil {
+	start := time.Now()
+	if err := cmd.Validate(h.validateOpts...); err != nil {
 		return nil, err
 	}
 
-	orderID, err := generateOrderID()
-	if err != nil {
+	if err := h.blockedDomains.Check(cmd.CustomerEmail); err != nil {
 		return nil, err
 	}
+	h.endPhase(ctx, PhaseValidate, start)
 
+	now := h.clock.Now()
 	order := domain.Order{
-		ID:            orderID,
-		CustomerEmail: cmd.CustomerEmail,
-		AmountCents:   cmd.AmountCents,
-		Status:        domain.StatusPending,
-		CreatedAt:     time.Now().UTC(),
-		UpdatedAt:     time.Now().UTC(),
+		CustomerID:     cmd.CustomerID,
+		CustomerEmail:  cmd.CustomerEmail,
+		Amount:         cmd.Amount,
+		Status:         domain.StatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Metadata:       cmd.Metadata,
+		IdempotencyKey: cmd.IdempotencyKey,
+	}
+
+	if err := order.Validate(h.validateOpts...); err != nil {
+		return nil, err
+	}
+
+	if cmd.DryRun {
+		return &order, nil
 	}
 
-	if err := order.Validate(); err != nil {
+	orderID, err := h.ids.NewID()
+	if err != nil {
 		return nil, err
 	}
 
+	seq, err := h.references.NextReference(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order.ID = orderID
+	order.Reference = domain.FormatReference(now.Year(), seq)
+
+	start = time.Now()
 	if err := h.repo.Create(ctx, order); err != nil {
 		return nil, err
 	}
+	h.endPhase(ctx, PhasePersist, start)
 
-	if err := h.events.PublishOrderCreated(ctx, order.ID); err != nil {
-		return &order, fmt.Errorf("order saved but failed to publish event: %w", err)
+	var recordErr error
+	if h.transitions != nil {
+		if err := h.transitions.RecordTransition(ctx, order.ID, "", order.Status); err != nil {
+			recordErr = fmt.Errorf("order saved but failed to record transition: %w", err)
+		}
 	}
 
-	return &order, nil
+	start = time.Now()
+	err = h.events.PublishOrderCreated(ctx, order.ID)
+	h.endPhase(ctx, PhasePublish, start)
+	if err != nil {
+		return &order, errors.Join(fmt.Errorf("order saved but failed to publish event: %w", err), recordErr)
+	}
+
+	return &order, recordErr
 }
 
-func generateOrderID() (string, error) {
-	buf := make([]byte, 16)
-	if _, err := rand.Read(buf); err != nil {
-		return "", fmt.Errorf("generate order id: %w", err)
+// endPhase marks the end of a creation phase on the current span and records its duration.
+func (h *CreateOrderCommandHandler) endPhase(ctx context.Context, phase string, start time.Time) {
+	elapsed := time.Since(start)
+	trace.SpanFromContext(ctx).AddEvent("order.create."+phase, trace.WithAttributes(
+		attribute.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	))
+	if h.metrics != nil {
+		h.metrics.RecordOrderCreationPhase(ctx, phase, elapsed.Seconds())
 	}
-	return hex.EncodeToString(buf), nil
+}
+
+// localReferenceSequence numbers references within a single process.
+type localReferenceSequence struct {
+	last atomic.Int64
+}
+
+func (s *localReferenceSequence) NextReference(_ context.Context) (int64, error) {
+	return s.last.Add(1), nil
 }
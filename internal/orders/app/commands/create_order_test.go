@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/dejobratic/tbd/internal/kafka"
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
 	"github.com/dejobratic/tbd/internal/orders/app/commands"
 	"github.com/dejobratic/tbd/internal/orders/domain"
 	"github.com/dejobratic/tbd/internal/orders/ports"
+	"github.com/dejobratic/tbd/internal/orders/ports/portstest"
 )
 
 type mockRepository struct {
@@ -25,42 +29,52 @@ func (m *mockRepository) GetByID(ctx context.Context, id string) (*domain.Order,
 	return nil, nil
 }
 
+func (m *mockRepository) GetByIDs(ctx context.Context, ids []string) (map[string]domain.Order, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) GetByReference(ctx context.Context, reference string) (*domain.Order, error) {
+	return nil, nil
+}
+
+func (m *mockRepository) Exists(ctx context.Context, id string) (bool, error) {
+	return false, nil
+}
+
 func (m *mockRepository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
 	return nil, nil
 }
 
-func (m *mockRepository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
-	return nil
+func (m *mockRepository) Count(ctx context.Context, filter ports.ListFilter) (int, error) {
+	return 0, nil
 }
 
-type mockEventBus struct {
-	publishOrderCreatedFn func(ctx context.Context, orderID string) error
+func (m *mockRepository) DistinctCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error) {
+	return nil, nil
 }
 
-func (m *mockEventBus) PublishOrderCreated(ctx context.Context, orderID string) error {
-	if m.publishOrderCreatedFn != nil {
-		return m.publishOrderCreatedFn(ctx, orderID)
-	}
+func (m *mockRepository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
 	return nil
 }
 
-func (m *mockEventBus) PublishOrderProcessed(ctx context.Context, orderID string) error {
+func (m *mockRepository) AppendStatusHistory(ctx context.Context, id string, transition domain.StatusTransition) error {
 	return nil
 }
 
-func (m *mockEventBus) PublishOrderFailed(ctx context.Context, orderID string, reason string) error {
-	return nil
+func (m *mockRepository) GetStatusHistory(ctx context.Context, id string) ([]domain.StatusTransition, error) {
+	return nil, nil
 }
 
 func TestCreateOrder(t *testing.T) {
 	t.Run("creates pending order with valid input", func(t *testing.T) {
 		repo := &mockRepository{}
-		events := &mockEventBus{}
+		events := kafka.NewSpyEventBus()
 		handler := commands.NewCreateOrderCommandHandler(repo, events)
 
 		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
 			CustomerEmail: "test@example.com",
-			AmountCents:   1000,
+			Amount:        domain.NewMoney(1000, "USD"),
 		}
 
 		order, err := handler.Handle(context.Background(), cmd)
@@ -77,8 +91,8 @@ func TestCreateOrder(t *testing.T) {
 			t.Errorf("expected customer email %s, got %s", cmd.CustomerEmail, order.CustomerEmail)
 		}
 
-		if order.AmountCents != cmd.AmountCents {
-			t.Errorf("expected amount %d, got %d", cmd.AmountCents, order.AmountCents)
+		if order.Amount.AmountCents != cmd.Amount.AmountCents {
+			t.Errorf("expected amount %d, got %d", cmd.Amount.AmountCents, order.Amount.AmountCents)
 		}
 
 		if order.Status != domain.StatusPending {
@@ -88,16 +102,34 @@ func TestCreateOrder(t *testing.T) {
 		if order.ID == "" {
 			t.Error("expected order ID to be generated")
 		}
+
+		if published := events.PublishedCreated(); len(published) != 1 || published[0] != order.ID {
+			t.Errorf("expected order.created for %s, got %v", order.ID, published)
+		}
+	})
+
+	t.Run("returns validation error when customer id is empty", func(t *testing.T) {
+		handler := commands.NewCreateOrderCommandHandler(&mockRepository{}, kafka.NewSpyEventBus())
+
+		_, err := handler.Handle(context.Background(), commands.CreateOrderCommand{
+			CustomerEmail: "test@example.com",
+			Amount:        domain.NewMoney(1000, "USD"),
+		})
+
+		if err == nil || err.Error() != "customer_id is required" {
+			t.Errorf("expected error %q, got %v", "customer_id is required", err)
+		}
 	})
 
 	t.Run("returns validation error when email is empty", func(t *testing.T) {
 		repo := &mockRepository{}
-		events := &mockEventBus{}
+		events := kafka.NewSpyEventBus()
 		handler := commands.NewCreateOrderCommandHandler(repo, events)
 
 		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
 			CustomerEmail: "",
-			AmountCents:   1000,
+			Amount:        domain.NewMoney(1000, "USD"),
 		}
 
 		order, err := handler.Handle(context.Background(), cmd)
@@ -117,12 +149,13 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("returns validation error when email is invalid", func(t *testing.T) {
 		repo := &mockRepository{}
-		events := &mockEventBus{}
+		events := kafka.NewSpyEventBus()
 		handler := commands.NewCreateOrderCommandHandler(repo, events)
 
 		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
 			CustomerEmail: "invalid-email",
-			AmountCents:   1000,
+			Amount:        domain.NewMoney(1000, "USD"),
 		}
 
 		order, err := handler.Handle(context.Background(), cmd)
@@ -142,12 +175,13 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("returns validation error when amount is zero", func(t *testing.T) {
 		repo := &mockRepository{}
-		events := &mockEventBus{}
+		events := kafka.NewSpyEventBus()
 		handler := commands.NewCreateOrderCommandHandler(repo, events)
 
 		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
 			CustomerEmail: "test@example.com",
-			AmountCents:   0,
+			Amount:        domain.NewMoney(0, "USD"),
 		}
 
 		order, err := handler.Handle(context.Background(), cmd)
@@ -167,12 +201,13 @@ func TestCreateOrder(t *testing.T) {
 
 	t.Run("returns validation error when amount is negative", func(t *testing.T) {
 		repo := &mockRepository{}
-		events := &mockEventBus{}
+		events := kafka.NewSpyEventBus()
 		handler := commands.NewCreateOrderCommandHandler(repo, events)
 
 		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
 			CustomerEmail: "test@example.com",
-			AmountCents:   -100,
+			Amount:        domain.NewMoney(-100, "USD"),
 		}
 
 		order, err := handler.Handle(context.Background(), cmd)
@@ -197,12 +232,13 @@ func TestCreateOrder(t *testing.T) {
 				return repoErr
 			},
 		}
-		events := &mockEventBus{}
+		events := kafka.NewSpyEventBus()
 		handler := commands.NewCreateOrderCommandHandler(repo, events)
 
 		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
 			CustomerEmail: "test@example.com",
-			AmountCents:   1000,
+			Amount:        domain.NewMoney(1000, "USD"),
 		}
 
 		order, err := handler.Handle(context.Background(), cmd)
@@ -223,16 +259,14 @@ func TestCreateOrder(t *testing.T) {
 	t.Run("returns order even when event publishing fails", func(t *testing.T) {
 		eventErr := errors.New("kafka unavailable")
 		repo := &mockRepository{}
-		events := &mockEventBus{
-			publishOrderCreatedFn: func(ctx context.Context, orderID string) error {
-				return eventErr
-			},
-		}
+		events := kafka.NewSpyEventBus()
+		events.FailOn(kafka.EventOrderCreated, eventErr)
 		handler := commands.NewCreateOrderCommandHandler(repo, events)
 
 		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
 			CustomerEmail: "test@example.com",
-			AmountCents:   1000,
+			Amount:        domain.NewMoney(1000, "USD"),
 		}
 
 		order, err := handler.Handle(context.Background(), cmd)
@@ -249,4 +283,187 @@ func TestCreateOrder(t *testing.T) {
 			t.Errorf("expected customer email %s, got %s", cmd.CustomerEmail, order.CustomerEmail)
 		}
 	})
+
+	t.Run("returns blocked domain error when email domain is blocklisted", func(t *testing.T) {
+		repo := &mockRepository{}
+		events := kafka.NewSpyEventBus()
+		handler := commands.NewCreateOrderCommandHandler(repo, events,
+			commands.WithBlockedEmailDomains([]string{"mailinator.com"}),
+		)
+
+		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
+			CustomerEmail: "test@MailInator.COM",
+			Amount:        domain.NewMoney(1000, "USD"),
+		}
+
+		order, err := handler.Handle(context.Background(), cmd)
+
+		if !errors.Is(err, domain.ErrBlockedEmailDomain) {
+			t.Errorf("expected ErrBlockedEmailDomain, got %v", err)
+		}
+
+		if order != nil {
+			t.Errorf("expected nil order, got %+v", order)
+		}
+	})
+
+	t.Run("creates order when blocklisted domain appears only in local part", func(t *testing.T) {
+		repo := &mockRepository{}
+		events := kafka.NewSpyEventBus()
+		handler := commands.NewCreateOrderCommandHandler(repo, events,
+			commands.WithBlockedEmailDomains([]string{"mailinator.com"}),
+		)
+
+		cmd := commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
+			CustomerEmail: "mailinator.com@example.com",
+			Amount:        domain.NewMoney(1000, "USD"),
+		}
+
+		if _, err := handler.Handle(context.Background(), cmd); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("stamps the order with the injected clock", func(t *testing.T) {
+		now := time.Date(2025, 3, 14, 9, 26, 53, 0, time.UTC)
+		handler := commands.NewCreateOrderCommandHandler(&mockRepository{}, kafka.NewSpyEventBus(),
+			commands.WithClock(portstest.NewFakeClock(now)),
+		)
+
+		order, err := handler.Handle(context.Background(), commands.CreateOrderCommand{
+			CustomerID:    "customer-1",
+			CustomerEmail: "test@example.com",
+			Amount:        domain.NewMoney(1000, "USD"),
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if !order.CreatedAt.Equal(now) || !order.UpdatedAt.Equal(now) {
+			t.Errorf("expected timestamps %v, got created %v updated %v", now, order.CreatedAt, order.UpdatedAt)
+		}
+	})
+
+	t.Run("records the idempotency key on the order", func(t *testing.T) {
+		repo := &mockRepository{}
+		handler := commands.NewCreateOrderCommandHandler(repo, kafka.NewSpyEventBus())
+
+		cmd := commands.CreateOrderCommand{
+			CustomerID:     "customer-1",
+			CustomerEmail:  "test@example.com",
+			Amount:         domain.NewMoney(1000, "USD"),
+			IdempotencyKey: "key-1",
+		}
+
+		order, err := handler.Handle(context.Background(), cmd)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if got := order.IdempotencyKey; got != "key-1" {
+			t.Errorf("expected idempotency key key-1, got %q", got)
+		}
+	})
+
+	t.Run("dry run validates without persisting or publishing", func(t *testing.T) {
+		created := false
+		repo := &mockRepository{createFn: func(context.Context, domain.Order) error {
+			created = true
+			return nil
+		}}
+		events := kafka.NewSpyEventBus()
+		handler := commands.NewCreateOrderCommandHandler(repo, events)
+		cmd := commands.CreateOrderCommand{CustomerID: "customer-1", CustomerEmail: "test@example.com", Amount: domain.NewMoney(1000, "USD"), DryRun: true}
+
+		order, err := handler.Handle(context.Background(), cmd)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		if order.ID != "" || order.Reference != "" {
+			t.Errorf("expected no ID or reference, got %q / %q", order.ID, order.Reference)
+		}
+		if order.Status != domain.StatusPending {
+			t.Errorf("expected status %s, got %s", domain.StatusPending, order.Status)
+		}
+		if created {
+			t.Error("expected repository Create not to be called")
+		}
+		if published := events.PublishedCreated(); len(published) != 0 {
+			t.Errorf("expected no events, got %v", published)
+		}
+	})
+
+	t.Run("dry run still rejects invalid orders", func(t *testing.T) {
+		handler := commands.NewCreateOrderCommandHandler(&mockRepository{}, kafka.NewSpyEventBus())
+		cmd := commands.CreateOrderCommand{CustomerID: "customer-1", CustomerEmail: "invalid", Amount: domain.NewMoney(1000, "USD"), DryRun: true}
+
+		_, err := handler.Handle(context.Background(), cmd)
+
+		if !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("expected validation error, got: %v", err)
+		}
+	})
+
+	t.Run("assigns sequential references", func(t *testing.T) {
+		repo := &mockRepository{}
+		handler := commands.NewCreateOrderCommandHandler(repo, kafka.NewSpyEventBus(),
+			commands.WithReferenceSequence(memory.NewReferenceSequence()),
+		)
+		cmd := commands.CreateOrderCommand{CustomerID: "customer-1", CustomerEmail: "test@example.com", Amount: domain.NewMoney(1000, "USD")}
+
+		first, err := handler.Handle(context.Background(), cmd)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		second, err := handler.Handle(context.Background(), cmd)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+
+		year := first.CreatedAt.Year()
+		if want := domain.FormatReference(year, 1); first.Reference != want {
+			t.Errorf("expected reference %s, got %s", want, first.Reference)
+		}
+		if want := domain.FormatReference(year, 2); second.Reference != want {
+			t.Errorf("expected reference %s, got %s", want, second.Reference)
+		}
+	})
+}
+
+func TestCreateOrderAllowZeroAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowZero bool
+		amount    int64
+		wantErr   bool
+	}{
+		{"zero rejected by default", false, 0, true},
+		{"negative rejected by default", false, -100, true},
+		{"positive accepted by default", false, 100, false},
+		{"zero accepted when allowed", true, 0, false},
+		{"negative rejected when zero allowed", true, -100, true},
+		{"positive accepted when zero allowed", true, 100, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := commands.NewCreateOrderCommandHandler(&mockRepository{}, kafka.NewSpyEventBus(),
+				commands.WithAllowZeroAmount(tt.allowZero),
+			)
+
+			_, err := handler.Handle(context.Background(), commands.CreateOrderCommand{
+				CustomerID:    "customer-1",
+				CustomerEmail: "test@example.com",
+				Amount:        domain.NewMoney(tt.amount, "USD"),
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, domain.ErrValidation) {
+				t.Errorf("expected ErrValidation, got %v", err)
+			}
+		})
+	}
 }
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Order ID strategies accepted by NewIDGenerator.
+const (
+	IDStrategyHex    = "hex"
+	IDStrategyUUIDv4 = "uuidv4"
+	IDStrategyUUIDv7 = "uuidv7"
+)
+
+// IDGenerator produces IDs for new orders.
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+// IDGeneratorFunc adapts a function to IDGenerator.
+type IDGeneratorFunc func() (string, error)
+
+func (f IDGeneratorFunc) NewID() (string, error) {
+	return f()
+}
+
+// NewIDGenerator returns the generator for a strategy name. An empty name selects hex.
+func NewIDGenerator(strategy string) (IDGenerator, error) {
+	switch strategy {
+	case "", IDStrategyHex:
+		return IDGeneratorFunc(generateHexID), nil
+	case IDStrategyUUIDv4:
+		return IDGeneratorFunc(generateUUIDv4), nil
+	case IDStrategyUUIDv7:
+		return IDGeneratorFunc(generateUUIDv7), nil
+	default:
+		return nil, fmt.Errorf("unsupported order id strategy %q", strategy)
+	}
+}
+
+// generateHexID returns 16 random bytes as a 32-character hex string.
+func generateHexID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate order id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func generateUUIDv4() (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("generate order id: %w", err)
+	}
+	return id.String(), nil
+}
+
+// generateUUIDv7 returns a time-ordered UUID, which keeps new rows close together in the
+// primary key index.
+func generateUUIDv7() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", fmt.Errorf("generate order id: %w", err)
+	}
+	return id.String(), nil
+}
@@ -0,0 +1,55 @@
+package commands_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/orders/app/commands"
+)
+
+func TestNewIDGenerator(t *testing.T) {
+	tests := []struct {
+		strategy string
+		pattern  string
+	}{
+		{strategy: "", pattern: `^[0-9a-f]{32}$`},
+		{strategy: commands.IDStrategyHex, pattern: `^[0-9a-f]{32}$`},
+		{strategy: commands.IDStrategyUUIDv4, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{strategy: commands.IDStrategyUUIDv7, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+	}
+
+	for _, tt := range tests {
+		t.Run("generates "+tt.strategy+" ids", func(t *testing.T) {
+			generator, err := commands.NewIDGenerator(tt.strategy)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			id, err := generator.NewID()
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !regexp.MustCompile(tt.pattern).MatchString(id) {
+				t.Errorf("id %q does not match %s", id, tt.pattern)
+			}
+		})
+	}
+
+	t.Run("uuidv7 ids sort by creation time", func(t *testing.T) {
+		generator, _ := commands.NewIDGenerator(commands.IDStrategyUUIDv7)
+		previous, _ := generator.NewID()
+		for range 100 {
+			next, _ := generator.NewID()
+			if next <= previous {
+				t.Fatalf("expected %q to sort after %q", next, previous)
+			}
+			previous = next
+		}
+	})
+
+	t.Run("rejects unknown strategies", func(t *testing.T) {
+		if _, err := commands.NewIDGenerator("ulid"); err == nil {
+			t.Error("expected error for unknown strategy")
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ScopeName is the instrumentation scope of command spans.
+const ScopeName = "github.com/dejobratic/tbd/internal/orders/app/commands"
+
+var tracer = telemetry.NewTracer(ScopeName)
+
+// NewObservableMiddleware traces and logs every command dispatched through a CommandBus.
+func NewObservableMiddleware(logger *slog.Logger) Middleware {
+	return func(name string, next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			ctx, span := tracer.StartSpan(ctx, name+".Handle")
+			defer span.End()
+
+			telemetry.AddSpanAttributes(span, attribute.String("command.name", name))
+
+			start := time.Now()
+			result, err := next(ctx, cmd)
+			duration := time.Since(start)
+
+			if err != nil {
+				telemetry.RecordSpanError(span, err)
+				logger.ErrorContext(ctx, "command failed",
+					"command", name,
+					"duration", duration,
+					"error", err,
+				)
+				return result, err
+			}
+
+			logger.DebugContext(ctx, "command handled",
+				"command", name,
+				"duration", duration,
+			)
+			telemetry.SetSpanSuccess(span)
+
+			return result, nil
+		}
+	}
+}
@@ -9,8 +9,11 @@ import (
 	"github.com/dejobratic/tbd/internal/orders/metrics"
 	"github.com/dejobratic/tbd/internal/telemetry"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ObservableCommandHandler records business metrics and order details for create order
+// commands. Tracing and failure logging are provided by the CommandBus middleware.
 type ObservableCommandHandler struct {
 	handler CommandHandler
 	logger  *slog.Logger
@@ -26,8 +29,12 @@ func NewObservableCommandHandler(handler CommandHandler, logger *slog.Logger, me
 }
 
 func (o *ObservableCommandHandler) Handle(ctx context.Context, cmd CreateOrderCommand) (*domain.Order, error) {
-	ctx, span := telemetry.StartSpan(ctx, "CreateOrderCommand.Handle")
-	defer span.End()
+	span := trace.SpanFromContext(ctx)
+
+	if cmd.DryRun {
+		telemetry.AddSpanAttributes(span, attribute.Bool("order.dry_run", true))
+		return o.handler.Handle(ctx, cmd)
+	}
 
 	start := time.Now()
 	var success bool
@@ -39,24 +46,21 @@ func (o *ObservableCommandHandler) Handle(ctx context.Context, cmd CreateOrderCo
 
 	o.logger.InfoContext(ctx, "creating order",
 		"customer_email", cmd.CustomerEmail,
-		"amount_cents", cmd.AmountCents,
+		"amount_cents", cmd.Amount.AmountCents,
+		"currency", cmd.Amount.Currency,
 	)
 
 	order, err := o.handler.Handle(ctx, cmd)
 
 	if err != nil {
-		telemetry.RecordSpanError(span, err)
-		o.logger.ErrorContext(ctx, "failed to create order",
-			"error", err,
-			"customer_email", cmd.CustomerEmail,
-		)
 		return nil, err
 	}
 
 	telemetry.AddSpanAttributes(span,
 		attribute.String("order.id", order.ID),
 		attribute.String("order.customer_email", order.CustomerEmail),
-		attribute.Int64("order.amount_cents", order.AmountCents),
+		attribute.Int64("order.amount_cents", order.Amount.AmountCents),
+		attribute.String("order.currency", order.Amount.Currency),
 		attribute.String("order.status", string(order.Status)),
 	)
 
@@ -66,7 +70,6 @@ func (o *ObservableCommandHandler) Handle(ctx context.Context, cmd CreateOrderCo
 	)
 
 	success = true
-	telemetry.SetSpanSuccess(span)
 
 	return order, nil
 }
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// ErrStatusUnchanged is returned when the order already has the requested status.
+var ErrStatusUnchanged = errors.New("order already has the requested status")
+
+// UpdateOrderStatusCommand moves an order to a new status. Reason is published with
+// order.failed events.
+type UpdateOrderStatusCommand struct {
+	OrderID string
+	Status  domain.OrderStatus
+	Reason  string
+}
+
+func (c UpdateOrderStatusCommand) CommandName() string {
+	return "UpdateOrderStatusCommand"
+}
+
+func (c UpdateOrderStatusCommand) Validate() error {
+	if strings.TrimSpace(c.OrderID) == "" {
+		return domain.NewValidationError("order_id is required")
+	}
+	if !c.Status.IsValid() {
+		return domain.NewValidationError(fmt.Sprintf("status %q is not valid", c.Status))
+	}
+	return nil
+}
+
+type UpdateOrderStatusCommandHandler struct {
+	repo        ports.OrderRepository
+	events      ports.EventBus
+	clock       ports.Clock
+	transitions ports.TransitionRecorder
+}
+
+// UpdateOrderStatusOption customizes an UpdateOrderStatusCommandHandler.
+type UpdateOrderStatusOption func(*UpdateOrderStatusCommandHandler)
+
+// WithStatusClock sets the clock the returned order's UpdatedAt is taken from.
+func WithStatusClock(clock ports.Clock) UpdateOrderStatusOption {
+	return func(h *UpdateOrderStatusCommandHandler) {
+		h.clock = clock
+	}
+}
+
+// WithStatusTransitionRecorder reports every status change to recorder, from the status the
+// transition was checked against. A recording failure fails the command, although the
+// status is changed and its event published.
+func WithStatusTransitionRecorder(recorder ports.TransitionRecorder) UpdateOrderStatusOption {
+	return func(h *UpdateOrderStatusCommandHandler) {
+		h.transitions = recorder
+	}
+}
+
+func NewUpdateOrderStatusCommandHandler(repo ports.OrderRepository, events ports.EventBus, opts ...UpdateOrderStatusOption) *UpdateOrderStatusCommandHandler {
+	h := &UpdateOrderStatusCommandHandler{
+		repo:   repo,
+		events: events,
+		clock:  ports.SystemClock{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *UpdateOrderStatusCommandHandler) Handle(ctx context.Context, cmd UpdateOrderStatusCommand) (*domain.Order, error) {
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	order, err := h.repo.GetByID(ports.WithPrimaryReads(ctx), cmd.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status == cmd.Status {
+		return order, ErrStatusUnchanged
+	}
+
+	if !order.CanTransitionTo(cmd.Status) {
+		return order, fmt.Errorf("%w: %s to %s", domain.ErrInvalidTransition, order.Status, cmd.Status)
+	}
+
+	if err := h.repo.UpdateStatus(ctx, order.ID, cmd.Status); err != nil {
+		return nil, err
+	}
+
+	var recordErr error
+	if h.transitions != nil {
+		if err := h.transitions.RecordTransition(ctx, order.ID, order.Status, cmd.Status); err != nil {
+			recordErr = fmt.Errorf("status updated but failed to record transition: %w", err)
+		}
+	}
+
+	order.Status = cmd.Status
+	order.UpdatedAt = h.clock.Now()
+
+	switch cmd.Status {
+	case domain.StatusFailed:
+		err = h.events.PublishOrderFailed(ctx, order.ID, cmd.Reason)
+	case domain.StatusCompleted:
+		err = h.events.PublishOrderProcessed(ctx, order.ID)
+	}
+	if err != nil {
+		return order, errors.Join(fmt.Errorf("status updated but failed to publish event: %w", err), recordErr)
+	}
+
+	return order, recordErr
+}
@@ -0,0 +1,97 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/kafka"
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/app/commands"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+)
+
+func seedOrder(t *testing.T, repo *memory.Repository, id string, status domain.OrderStatus) {
+	t.Helper()
+	order := domain.Order{
+		ID:            id,
+		CustomerEmail: "test@example.com",
+		Amount:        domain.NewMoney(1000, "USD"),
+		Status:        status,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("failed to seed order: %v", err)
+	}
+}
+
+func TestUpdateOrderStatus(t *testing.T) {
+	t.Run("fails processing order and publishes reason", func(t *testing.T) {
+		repo := memory.NewRepository()
+		events := kafka.NewSpyEventBus()
+		seedOrder(t, repo, "order-1", domain.StatusProcessing)
+		handler := commands.NewUpdateOrderStatusCommandHandler(repo, events)
+
+		order, err := handler.Handle(context.Background(), commands.UpdateOrderStatusCommand{
+			OrderID: "order-1",
+			Status:  domain.StatusFailed,
+			Reason:  "payment provider outage",
+		})
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if order.Status != domain.StatusFailed {
+			t.Errorf("expected status failed, got %s", order.Status)
+		}
+		published := events.Events()
+		if len(published) != 1 || published[0].Reason != "payment provider outage" {
+			t.Errorf("expected order.failed with reason, got %+v", published)
+		}
+	})
+
+	t.Run("rejects illegal transition", func(t *testing.T) {
+		repo := memory.NewRepository()
+		seedOrder(t, repo, "order-1", domain.StatusCompleted)
+		handler := commands.NewUpdateOrderStatusCommandHandler(repo, kafka.NewSpyEventBus())
+
+		_, err := handler.Handle(context.Background(), commands.UpdateOrderStatusCommand{
+			OrderID: "order-1",
+			Status:  domain.StatusFailed,
+		})
+
+		if !errors.Is(err, domain.ErrInvalidTransition) {
+			t.Errorf("expected ErrInvalidTransition, got %v", err)
+		}
+	})
+
+	t.Run("reports unchanged status", func(t *testing.T) {
+		repo := memory.NewRepository()
+		seedOrder(t, repo, "order-1", domain.StatusFailed)
+		handler := commands.NewUpdateOrderStatusCommandHandler(repo, kafka.NewSpyEventBus())
+
+		_, err := handler.Handle(context.Background(), commands.UpdateOrderStatusCommand{
+			OrderID: "order-1",
+			Status:  domain.StatusFailed,
+		})
+
+		if !errors.Is(err, commands.ErrStatusUnchanged) {
+			t.Errorf("expected ErrStatusUnchanged, got %v", err)
+		}
+	})
+
+	t.Run("rejects unknown status", func(t *testing.T) {
+		handler := commands.NewUpdateOrderStatusCommandHandler(memory.NewRepository(), kafka.NewSpyEventBus())
+
+		_, err := handler.Handle(context.Background(), commands.UpdateOrderStatusCommand{
+			OrderID: "order-1",
+			Status:  "archived",
+		})
+
+		if !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("expected ErrValidation, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// Errors returned by Service. Callers should match them with errors.Is rather than by
+// message.
+var (
+	// ErrNotFound is returned when the requested order does not exist.
+	ErrNotFound = ports.ErrNotFound
+	// ErrValidation is matched by input that failed validation; see domain.ValidationError.
+	ErrValidation = domain.ErrValidation
+	// ErrNotCancellable is returned when the order's status no longer permits cancellation.
+	// It also matches domain.ErrInvalidTransition.
+	ErrNotCancellable = errors.New("order cannot be canceled")
+	// ErrIdempotencyStore wraps idempotency store failures in CreateOrderIdempotent.
+	ErrIdempotencyStore = errors.New("idempotency store failed")
+)
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// IdempotentCreateOption customizes CreateOrderIdempotent.
+type IdempotentCreateOption func(*idempotentCreate)
+
+type idempotentCreate struct {
+	respond  func(*domain.Order) (ports.StoredResponse, error)
+	failOpen func(operation, orderID string, err error)
+}
+
+// WithStoredResponse sets how the response saved for the key is built from the created
+// order, e.g. the exact HTTP response. By default the order is stored as JSON.
+func WithStoredResponse(respond func(*domain.Order) (ports.StoredResponse, error)) IdempotentCreateOption {
+	return func(c *idempotentCreate) {
+		c.respond = respond
+	}
+}
+
+// WithIdempotencyFailOpen lets creation proceed when the idempotency store fails instead of
+// returning ErrIdempotencyStore. report is called with the failed operation, "get" or
+// "save", so the caller can log it and flag its response.
+func WithIdempotencyFailOpen(report func(operation, orderID string, err error)) IdempotentCreateOption {
+	return func(c *idempotentCreate) {
+		c.failOpen = report
+	}
+}
+
+// CreateOrderIdempotent creates an order at most once per idempotency key and returns the
+// response saved for the key. The first call for a key creates the order and saves its
+// response; later calls return that stored response unchanged, with replayed set. input is
+// only called when the key has no stored response, so a retry is replayed without its
+// payload being read or validated; an error from input is returned as is. Calls for the
+// same key are serialized within this process so concurrent retries do not both create an
+// order.
+func (s *Service) CreateOrderIdempotent(ctx context.Context, key string, input func() (CreateOrderInput, error), opts ...IdempotentCreateOption) (response ports.StoredResponse, replayed bool, err error) {
+	create := &idempotentCreate{respond: storedOrder}
+	for _, opt := range opts {
+		opt(create)
+	}
+
+	unlock := s.createLocks.lock(key)
+	defer unlock()
+
+	stored, err := s.idemStore.Get(ctx, key)
+	if err != nil {
+		if create.failOpen == nil {
+			return ports.StoredResponse{}, false, fmt.Errorf("%w: get: %w", ErrIdempotencyStore, err)
+		}
+		create.failOpen("get", "", err)
+	}
+	if stored != nil {
+		return *stored, true, nil
+	}
+
+	payload, err := input()
+	if err != nil {
+		return ports.StoredResponse{}, false, err
+	}
+	payload.IdempotencyKey = key
+	order, err := s.CreateOrder(ctx, payload)
+	if err != nil {
+		return ports.StoredResponse{}, false, err
+	}
+
+	response, err = create.respond(order)
+	if err != nil {
+		return ports.StoredResponse{}, false, fmt.Errorf("build idempotent response: %w", err)
+	}
+	response.OrderID = order.ID
+	if err := s.idemStore.Save(ctx, key, response); err != nil {
+		if create.failOpen == nil {
+			return ports.StoredResponse{}, false, fmt.Errorf("%w: save: %w", ErrIdempotencyStore, err)
+		}
+		create.failOpen("save", order.ID, err)
+	}
+
+	return response, false, nil
+}
+
+// storedOrder is the default stored response: the order as JSON.
+func storedOrder(order *domain.Order) (ports.StoredResponse, error) {
+	body, err := json.Marshal(order)
+	if err != nil {
+		return ports.StoredResponse{}, fmt.Errorf("marshal order: %w", err)
+	}
+	return ports.StoredResponse{Body: body}, nil
+}
+
+// keyLocks hands out one mutex per key, dropping it once nobody holds or waits for it.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock blocks until key is free and returns the function that releases it.
+func (k *keyLocks) lock(key string) (unlock func()) {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = map[string]*keyLock{}
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
@@ -0,0 +1,216 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/kafka"
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/app"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	ordersmetrics "github.com/dejobratic/tbd/internal/orders/metrics"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// idempotencyStore is an in-memory IdempotencyStore. getDelay widens the window between a
+// miss and the following save so concurrent calls for one key overlap; err fails every call.
+type idempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]ports.StoredResponse
+	getDelay  time.Duration
+	err       error
+}
+
+func (s *idempotencyStore) Get(_ context.Context, key string) (*ports.StoredResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.mu.Lock()
+	response, ok := s.responses[key]
+	s.mu.Unlock()
+	time.Sleep(s.getDelay)
+	if !ok {
+		return nil, nil
+	}
+	return &response, nil
+}
+
+func (s *idempotencyStore) Save(_ context.Context, key string, response ports.StoredResponse) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.responses[key]; !ok {
+		s.responses[key] = response
+	}
+	return nil
+}
+
+func (s *idempotencyStore) CountSince(context.Context, time.Time) (int64, error) {
+	return int64(len(s.responses)), s.err
+}
+
+func TestCreateOrderIdempotent(t *testing.T) {
+	businessMetrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	setup := func(store *idempotencyStore) (*app.Service, *memory.Repository) {
+		if store.responses == nil {
+			store.responses = map[string]ports.StoredResponse{}
+		}
+		repo := memory.NewRepository()
+		return app.NewService(repo, kafka.NewSpyEventBus(), store, slog.Default(), businessMetrics), repo
+	}
+	input := func() (app.CreateOrderInput, error) {
+		return app.CreateOrderInput{CustomerID: "customer-1", CustomerEmail: "user@example.com", AmountCents: 1999}, nil
+	}
+	count := func(t *testing.T, repo *memory.Repository) int {
+		t.Helper()
+		n, err := repo.Count(context.Background(), ports.ListFilter{})
+		if err != nil {
+			t.Fatalf("failed to count orders: %v", err)
+		}
+		return n
+	}
+
+	t.Run("replays the stored response without reading the input", func(t *testing.T) {
+		service, repo := setup(&idempotencyStore{})
+		ctx := context.Background()
+
+		first, replayed, err := service.CreateOrderIdempotent(ctx, "key-12345", input)
+		if err != nil || replayed {
+			t.Fatalf("expected a new order, got replayed=%v err=%v", replayed, err)
+		}
+		if err := repo.UpdateStatus(ctx, first.OrderID, domain.StatusCompleted); err != nil {
+			t.Fatalf("failed to update order: %v", err)
+		}
+
+		unread := func() (app.CreateOrderInput, error) {
+			t.Error("expected the input not to be read on a replay")
+			return app.CreateOrderInput{}, nil
+		}
+		retry, replayed, err := service.CreateOrderIdempotent(ctx, "key-12345", unread)
+		if err != nil {
+			t.Fatalf("failed to replay: %v", err)
+		}
+		if !replayed {
+			t.Error("expected the retry to be a replay")
+		}
+		if retry.OrderID != first.OrderID || string(retry.Body) != string(first.Body) {
+			t.Errorf("expected the stored response %+v, got %+v", first, retry)
+		}
+		if got := count(t, repo); got != 1 {
+			t.Errorf("expected 1 order, got %d", got)
+		}
+	})
+
+	t.Run("saves the response built for the order", func(t *testing.T) {
+		store := &idempotencyStore{}
+		service, _ := setup(store)
+
+		respond := func(order *domain.Order) (ports.StoredResponse, error) {
+			return ports.StoredResponse{StatusCode: 201, Body: []byte(`{"id":"` + order.ID + `"}`)}, nil
+		}
+		response, _, err := service.CreateOrderIdempotent(context.Background(), "key-12345", input, app.WithStoredResponse(respond))
+		if err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+
+		stored := store.responses["key-12345"]
+		if stored.StatusCode != 201 || stored.OrderID != response.OrderID || string(stored.Body) != string(response.Body) {
+			t.Errorf("expected %+v to be stored, got %+v", response, stored)
+		}
+	})
+
+	t.Run("returns an input error without creating an order", func(t *testing.T) {
+		service, repo := setup(&idempotencyStore{})
+		errRejected := errors.New("rejected")
+
+		_, _, err := service.CreateOrderIdempotent(context.Background(), "key-12345", func() (app.CreateOrderInput, error) {
+			return app.CreateOrderInput{}, errRejected
+		})
+		if !errors.Is(err, errRejected) {
+			t.Errorf("expected the input error, got %v", err)
+		}
+		if got := count(t, repo); got != 0 {
+			t.Errorf("expected no orders, got %d", got)
+		}
+	})
+
+	t.Run("creates one order for concurrent calls with one key", func(t *testing.T) {
+		service, repo := setup(&idempotencyStore{getDelay: 5 * time.Millisecond})
+
+		responses := make([]ports.StoredResponse, 8)
+		replays := make([]bool, len(responses))
+		var wg sync.WaitGroup
+		for i := range responses {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var err error
+				responses[i], replays[i], err = service.CreateOrderIdempotent(context.Background(), "key-12345", input)
+				if err != nil {
+					t.Errorf("call %d failed: %v", i, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		created := 0
+		for i, response := range responses {
+			if response.OrderID != responses[0].OrderID {
+				t.Errorf("expected the same order, got %s and %s", response.OrderID, responses[0].OrderID)
+			}
+			if !replays[i] {
+				created++
+			}
+		}
+		if created != 1 {
+			t.Errorf("expected exactly 1 call to create, got %d", created)
+		}
+		if got := count(t, repo); got != 1 {
+			t.Errorf("expected 1 order, got %d", got)
+		}
+	})
+
+	t.Run("fails when the store fails", func(t *testing.T) {
+		service, repo := setup(&idempotencyStore{err: errors.New("connection refused")})
+
+		_, _, err := service.CreateOrderIdempotent(context.Background(), "key-12345", input)
+		if !errors.Is(err, app.ErrIdempotencyStore) {
+			t.Errorf("expected ErrIdempotencyStore, got %v", err)
+		}
+		if got := count(t, repo); got != 0 {
+			t.Errorf("expected no orders, got %d", got)
+		}
+	})
+
+	t.Run("creates the order and reports store failures when failing open", func(t *testing.T) {
+		service, repo := setup(&idempotencyStore{err: errors.New("connection refused")})
+
+		var operations []string
+		report := func(operation, orderID string, err error) {
+			operations = append(operations, operation)
+		}
+		response, replayed, err := service.CreateOrderIdempotent(context.Background(), "key-12345", input, app.WithIdempotencyFailOpen(report))
+		if err != nil {
+			t.Fatalf("expected creation to proceed, got %v", err)
+		}
+		if replayed || response.OrderID == "" {
+			t.Errorf("expected a new order, got replayed=%v response=%+v", replayed, response)
+		}
+		if len(operations) != 2 || operations[0] != "get" || operations[1] != "save" {
+			t.Errorf("expected get and save failures reported, got %v", operations)
+		}
+		if got := count(t, repo); got != 1 {
+			t.Errorf("expected 1 order, got %d", got)
+		}
+	})
+}
@@ -2,7 +2,6 @@ package queries
 
 import (
 	"context"
-	"errors"
 	"strings"
 
 	"github.com/dejobratic/tbd/internal/orders/domain"
@@ -41,7 +40,7 @@ func (h *GetOrderQueryHandler) Handle(ctx context.Context, query GetOrderQuery)
 // Validate ensures the query has valid parameters.
 func (q GetOrderQuery) Validate() error {
 	if strings.TrimSpace(q.OrderID) == "" {
-		return errors.New("order_id is required")
+		return domain.NewValidationError("order_id is required")
 	}
 	return nil
 }
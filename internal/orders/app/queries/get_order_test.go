@@ -40,6 +40,29 @@ func (r *inMemoryRepository) GetByID(ctx context.Context, id string) (*domain.Or
 	return &order, nil
 }
 
+func (r *inMemoryRepository) GetByIDs(ctx context.Context, ids []string) (map[string]domain.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	orders := make(map[string]domain.Order, len(ids))
+	for _, id := range ids {
+		if order, exists := r.orders[id]; exists {
+			orders[id] = order
+		}
+	}
+	return orders, nil
+}
+
+func (r *inMemoryRepository) GetByReference(ctx context.Context, reference string) (*domain.Order, error) {
+	return nil, ports.ErrNotFound
+}
+
+func (r *inMemoryRepository) Exists(ctx context.Context, id string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, exists := r.orders[id]
+	return exists, nil
+}
+
 func (r *inMemoryRepository) List(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -50,6 +73,16 @@ func (r *inMemoryRepository) List(ctx context.Context, filter ports.ListFilter)
 	return orders, nil
 }
 
+func (r *inMemoryRepository) Count(ctx context.Context, filter ports.ListFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.orders), nil
+}
+
+func (r *inMemoryRepository) DistinctCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error) {
+	return nil, nil
+}
+
 func (r *inMemoryRepository) UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -63,6 +96,14 @@ func (r *inMemoryRepository) UpdateStatus(ctx context.Context, id string, status
 	return nil
 }
 
+func (r *inMemoryRepository) AppendStatusHistory(ctx context.Context, id string, transition domain.StatusTransition) error {
+	return nil
+}
+
+func (r *inMemoryRepository) GetStatusHistory(ctx context.Context, id string) ([]domain.StatusTransition, error) {
+	return nil, nil
+}
+
 func TestGetOrder(t *testing.T) {
 	t.Run("returns order by ID", func(t *testing.T) {
 		repo := newInMemoryRepository()
@@ -72,7 +113,7 @@ func TestGetOrder(t *testing.T) {
 		expectedOrder := domain.Order{
 			ID:            "test-order-123",
 			CustomerEmail: "test@example.com",
-			AmountCents:   1999,
+			Amount:        domain.NewMoney(1999, "USD"),
 			Status:        domain.StatusPending,
 			CreatedAt:     time.Now().UTC(),
 			UpdatedAt:     time.Now().UTC(),
@@ -101,8 +142,8 @@ func TestGetOrder(t *testing.T) {
 			t.Errorf("expected email %s, got %s", expectedOrder.CustomerEmail, result.CustomerEmail)
 		}
 
-		if result.AmountCents != expectedOrder.AmountCents {
-			t.Errorf("expected amount %d, got %d", expectedOrder.AmountCents, result.AmountCents)
+		if result.Amount.AmountCents != expectedOrder.Amount.AmountCents {
+			t.Errorf("expected amount %d, got %d", expectedOrder.Amount.AmountCents, result.Amount.AmountCents)
 		}
 
 		if result.Status != expectedOrder.Status {
@@ -178,7 +219,7 @@ func TestGetOrder(t *testing.T) {
 			{
 				ID:            "order-1",
 				CustomerEmail: "user1@example.com",
-				AmountCents:   1000,
+				Amount:        domain.NewMoney(1000, "USD"),
 				Status:        domain.StatusPending,
 				CreatedAt:     time.Now().UTC(),
 				UpdatedAt:     time.Now().UTC(),
@@ -186,7 +227,7 @@ func TestGetOrder(t *testing.T) {
 			{
 				ID:            "order-2",
 				CustomerEmail: "user2@example.com",
-				AmountCents:   2000,
+				Amount:        domain.NewMoney(2000, "USD"),
 				Status:        domain.StatusCompleted,
 				CreatedAt:     time.Now().UTC(),
 				UpdatedAt:     time.Now().UTC(),
@@ -194,7 +235,7 @@ func TestGetOrder(t *testing.T) {
 			{
 				ID:            "order-3",
 				CustomerEmail: "user3@example.com",
-				AmountCents:   3000,
+				Amount:        domain.NewMoney(3000, "USD"),
 				Status:        domain.StatusCanceled,
 				CreatedAt:     time.Now().UTC(),
 				UpdatedAt:     time.Now().UTC(),
@@ -2,10 +2,13 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
+	"github.com/dejobratic/tbd/internal/audit"
 	"github.com/dejobratic/tbd/internal/orders/app/commands"
 	"github.com/dejobratic/tbd/internal/orders/domain"
 	"github.com/dejobratic/tbd/internal/orders/metrics"
@@ -14,10 +17,61 @@ import (
 
 // Service bundles use cases for handling orders via the API.
 type Service struct {
-	repo               ports.OrderRepository
-	events             ports.EventBus
-	idemStore          ports.IdempotencyStore
-	createOrderHandler commands.CommandHandler
+	repo        ports.OrderRepository
+	events      ports.EventBus
+	idemStore   ports.IdempotencyStore
+	bus         *commands.CommandBus
+	pages       ports.PageLimits
+	audit       audit.Log
+	transitions ports.TransitionRecorder
+	clock       ports.Clock
+	// validateOpts are the create handler's validation options, reused when deciding
+	// whether a failed order may be reprocessed.
+	validateOpts []domain.ValidateOption
+
+	createLocks keyLocks
+}
+
+// Option customizes Service construction.
+type Option func(*serviceOptions)
+
+type serviceOptions struct {
+	createOrderOpts []commands.CreateOrderOption
+	pageLimits      ports.PageLimits
+	auditLog        audit.Log
+	clock           ports.Clock
+}
+
+// WithCreateOrderOptions forwards options to the create order command handler.
+func WithCreateOrderOptions(opts ...commands.CreateOrderOption) Option {
+	return func(o *serviceOptions) {
+		o.createOrderOpts = append(o.createOrderOpts, opts...)
+	}
+}
+
+// WithPageLimits sets the default and maximum page size of list queries.
+func WithPageLimits(limits ports.PageLimits) Option {
+	return func(o *serviceOptions) {
+		o.pageLimits = limits
+	}
+}
+
+// WithAuditLog records every order creation, cancel and status change made through the
+// service to log, and sets the log GetOrderAudit reads from. Each entry's before and after
+// status are the ones the change was checked and made with. A failed write fails the
+// operation rather than losing the entry.
+func WithAuditLog(log audit.Log) Option {
+	return func(o *serviceOptions) {
+		o.auditLog = log
+	}
+}
+
+// WithClock sets the clock order timestamps are taken from, for the service and its
+// command handlers.
+func WithClock(clock ports.Clock) Option {
+	return func(o *serviceOptions) {
+		o.clock = clock
+	}
 }
 
 // NewService wires required dependencies.
@@ -27,31 +81,83 @@ func NewService(
 	idem ports.IdempotencyStore,
 	logger *slog.Logger,
 	metrics *metrics.Metrics,
+	opts ...Option,
 ) *Service {
-	coreHandler := commands.NewCreateOrderCommandHandler(repo, events)
+	options := &serviceOptions{pageLimits: ports.DefaultPageLimits, clock: ports.SystemClock{}}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	bus := commands.NewCommandBus(commands.NewObservableMiddleware(logger))
+
+	createOpts := append([]commands.CreateOrderOption{commands.WithPhaseMetrics(metrics), commands.WithClock(options.clock)}, options.createOrderOpts...)
+	updateOpts := []commands.UpdateOrderStatusOption{commands.WithStatusClock(options.clock)}
+	var transitions ports.TransitionRecorder
+	if options.auditLog != nil {
+		transitions = audit.NewRecorder(options.auditLog)
+		createOpts = append(createOpts, commands.WithTransitionRecorder(transitions))
+		updateOpts = append(updateOpts, commands.WithStatusTransitionRecorder(transitions))
+	}
+
+	coreHandler := commands.NewCreateOrderCommandHandler(repo, events, createOpts...)
 	observableHandler := commands.NewObservableCommandHandler(coreHandler, logger, metrics)
+	bus.Register(commands.CreateOrderCommand{}.CommandName(), commands.Handle(observableHandler.Handle))
+
+	updateStatusHandler := commands.NewUpdateOrderStatusCommandHandler(repo, events, updateOpts...)
+	bus.Register(commands.UpdateOrderStatusCommand{}.CommandName(), commands.Handle(updateStatusHandler.Handle))
 
 	return &Service{
-		repo:               repo,
-		events:             events,
-		idemStore:          idem,
-		createOrderHandler: observableHandler,
+		repo:         repo,
+		events:       events,
+		idemStore:    idem,
+		bus:          bus,
+		pages:        options.pageLimits,
+		audit:        options.auditLog,
+		transitions:  transitions,
+		clock:        options.clock,
+		validateOpts: coreHandler.ValidateOptions(),
 	}
 }
 
+// Dispatch sends a command through the command bus.
+func (s *Service) Dispatch(ctx context.Context, cmd commands.Command) (any, error) {
+	return s.bus.Dispatch(ctx, cmd)
+}
+
 // CreateOrderInput captures payload for creating an order.
 type CreateOrderInput struct {
-	CustomerEmail string `json:"customer_email"`
-	AmountCents   int64  `json:"amount_cents"`
+	CustomerID    string            `json:"customer_id"`
+	CustomerEmail string            `json:"customer_email"`
+	AmountCents   int64             `json:"amount_cents"`
+	Currency      string            `json:"currency,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	// IdempotencyKey comes from the request header, never the body.
+	IdempotencyKey string `json:"-"`
 }
 
 // CreateOrder orchestrates order creation and event emission.
 func (s *Service) CreateOrder(ctx context.Context, input CreateOrderInput) (*domain.Order, error) {
+	return s.dispatchCreate(ctx, input, false)
+}
+
+// ValidateOrder runs every create order check and returns the order that would be created,
+// without an ID or reference. Nothing is persisted or published.
+func (s *Service) ValidateOrder(ctx context.Context, input CreateOrderInput) (*domain.Order, error) {
+	return s.dispatchCreate(ctx, input, true)
+}
+
+func (s *Service) dispatchCreate(ctx context.Context, input CreateOrderInput, dryRun bool) (*domain.Order, error) {
 	cmd := commands.CreateOrderCommand{
-		CustomerEmail: input.CustomerEmail,
-		AmountCents:   input.AmountCents,
+		CustomerID:     input.CustomerID,
+		CustomerEmail:  input.CustomerEmail,
+		Amount:         domain.NewMoney(input.AmountCents, input.Currency),
+		Metadata:       input.Metadata,
+		IdempotencyKey: input.IdempotencyKey,
+		DryRun:         dryRun,
 	}
-	return s.createOrderHandler.Handle(ctx, cmd)
+	result, err := s.bus.Dispatch(ctx, cmd)
+	order, _ := result.(*domain.Order)
+	return order, err
 }
 
 // GetOrder retrieves an order by ID.
@@ -59,32 +165,252 @@ func (s *Service) GetOrder(ctx context.Context, id string) (*domain.Order, error
 	return s.repo.GetByID(ctx, id)
 }
 
-// ListOrders returns orders using a filter.
+// GetOrderByReference retrieves an order by its human-readable reference.
+func (s *Service) GetOrderByReference(ctx context.Context, reference string) (*domain.Order, error) {
+	return s.repo.GetByReference(ctx, reference)
+}
+
+// GetOrderHistory returns the order's status transitions, oldest first.
+func (s *Service) GetOrderHistory(ctx context.Context, id string) ([]domain.StatusTransition, error) {
+	return s.repo.GetStatusHistory(ctx, id)
+}
+
+// GetOrderAudit returns the order's audit entries, oldest first. It is empty when no audit
+// log is configured.
+func (s *Service) GetOrderAudit(ctx context.Context, id string) ([]audit.Entry, error) {
+	exists, err := s.repo.Exists(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if s.audit == nil {
+		return []audit.Entry{}, nil
+	}
+	return s.audit.ListByOrder(ctx, id)
+}
+
+// NormalizeListFilter validates filter and applies the configured page limits, returning
+// the filter that ListOrders will actually run.
+func (s *Service) NormalizeListFilter(filter ports.ListFilter) (ports.ListFilter, error) {
+	return filter.Normalize(s.pages)
+}
+
+// ListOrders returns orders using a filter. Invalid filters are rejected with ErrValidation
+// before reaching the repository.
 func (s *Service) ListOrders(ctx context.Context, filter ports.ListFilter) ([]domain.Order, error) {
+	filter, err := s.NormalizeListFilter(filter)
+	if err != nil {
+		return nil, err
+	}
 	return s.repo.List(ctx, filter)
 }
 
+// CountOrders returns the number of orders matching a filter, ignoring pagination.
+func (s *Service) CountOrders(ctx context.Context, filter ports.ListFilter) (int, error) {
+	filter, err := s.NormalizeListFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.Count(ctx, filter)
+}
+
+// ListCustomers returns one page of the distinct, lower-cased emails of customers whose
+// orders match a filter.
+func (s *Service) ListCustomers(ctx context.Context, filter ports.ListFilter) ([]string, error) {
+	filter, err := s.NormalizeListFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.DistinctCustomers(ctx, filter)
+}
+
 // CancelOrder attempts to cancel a pending order.
 func (s *Service) CancelOrder(ctx context.Context, id string) (*domain.Order, error) {
-	order, err := s.repo.GetByID(ctx, id)
+	order, err := s.repo.GetByID(ports.WithPrimaryReads(ctx), id)
 	if err != nil {
 		return nil, err
 	}
 
-	if order.Status != domain.StatusPending {
-		return nil, fmt.Errorf("cannot cancel order in status %s", order.Status)
+	if !order.IsCancellable() {
+		return nil, fmt.Errorf("%w: %w: order is %s", ErrNotCancellable, domain.ErrInvalidTransition, order.Status)
 	}
 
 	if err := s.repo.UpdateStatus(ctx, id, domain.StatusCanceled); err != nil {
 		return nil, err
 	}
+	if err := s.recordTransition(ctx, id, order.Status, domain.StatusCanceled); err != nil {
+		return nil, err
+	}
 
 	order.Status = domain.StatusCanceled
-	order.UpdatedAt = time.Now().UTC()
+	order.UpdatedAt = s.clock.Now()
 
 	return order, nil
 }
 
+// Bulk status update limits.
+const (
+	MaxBulkStatusIDs      = 500
+	bulkStatusConcurrency = 8
+)
+
+// Per-order outcomes reported by BulkUpdateStatus.
+const (
+	BulkResultSucceeded = "succeeded"
+	BulkResultSkipped   = "skipped"
+	BulkResultErrored   = "errored"
+)
+
+// BulkStatusInput captures payload for moving several orders to one status.
+type BulkStatusInput struct {
+	IDs    []string           `json:"ids"`
+	Status domain.OrderStatus `json:"status"`
+	Reason string             `json:"reason,omitempty"`
+}
+
+// BulkStatusResult reports what happened to a single order in a bulk update.
+type BulkStatusResult struct {
+	OrderID string `json:"id"`
+	Result  string `json:"result"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkUpdateStatus moves each order to the requested status with bounded concurrency.
+// Orders already in that status or whose status does not permit the transition are
+// skipped; other failures are reported per order. Results follow the input order.
+func (s *Service) BulkUpdateStatus(ctx context.Context, input BulkStatusInput) ([]BulkStatusResult, error) {
+	if len(input.IDs) == 0 {
+		return nil, domain.NewValidationError("ids must not be empty")
+	}
+	if len(input.IDs) > MaxBulkStatusIDs {
+		return nil, domain.NewValidationError(fmt.Sprintf("at most %d ids are allowed", MaxBulkStatusIDs))
+	}
+	if !input.Status.IsValid() {
+		return nil, domain.NewValidationError(fmt.Sprintf("status %q is not valid", input.Status))
+	}
+
+	results := make([]BulkStatusResult, len(input.IDs))
+	sem := make(chan struct{}, bulkStatusConcurrency)
+	var wg sync.WaitGroup
+
+	for i, id := range input.IDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			_, err := s.bus.Dispatch(ctx, commands.UpdateOrderStatusCommand{
+				OrderID: id,
+				Status:  input.Status,
+				Reason:  input.Reason,
+			})
+			results[i] = bulkStatusResult(id, err)
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func bulkStatusResult(id string, err error) BulkStatusResult {
+	switch {
+	case err == nil:
+		return BulkStatusResult{OrderID: id, Result: BulkResultSucceeded}
+	case errors.Is(err, commands.ErrStatusUnchanged), errors.Is(err, domain.ErrInvalidTransition):
+		return BulkStatusResult{OrderID: id, Result: BulkResultSkipped, Error: err.Error()}
+	default:
+		return BulkStatusResult{OrderID: id, Result: BulkResultErrored, Error: err.Error()}
+	}
+}
+
+// Reprocessing defaults applied when ReprocessInput leaves a field unset.
+const (
+	DefaultReprocessBatchSize = 100
+	DefaultReprocessLimit     = 1000
+)
+
+// ReprocessInput bounds a reprocess run. BatchSize is the page size used to scan failed
+// orders and Limit caps how many failed orders are examined.
+type ReprocessInput struct {
+	BatchSize int
+	Limit     int
+}
+
+// ReprocessSummary counts the outcome of a reprocess run.
+type ReprocessSummary struct {
+	Scanned     int `json:"scanned"`
+	Reprocessed int `json:"reprocessed"`
+	Skipped     int `json:"skipped"`
+	Errored     int `json:"errored"`
+}
+
+// ReprocessFailedOrders moves reprocessable failed orders back to pending and republishes
+// order.created for each. Failed orders are collected before any are updated so paging is
+// not disturbed by the status changes.
+func (s *Service) ReprocessFailedOrders(ctx context.Context, input ReprocessInput) (ReprocessSummary, error) {
+	batchSize := input.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultReprocessBatchSize
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultReprocessLimit
+	}
+
+	var failed []domain.Order
+	for page := 1; len(failed) < limit; page++ {
+		orders, err := s.repo.List(ports.WithPrimaryReads(ctx), ports.ListFilter{
+			Statuses: []domain.OrderStatus{domain.StatusFailed},
+			Page:     page,
+			PageSize: batchSize,
+		})
+		if err != nil {
+			return ReprocessSummary{}, err
+		}
+		failed = append(failed, orders...)
+		if len(orders) < batchSize {
+			break
+		}
+	}
+	if len(failed) > limit {
+		failed = failed[:limit]
+	}
+
+	summary := ReprocessSummary{Scanned: len(failed)}
+	for _, order := range failed {
+		if !order.CanReprocess(s.validateOpts...) {
+			summary.Skipped++
+			continue
+		}
+		if err := s.repo.UpdateStatus(ctx, order.ID, domain.StatusPending); err != nil {
+			summary.Errored++
+			continue
+		}
+		recordErr := s.recordTransition(ctx, order.ID, order.Status, domain.StatusPending)
+		if err := s.events.PublishOrderCreated(ctx, order.ID); err != nil || recordErr != nil {
+			summary.Errored++
+			continue
+		}
+		summary.Reprocessed++
+	}
+
+	return summary, nil
+}
+
+// recordTransition reports a persisted status change to the audit log, if one is set.
+func (s *Service) recordTransition(ctx context.Context, id string, from, to domain.OrderStatus) error {
+	if s.transitions == nil {
+		return nil
+	}
+	if err := s.transitions.RecordTransition(ctx, id, from, to); err != nil {
+		return fmt.Errorf("status updated but failed to record transition: %w", err)
+	}
+	return nil
+}
+
 // SaveIdempotentResponse writes response details for a key.
 func (s *Service) SaveIdempotentResponse(ctx context.Context, key string, response ports.StoredResponse) error {
 	return s.idemStore.Save(ctx, key, response)
@@ -94,3 +420,9 @@ func (s *Service) SaveIdempotentResponse(ctx context.Context, key string, respon
 func (s *Service) GetIdempotentResponse(ctx context.Context, key string) (*ports.StoredResponse, error) {
 	return s.idemStore.Get(ctx, key)
 }
+
+// CountIdempotencyKeysSince reports how many idempotency keys were first used at or after
+// since, a proxy for how often clients send creates.
+func (s *Service) CountIdempotencyKeysSince(ctx context.Context, since time.Time) (int64, error) {
+	return s.idemStore.CountSince(ctx, since)
+}
@@ -0,0 +1,170 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/audit"
+	auditmemory "github.com/dejobratic/tbd/internal/audit/memory"
+	"github.com/dejobratic/tbd/internal/kafka"
+	"github.com/dejobratic/tbd/internal/orders/adapters"
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/app"
+	"github.com/dejobratic/tbd/internal/orders/app/commands"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	ordersmetrics "github.com/dejobratic/tbd/internal/orders/metrics"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// failingAuditLog rejects every entry.
+type failingAuditLog struct{}
+
+func (failingAuditLog) Record(context.Context, audit.Entry) error {
+	return errors.New("audit log unavailable")
+}
+
+func (failingAuditLog) ListByOrder(context.Context, string) ([]audit.Entry, error) {
+	return nil, nil
+}
+
+func TestServiceAuditLog(t *testing.T) {
+	businessMetrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	ctx := context.Background()
+	input := app.CreateOrderInput{CustomerID: "customer-1", CustomerEmail: "user@example.com", AmountCents: 1999}
+
+	t.Run("records creations and status changes with their before and after status", func(t *testing.T) {
+		log := auditmemory.NewStore()
+		service := app.NewService(memory.NewRepository(), kafka.NewSpyEventBus(), nil, slog.Default(), businessMetrics, app.WithAuditLog(log))
+
+		processed, err := service.CreateOrder(ctx, input)
+		if err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+		results, err := service.BulkUpdateStatus(ctx, app.BulkStatusInput{IDs: []string{processed.ID}, Status: domain.StatusProcessing})
+		if err != nil || results[0].Result != app.BulkResultSucceeded {
+			t.Fatalf("failed to update status: %v %+v", err, results)
+		}
+		canceled, err := service.CreateOrder(ctx, input)
+		if err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+		if _, err := service.CancelOrder(ctx, canceled.ID); err != nil {
+			t.Fatalf("failed to cancel order: %v", err)
+		}
+
+		type transition struct {
+			action   string
+			from, to domain.OrderStatus
+		}
+		for id, want := range map[string][]transition{
+			processed.ID: {{audit.ActionCreate, "", domain.StatusPending}, {audit.ActionStatusChange, domain.StatusPending, domain.StatusProcessing}},
+			canceled.ID:  {{audit.ActionCreate, "", domain.StatusPending}, {audit.ActionCancel, domain.StatusPending, domain.StatusCanceled}},
+		} {
+			entries, err := service.GetOrderAudit(ctx, id)
+			if err != nil {
+				t.Fatalf("failed to get audit trail: %v", err)
+			}
+			if len(entries) != len(want) {
+				t.Fatalf("expected %d entries, got %+v", len(want), entries)
+			}
+			for i, w := range want {
+				if entries[i].Action != w.action || entries[i].FromStatus != w.from || entries[i].ToStatus != w.to {
+					t.Errorf("entry %d: expected %s %s -> %s, got %+v", i, w.action, w.from, w.to, entries[i])
+				}
+			}
+		}
+	})
+
+	t.Run("fails the change when the audit write fails", func(t *testing.T) {
+		repo := memory.NewRepository()
+		service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), businessMetrics, app.WithAuditLog(failingAuditLog{}))
+
+		for range 2 {
+			if _, err := service.CreateOrder(ctx, input); err == nil || !strings.Contains(err.Error(), "audit log unavailable") {
+				t.Fatalf("expected the create to fail on the audit write, got %v", err)
+			}
+		}
+		orders, err := repo.List(ctx, ports.ListFilter{})
+		if err != nil || len(orders) != 2 {
+			t.Fatalf("expected the orders to be saved, got %v %+v", err, orders)
+		}
+
+		if _, err := service.CancelOrder(ctx, orders[0].ID); err == nil || !strings.Contains(err.Error(), "audit log unavailable") {
+			t.Errorf("expected the cancel to fail on the audit write, got %v", err)
+		}
+		results, err := service.BulkUpdateStatus(ctx, app.BulkStatusInput{IDs: []string{orders[1].ID}, Status: domain.StatusProcessing})
+		if err != nil {
+			t.Fatalf("failed to update status: %v", err)
+		}
+		if results[0].Result != app.BulkResultErrored || !strings.Contains(results[0].Error, "audit log unavailable") {
+			t.Errorf("expected the bulk update to fail on the audit write, got %+v", results[0])
+		}
+	})
+}
+
+func TestReprocessFailedOrders(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRepository()
+	for _, order := range []domain.Order{
+		{ID: "free", CustomerID: "customer-1", CustomerEmail: "a@b.com", Amount: domain.NewMoney(0, "USD"), Status: domain.StatusFailed},
+		{ID: "long-email", CustomerID: "customer-1", CustomerEmail: "customer@example.com", Amount: domain.NewMoney(100, "USD"), Status: domain.StatusFailed},
+	} {
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("failed to seed order: %v", err)
+		}
+	}
+	service := app.NewService(repo, kafka.NewSpyEventBus(), nil, slog.Default(), nil, app.WithCreateOrderOptions(
+		commands.WithAllowZeroAmount(true),
+		commands.WithMaxEmailLength(10),
+	))
+
+	summary, err := service.ReprocessFailedOrders(ctx, app.ReprocessInput{})
+	if err != nil {
+		t.Fatalf("failed to reprocess: %v", err)
+	}
+	if want := (app.ReprocessSummary{Scanned: 2, Reprocessed: 1, Skipped: 1}); summary != want {
+		t.Errorf("expected summary %+v, got %+v", want, summary)
+	}
+	if order, err := repo.GetByID(ctx, "free"); err != nil || order.Status != domain.StatusPending {
+		t.Errorf("expected the free order to be reprocessed under the create options, got %+v %v", order, err)
+	}
+}
+
+func TestServiceChecksTransitionsAgainstThePrimary(t *testing.T) {
+	businessMetrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	ctx := context.Background()
+	// The replica still has the order pending after the primary completed it.
+	primary, replica := memory.NewRepository(), memory.NewRepository()
+	if err := primary.Create(ctx, domain.Order{ID: "order-1", Status: domain.StatusCompleted}); err != nil {
+		t.Fatalf("failed to seed primary: %v", err)
+	}
+	if err := replica.Create(ctx, domain.Order{ID: "order-1", Status: domain.StatusPending}); err != nil {
+		t.Fatalf("failed to seed replica: %v", err)
+	}
+	service := app.NewService(adapters.NewReadWriteRepository(primary, replica), kafka.NewSpyEventBus(), nil, slog.Default(), businessMetrics)
+
+	if _, err := service.CancelOrder(ctx, "order-1"); !errors.Is(err, app.ErrNotCancellable) {
+		t.Errorf("expected ErrNotCancellable, got %v", err)
+	}
+	results, err := service.BulkUpdateStatus(ctx, app.BulkStatusInput{IDs: []string{"order-1"}, Status: domain.StatusProcessing})
+	if err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	if results[0].Result == app.BulkResultSucceeded {
+		t.Errorf("expected the completed order not to move to processing, got %+v", results[0])
+	}
+	order, err := primary.GetByID(ctx, "order-1")
+	if err != nil || order.Status != domain.StatusCompleted {
+		t.Errorf("expected the order to stay completed, got %+v %v", order, err)
+	}
+}
@@ -0,0 +1,271 @@
+// Package client is a typed Go client for the orders HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTimeout           = 10 * time.Second
+	defaultIdempotencyHeader = "Idempotency-Key"
+)
+
+// ErrNotFound is wrapped by the APIError of a 404 or 410 response.
+var ErrNotFound = errors.New("order not found")
+
+// APIError is returned for any non-2xx response.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("orders api: %d %s", e.StatusCode, e.Message)
+}
+
+// Unwrap lets errors.Is(err, ErrNotFound) match missing and deleted orders.
+func (e *APIError) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Client calls the orders API.
+type Client struct {
+	baseURL           *url.URL
+	httpClient        *http.Client
+	idempotencyHeader string
+	bearerToken       string
+}
+
+// Option customizes a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client, e.g. one with instrumented transport.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithTimeout bounds each request, including reading the response body.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithIdempotencyHeader sets the header the idempotency key is sent in, matching the
+// server's IDEMPOTENCY_HEADER.
+func WithIdempotencyHeader(name string) Option {
+	return func(c *Client) {
+		if name != "" {
+			c.idempotencyHeader = name
+		}
+	}
+}
+
+// WithBearerToken authenticates every request with token.
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.bearerToken = token
+	}
+}
+
+// NewClient constructs a Client for the API at baseURL, e.g. "http://orders:8080".
+func NewClient(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("parse base URL: %q must be absolute", baseURL)
+	}
+
+	c := &Client{
+		baseURL:           parsed,
+		httpClient:        &http.Client{Timeout: defaultTimeout},
+		idempotencyHeader: defaultIdempotencyHeader,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// CreateOrderRequest is the body of a create order call.
+type CreateOrderRequest struct {
+	CustomerID    string            `json:"customer_id"`
+	CustomerEmail string            `json:"customer_email"`
+	AmountCents   int64             `json:"amount_cents"`
+	Currency      string            `json:"currency,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	// IdempotencyKey is sent in the idempotency header. Set it and reuse the request to
+	// retry safely; when empty a random key is generated for this call.
+	IdempotencyKey string `json:"-"`
+}
+
+// ListOrdersParams filters and pages a list call. Zero values are omitted.
+type ListOrdersParams struct {
+	Status     domain.OrderStatus
+	CustomerID string
+	Currency   string
+	// UpdatedAfter lists orders changed at or after it, oldest change first.
+	UpdatedAfter time.Time
+	Page         int
+	PageSize     int
+}
+
+// Pagination describes the page the server applied to a list call.
+type Pagination struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+// OrderPage is one page of a list call.
+type OrderPage struct {
+	Orders     []domain.Order `json:"orders"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+type orderEnvelope struct {
+	Order domain.Order `json:"order"`
+}
+
+// CreateOrder creates an order. The API accepts it asynchronously, so the returned order is
+// pending.
+func (c *Client) CreateOrder(ctx context.Context, req CreateOrderRequest) (*domain.Order, error) {
+	key := req.IdempotencyKey
+	if key == "" {
+		key = uuid.NewString()
+	}
+
+	var resp orderEnvelope
+	if err := c.do(ctx, http.MethodPost, "/v1/orders", nil, req, key, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Order, nil
+}
+
+// GetOrder fetches an order by ID.
+func (c *Client) GetOrder(ctx context.Context, id string) (*domain.Order, error) {
+	var resp orderEnvelope
+	if err := c.do(ctx, http.MethodGet, "/v1/orders/"+url.PathEscape(id), nil, nil, "", &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Order, nil
+}
+
+// ListOrders fetches one page of orders.
+func (c *Client) ListOrders(ctx context.Context, params ListOrdersParams) (*OrderPage, error) {
+	query := url.Values{}
+	if params.Status != "" {
+		query.Set("status", string(params.Status))
+	}
+	if params.CustomerID != "" {
+		query.Set("customer_id", params.CustomerID)
+	}
+	if params.Currency != "" {
+		query.Set("currency", params.Currency)
+	}
+	if !params.UpdatedAfter.IsZero() {
+		query.Set("updated_after", params.UpdatedAfter.Format(time.RFC3339Nano))
+	}
+	if params.Page > 0 {
+		query.Set("page", strconv.Itoa(params.Page))
+	}
+	if params.PageSize > 0 {
+		query.Set("page_size", strconv.Itoa(params.PageSize))
+	}
+
+	var page OrderPage
+	if err := c.do(ctx, http.MethodGet, "/v1/orders", query, nil, "", &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// CancelOrder cancels an order. A generated idempotency key makes a retried cancel of the
+// same call safe on the server.
+func (c *Client) CancelOrder(ctx context.Context, id string) (*domain.Order, error) {
+	var resp orderEnvelope
+	if err := c.do(ctx, http.MethodPost, "/v1/orders/"+url.PathEscape(id)+"/cancel", nil, nil, uuid.NewString(), &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Order, nil
+}
+
+// do sends a request and decodes a 2xx JSON body into out or a non-2xx body into an
+// APIError.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any, idempotencyKey string, out any) error {
+	target := c.baseURL.JoinPath(path)
+	target.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reader)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(c.idempotencyHeader, idempotencyKey)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return decodeError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// decodeError reads the API's {"error": "..."} body, falling back to the status text.
+func decodeError(resp *http.Response) error {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	message := http.StatusText(resp.StatusCode)
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err == nil && strings.TrimSpace(payload.Error) != "" {
+		message = payload.Error
+	}
+	return &APIError{StatusCode: resp.StatusCode, Message: message}
+}
@@ -0,0 +1,129 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	idemmemory "github.com/dejobratic/tbd/internal/idempotency/memory"
+	"github.com/dejobratic/tbd/internal/kafka"
+	httpadapter "github.com/dejobratic/tbd/internal/orders/adapters/http"
+	"github.com/dejobratic/tbd/internal/orders/adapters/memory"
+	"github.com/dejobratic/tbd/internal/orders/app"
+	"github.com/dejobratic/tbd/internal/orders/client"
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	ordersmetrics "github.com/dejobratic/tbd/internal/orders/metrics"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+	metrics, err := ordersmetrics.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	service := app.NewService(memory.NewRepository(), kafka.NewSpyEventBus(), idemmemory.NewStore(), slog.Default(), metrics)
+	mux := http.NewServeMux()
+	httpadapter.NewHandler(service).Register(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c, err := client.NewClient(srv.URL)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return c
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	input := client.CreateOrderRequest{CustomerID: "customer-1", CustomerEmail: "alice@example.com", AmountCents: 1000}
+
+	t.Run("creates and fetches an order", func(t *testing.T) {
+		c := newTestClient(t)
+
+		created, err := c.CreateOrder(ctx, input)
+		if err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+		if created.ID == "" || created.Status != domain.StatusPending {
+			t.Fatalf("expected pending order with ID, got %+v", created)
+		}
+
+		got, err := c.GetOrder(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		if got.ID != created.ID || got.Amount.AmountCents != 1000 {
+			t.Errorf("expected %+v, got %+v", created, got)
+		}
+	})
+
+	t.Run("replays a create retried with the same idempotency key", func(t *testing.T) {
+		c := newTestClient(t)
+		retried := input
+		retried.IdempotencyKey = "retry-key"
+
+		first, err := c.CreateOrder(ctx, retried)
+		if err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+		second, err := c.CreateOrder(ctx, retried)
+		if err != nil {
+			t.Fatalf("failed to retry create: %v", err)
+		}
+		if first.ID != second.ID {
+			t.Errorf("expected replayed order %s, got %s", first.ID, second.ID)
+		}
+	})
+
+	t.Run("lists and cancels orders", func(t *testing.T) {
+		c := newTestClient(t)
+		created, err := c.CreateOrder(ctx, input)
+		if err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+
+		page, err := c.ListOrders(ctx, client.ListOrdersParams{CustomerID: "customer-1", PageSize: 10})
+		if err != nil {
+			t.Fatalf("failed to list orders: %v", err)
+		}
+		if len(page.Orders) != 1 || page.Pagination.Total != 1 {
+			t.Fatalf("expected one order, got %+v", page)
+		}
+
+		canceled, err := c.CancelOrder(ctx, created.ID)
+		if err != nil {
+			t.Fatalf("failed to cancel order: %v", err)
+		}
+		if canceled.Status != domain.StatusCanceled {
+			t.Errorf("expected canceled order, got %s", canceled.Status)
+		}
+	})
+
+	t.Run("returns typed errors", func(t *testing.T) {
+		c := newTestClient(t)
+
+		_, err := c.GetOrder(ctx, "missing")
+		if !errors.Is(err, client.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+
+		_, err = c.CreateOrder(ctx, client.CreateOrderRequest{CustomerID: "customer-1", CustomerEmail: "not-an-email", AmountCents: 1000})
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected 400 APIError, got %v", err)
+		}
+	})
+}
+
+func TestNewClient(t *testing.T) {
+	t.Run("rejects relative base URLs", func(t *testing.T) {
+		if _, err := client.NewClient("/v1"); err == nil {
+			t.Error("expected error for relative base URL")
+		}
+	})
+}
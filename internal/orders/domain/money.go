@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrency is assumed when an amount is given without a currency.
+const DefaultCurrency = "USD"
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies.
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// Money is an amount in the currency's minor unit (cents for USD) together with its
+// ISO 4217 currency code, so amounts in different currencies cannot be mixed silently.
+type Money struct {
+	AmountCents int64  `json:"amount_cents"`
+	Currency    string `json:"currency"`
+}
+
+// NewMoney builds a Money, normalizing the currency code and defaulting it to
+// DefaultCurrency when empty.
+func NewMoney(amountCents int64, currency string) Money {
+	return Money{AmountCents: amountCents, Currency: normalizeCurrency(currency)}
+}
+
+// Add returns the sum of m and other, failing with ErrCurrencyMismatch when their
+// currencies differ.
+func (m Money) Add(other Money) (Money, error) {
+	if normalizeCurrency(m.Currency) != normalizeCurrency(other.Currency) {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return NewMoney(m.AmountCents+other.AmountCents, m.Currency), nil
+}
+
+// IsPositive reports whether the amount is greater than zero.
+func (m Money) IsPositive() bool {
+	return m.AmountCents > 0
+}
+
+// ValidateCurrency rejects currency codes that are not three upper-case ASCII letters or
+// are not an active ISO 4217 currency.
+func ValidateCurrency(currency string) error {
+	if len(currency) != 3 {
+		return NewValidationError("currency must be a 3-letter ISO 4217 code")
+	}
+	for _, r := range currency {
+		if r < 'A' || r > 'Z' {
+			return NewValidationError("currency must be a 3-letter ISO 4217 code")
+		}
+	}
+	if _, ok := isoCurrencies[currency]; !ok {
+		return NewValidationError(fmt.Sprintf("unsupported currency %q", currency))
+	}
+	return nil
+}
+
+// currencyExponents lists ISO 4217 currencies whose minor unit is not a hundredth.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// currencySymbols prefix formatted amounts; other currencies are prefixed with their code.
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// CurrencyExponent returns the number of decimal places of currency's minor unit: 2 for
+// USD, 0 for JPY, 3 for BHD.
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[normalizeCurrency(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// Formatted renders the amount for display in major units using the currency's minor
+// unit, e.g. "$19.99", "¥1999" or "BHD 1.999".
+func (m Money) Formatted() string {
+	currency := normalizeCurrency(m.Currency)
+
+	sign := ""
+	amount := m.AmountCents
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%d", amount)
+	if exponent := CurrencyExponent(currency); exponent > 0 {
+		digits = fmt.Sprintf("%0*d", exponent+1, amount)
+		digits = digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+	}
+
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + digits
+	}
+	return sign + currency + " " + digits
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%d %s", m.AmountCents, m.Currency)
+}
+
+// moneyJSON has Money's fields without its methods, avoiding MarshalJSON recursion.
+type moneyJSON Money
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON(NewMoney(m.AmountCents, m.Currency)))
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var decoded moneyJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*m = NewMoney(decoded.AmountCents, decoded.Currency)
+	return nil
+}
+
+func normalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
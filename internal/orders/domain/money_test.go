@@ -0,0 +1,128 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+)
+
+func TestNewMoney(t *testing.T) {
+	t.Run("normalizes currency", func(t *testing.T) {
+		if got := domain.NewMoney(100, " eur "); got.Currency != "EUR" {
+			t.Errorf("expected currency EUR, got %q", got.Currency)
+		}
+	})
+
+	t.Run("defaults empty currency", func(t *testing.T) {
+		if got := domain.NewMoney(100, ""); got.Currency != domain.DefaultCurrency {
+			t.Errorf("expected currency %s, got %q", domain.DefaultCurrency, got.Currency)
+		}
+	})
+}
+
+func TestAddMoney(t *testing.T) {
+	t.Run("adds amounts in the same currency", func(t *testing.T) {
+		sum, err := domain.NewMoney(150, "USD").Add(domain.NewMoney(250, "usd"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if sum != domain.NewMoney(400, "USD") {
+			t.Errorf("expected 400 USD, got %s", sum)
+		}
+	})
+
+	t.Run("rejects mixed currencies", func(t *testing.T) {
+		_, err := domain.NewMoney(150, "USD").Add(domain.NewMoney(250, "EUR"))
+		if !errors.Is(err, domain.ErrCurrencyMismatch) {
+			t.Errorf("expected ErrCurrencyMismatch, got %v", err)
+		}
+	})
+}
+
+func TestMoneyIsPositive(t *testing.T) {
+	tests := []struct {
+		amount int64
+		want   bool
+	}{
+		{1, true},
+		{0, false},
+		{-1, false},
+	}
+
+	for _, tt := range tests {
+		if got := domain.NewMoney(tt.amount, "USD").IsPositive(); got != tt.want {
+			t.Errorf("Money{%d}.IsPositive() = %v, want %v", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	t.Run("marshals amount and currency", func(t *testing.T) {
+		data, err := json.Marshal(domain.NewMoney(1299, "USD"))
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+		if string(data) != `{"amount_cents":1299,"currency":"USD"}` {
+			t.Errorf("unexpected JSON %s", data)
+		}
+	})
+
+	t.Run("unmarshals and normalizes currency", func(t *testing.T) {
+		var m domain.Money
+		if err := json.Unmarshal([]byte(`{"amount_cents":500,"currency":"gbp"}`), &m); err != nil {
+			t.Fatalf("failed to unmarshal: %v", err)
+		}
+		if m != domain.NewMoney(500, "GBP") {
+			t.Errorf("expected 500 GBP, got %s", m)
+		}
+	})
+}
+
+func TestValidateCurrency(t *testing.T) {
+	tests := []struct {
+		currency string
+		wantErr  bool
+	}{
+		{"USD", false},
+		{"usd", true},
+		{"US", true},
+		{"", true},
+		{"US1", true},
+		{"JPY", false},
+		{"ZZZ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			if err := domain.ValidateCurrency(tt.currency); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCurrency(%q) error = %v, wantErr %v", tt.currency, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormattedMoney(t *testing.T) {
+	tests := []struct {
+		name  string
+		money domain.Money
+		want  string
+	}{
+		{name: "two decimal places", money: domain.NewMoney(1999, "USD"), want: "$19.99"},
+		{name: "pads sub-unit amounts", money: domain.NewMoney(5, "EUR"), want: "€0.05"},
+		{name: "zero decimal places", money: domain.NewMoney(1999, "JPY"), want: "¥1999"},
+		{name: "three decimal places", money: domain.NewMoney(1999, "BHD"), want: "BHD 1.999"},
+		{name: "currency without a symbol", money: domain.NewMoney(123456, "CHF"), want: "CHF 1234.56"},
+		{name: "negative amounts", money: domain.NewMoney(-250, "GBP"), want: "-£2.50"},
+		{name: "defaults the currency", money: domain.Money{AmountCents: 100}, want: "$1.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.money.Formatted(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 )
@@ -17,30 +18,202 @@ const (
 	StatusCanceled   OrderStatus = "canceled"
 )
 
+var (
+	// ErrInvalidTransition is returned when an order cannot move to the requested status.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrBlockedEmailDomain is returned when the customer email uses a blocklisted domain.
+	ErrBlockedEmailDomain = errors.New("customer_email domain is not allowed")
+	// ErrValidation matches every ValidationError.
+	ErrValidation = errors.New("validation failed")
+)
+
+// ValidationError reports invalid input. Its message is the violated rule, and it matches
+// ErrValidation with errors.Is.
+type ValidationError struct {
+	Message string
+}
+
+// NewValidationError returns a ValidationError for the given rule violation.
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// IsValid reports whether the status is one of the known lifecycle statuses.
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case StatusPending, StatusProcessing, StatusCompleted, StatusFailed, StatusCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// allowedTransitions maps each status to the statuses it may move to.
+var allowedTransitions = map[OrderStatus][]OrderStatus{
+	StatusPending:    {StatusProcessing, StatusCanceled},
+	StatusProcessing: {StatusCompleted, StatusFailed},
+}
+
 // Order represents a purchase request managed by the system.
 type Order struct {
 	ID            string      `json:"id"`
-	CustomerEmail string      `json:"customer_email"`
-	AmountCents   int64       `json:"amount_cents"`
+	Reference     string      `json:"reference"`
+	CustomerID    string      `json:"customer_id"`
+	CustomerEmail string      `json:"customer_email"` // display only; may change, unlike CustomerID
+	Amount        Money       `json:"amount"`
 	Status        OrderStatus `json:"status"`
 	CreatedAt     time.Time   `json:"created_at"`
 	UpdatedAt     time.Time   `json:"updated_at"`
+	// Metadata holds client-supplied key/values such as a cart ID or campaign.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// IdempotencyKey is the key of the create request that produced the order, if any.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
-// Validate ensures the order adheres to business constraints.
-func (o Order) Validate() error {
-	if strings.TrimSpace(o.CustomerEmail) == "" {
-		return errors.New("customer_email is required")
+// Limits on client-supplied order metadata.
+const (
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 40
+	MaxMetadataValueLength = 500
+)
+
+// ValidateMetadata enforces the metadata size limits.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataKeys {
+		return NewValidationError(fmt.Sprintf("metadata must have at most %d keys", MaxMetadataKeys))
+	}
+	for key, value := range metadata {
+		if strings.TrimSpace(key) == "" {
+			return NewValidationError("metadata keys must not be empty")
+		}
+		if len(key) > MaxMetadataKeyLength {
+			return NewValidationError(fmt.Sprintf("metadata key %q exceeds %d characters", key, MaxMetadataKeyLength))
+		}
+		if len(value) > MaxMetadataValueLength {
+			return NewValidationError(fmt.Sprintf("metadata value for %q exceeds %d characters", key, MaxMetadataValueLength))
+		}
+	}
+	return nil
+}
+
+// DefaultMaxEmailLength is the longest customer email accepted unless MaxEmailLength says
+// otherwise; 254 is the longest address SMTP can deliver to.
+const DefaultMaxEmailLength = 254
+
+// MaxReferenceLength bounds order references, which FormatReference keeps well below it.
+const MaxReferenceLength = 32
+
+// FormatReference builds the human-readable order reference, e.g. ORD-2024-000123.
+func FormatReference(year int, seq int64) string {
+	return fmt.Sprintf("ORD-%04d-%06d", year, seq)
+}
+
+// StatusTransition records a single change of an order's status.
+type StatusTransition struct {
+	From      OrderStatus `json:"from"`
+	To        OrderStatus `json:"to"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+// ValidateOption relaxes order validation rules.
+type ValidateOption func(*validateOptions)
+
+type validateOptions struct {
+	allowZeroAmount bool
+	maxEmailLength  int
+}
+
+func newValidateOptions(opts []ValidateOption) validateOptions {
+	options := validateOptions{maxEmailLength: DefaultMaxEmailLength}
+	for _, opt := range opts {
+		opt(&options)
 	}
-	if !strings.Contains(o.CustomerEmail, "@") {
-		return errors.New("customer_email must be valid")
+	return options
+}
+
+// AllowZeroAmount accepts amount_cents == 0 for free orders; negative amounts are still
+// rejected.
+func AllowZeroAmount(allow bool) ValidateOption {
+	return func(o *validateOptions) {
+		o.allowZeroAmount = allow
 	}
-	if o.AmountCents <= 0 {
-		return errors.New("amount_cents must be positive")
+}
+
+// MaxEmailLength overrides DefaultMaxEmailLength. Non-positive values keep the default.
+func MaxEmailLength(length int) ValidateOption {
+	return func(o *validateOptions) {
+		if length > 0 {
+			o.maxEmailLength = length
+		}
+	}
+}
+
+// ValidateEmail requires a customer email containing "@" and no longer than the configured
+// maximum.
+func ValidateEmail(email string, opts ...ValidateOption) error {
+	options := newValidateOptions(opts)
+
+	if strings.TrimSpace(email) == "" {
+		return NewValidationError("customer_email is required")
+	}
+	if len(email) > options.maxEmailLength {
+		return NewValidationError(fmt.Sprintf("customer_email must be at most %d characters", options.maxEmailLength))
+	}
+	if !strings.Contains(email, "@") {
+		return NewValidationError("customer_email must be valid")
+	}
+	return nil
+}
+
+// ValidateAmount rejects non-positive amounts, or only negative ones under AllowZeroAmount.
+func ValidateAmount(amountCents int64, opts ...ValidateOption) error {
+	options := newValidateOptions(opts)
+
+	if options.allowZeroAmount {
+		if amountCents < 0 {
+			return NewValidationError("amount_cents must not be negative")
+		}
+		return nil
+	}
+	if amountCents <= 0 {
+		return NewValidationError("amount_cents must be positive")
 	}
 	return nil
 }
 
+// Validate ensures the order adheres to business constraints.
+func (o Order) Validate(opts ...ValidateOption) error {
+	if strings.TrimSpace(o.CustomerID) == "" {
+		return NewValidationError("customer_id is required")
+	}
+	if err := ValidateEmail(o.CustomerEmail, opts...); err != nil {
+		return err
+	}
+	if len(o.Reference) > MaxReferenceLength {
+		return NewValidationError(fmt.Sprintf("reference must be at most %d characters", MaxReferenceLength))
+	}
+	if err := ValidateAmount(o.Amount.AmountCents, opts...); err != nil {
+		return err
+	}
+	if err := ValidateCurrency(o.Amount.Currency); err != nil {
+		return err
+	}
+	return ValidateMetadata(o.Metadata)
+}
+
+// FormattedAmount renders the order amount for display, e.g. "$19.99".
+func (o Order) FormattedAmount() string {
+	return o.Amount.Formatted()
+}
+
 // IsTerminal indicates whether the order is in a terminal state.
 func (o Order) IsTerminal() bool {
 	switch o.Status {
@@ -50,3 +223,61 @@ func (o Order) IsTerminal() bool {
 		return false
 	}
 }
+
+// CanTransitionTo reports whether the order may move from its current status to next.
+func (o Order) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range allowedTransitions[o.Status] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCancellable reports whether the order may still be canceled.
+func (o Order) IsCancellable() bool {
+	return o.CanTransitionTo(StatusCanceled)
+}
+
+// CanReprocess reports whether a failed order may be sent back to pending for another
+// attempt. Failed orders whose data no longer passes validation under opts are truly
+// terminal, so opts should match the ones orders are created with.
+func (o Order) CanReprocess(opts ...ValidateOption) bool {
+	return o.Status == StatusFailed && o.Validate(opts...) == nil
+}
+
+// EmailDomainBlocklist holds lower-cased email domains that may not place orders.
+type EmailDomainBlocklist map[string]struct{}
+
+// NewEmailDomainBlocklist builds a blocklist, normalizing domains to lower case.
+func NewEmailDomainBlocklist(domains []string) EmailDomainBlocklist {
+	blocklist := make(EmailDomainBlocklist, len(domains))
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" {
+			continue
+		}
+		blocklist[d] = struct{}{}
+	}
+	return blocklist
+}
+
+// Check returns ErrBlockedEmailDomain when the domain part of email is blocklisted.
+func (b EmailDomainBlocklist) Check(email string) error {
+	if len(b) == 0 {
+		return nil
+	}
+	if _, blocked := b[EmailDomain(email)]; blocked {
+		return ErrBlockedEmailDomain
+	}
+	return nil
+}
+
+// EmailDomain returns the lower-cased domain part of an email address.
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
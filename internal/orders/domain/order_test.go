@@ -1,6 +1,9 @@
 package domain_test
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,20 +20,32 @@ func TestValidateOrder(t *testing.T) {
 			name: "valid order",
 			order: domain.Order{
 				ID:            "test-id",
+				CustomerID:    "customer-1",
 				CustomerEmail: "user@example.com",
-				AmountCents:   1000,
+				Amount:        domain.NewMoney(1000, "USD"),
 				Status:        domain.StatusPending,
 				CreatedAt:     time.Now(),
 				UpdatedAt:     time.Now(),
 			},
 			wantErr: false,
 		},
+		{
+			name: "missing customer id",
+			order: domain.Order{
+				ID:            "test-id",
+				CustomerEmail: "user@example.com",
+				Amount:        domain.NewMoney(1000, "USD"),
+				Status:        domain.StatusPending,
+			},
+			wantErr: true,
+		},
 		{
 			name: "missing email",
 			order: domain.Order{
-				ID:          "test-id",
-				AmountCents: 1000,
-				Status:      domain.StatusPending,
+				ID:         "test-id",
+				CustomerID: "customer-1",
+				Amount:     domain.NewMoney(1000, "USD"),
+				Status:     domain.StatusPending,
 			},
 			wantErr: true,
 		},
@@ -38,8 +53,9 @@ func TestValidateOrder(t *testing.T) {
 			name: "whitespace only email",
 			order: domain.Order{
 				ID:            "test-id",
+				CustomerID:    "customer-1",
 				CustomerEmail: "   ",
-				AmountCents:   1000,
+				Amount:        domain.NewMoney(1000, "USD"),
 				Status:        domain.StatusPending,
 			},
 			wantErr: true,
@@ -48,8 +64,32 @@ func TestValidateOrder(t *testing.T) {
 			name: "invalid email format",
 			order: domain.Order{
 				ID:            "test-id",
+				CustomerID:    "customer-1",
 				CustomerEmail: "notanemail",
-				AmountCents:   1000,
+				Amount:        domain.NewMoney(1000, "USD"),
+				Status:        domain.StatusPending,
+			},
+			wantErr: true,
+		},
+		{
+			name: "email longer than 254 characters",
+			order: domain.Order{
+				ID:            "test-id",
+				CustomerID:    "customer-1",
+				CustomerEmail: strings.Repeat("a", 243) + "@example.com",
+				Amount:        domain.NewMoney(1000, "USD"),
+				Status:        domain.StatusPending,
+			},
+			wantErr: true,
+		},
+		{
+			name: "reference longer than the maximum",
+			order: domain.Order{
+				ID:            "test-id",
+				Reference:     strings.Repeat("R", domain.MaxReferenceLength+1),
+				CustomerID:    "customer-1",
+				CustomerEmail: "user@example.com",
+				Amount:        domain.NewMoney(1000, "USD"),
 				Status:        domain.StatusPending,
 			},
 			wantErr: true,
@@ -58,8 +98,9 @@ func TestValidateOrder(t *testing.T) {
 			name: "zero amount",
 			order: domain.Order{
 				ID:            "test-id",
+				CustomerID:    "customer-1",
 				CustomerEmail: "user@example.com",
-				AmountCents:   0,
+				Amount:        domain.NewMoney(0, "USD"),
 				Status:        domain.StatusPending,
 			},
 			wantErr: true,
@@ -68,8 +109,9 @@ func TestValidateOrder(t *testing.T) {
 			name: "negative amount",
 			order: domain.Order{
 				ID:            "test-id",
+				CustomerID:    "customer-1",
 				CustomerEmail: "user@example.com",
-				AmountCents:   -100,
+				Amount:        domain.NewMoney(-100, "USD"),
 				Status:        domain.StatusPending,
 			},
 			wantErr: true,
@@ -86,6 +128,36 @@ func TestValidateOrder(t *testing.T) {
 	}
 }
 
+func TestValidateEmail(t *testing.T) {
+	atLimit := strings.Repeat("a", domain.DefaultMaxEmailLength-len("@example.com")) + "@example.com"
+
+	tests := []struct {
+		name    string
+		email   string
+		opts    []domain.ValidateOption
+		wantErr bool
+	}{
+		{name: "valid", email: "user@example.com"},
+		{name: "at the default limit", email: atLimit},
+		{name: "over the default limit", email: "a" + atLimit, wantErr: true},
+		{name: "over a configured limit", email: "user@example.com", opts: []domain.ValidateOption{domain.MaxEmailLength(10)}, wantErr: true},
+		{name: "non-positive limit keeps the default", email: atLimit, opts: []domain.ValidateOption{domain.MaxEmailLength(0)}},
+		{name: "missing @", email: "user.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateEmail(tt.email, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEmail() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrValidation) {
+				t.Errorf("expected a validation error, got %v", err)
+			}
+		})
+	}
+}
+
 func TestCheckTerminalStatus(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -108,3 +180,164 @@ func TestCheckTerminalStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckStatusTransition(t *testing.T) {
+	tests := []struct {
+		name string
+		from domain.OrderStatus
+		to   domain.OrderStatus
+		want bool
+	}{
+		{"pending can be canceled", domain.StatusPending, domain.StatusCanceled, true},
+		{"pending can start processing", domain.StatusPending, domain.StatusProcessing, true},
+		{"processing can complete", domain.StatusProcessing, domain.StatusCompleted, true},
+		{"processing can fail", domain.StatusProcessing, domain.StatusFailed, true},
+		{"processing cannot be canceled", domain.StatusProcessing, domain.StatusCanceled, false},
+		{"completed cannot be canceled", domain.StatusCompleted, domain.StatusCanceled, false},
+		{"canceled cannot be canceled again", domain.StatusCanceled, domain.StatusCanceled, false},
+		{"failed cannot move back to pending", domain.StatusFailed, domain.StatusPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := domain.Order{Status: tt.from}
+			if got := order.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("Order.CanTransitionTo(%s) = %v, want %v", tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCancellable(t *testing.T) {
+	tests := []struct {
+		name   string
+		status domain.OrderStatus
+		want   bool
+	}{
+		{"pending is cancellable", domain.StatusPending, true},
+		{"processing is not cancellable", domain.StatusProcessing, false},
+		{"completed is not cancellable", domain.StatusCompleted, false},
+		{"failed is not cancellable", domain.StatusFailed, false},
+		{"canceled is not cancellable", domain.StatusCanceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := domain.Order{Status: tt.status}
+			if got := order.IsCancellable(); got != tt.want {
+				t.Errorf("Order.IsCancellable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckReprocessable(t *testing.T) {
+	tests := []struct {
+		name  string
+		order domain.Order
+		opts  []domain.ValidateOption
+		want  bool
+	}{
+		{"valid failed order", domain.Order{Status: domain.StatusFailed, CustomerID: "customer-1", CustomerEmail: "a@b.com", Amount: domain.NewMoney(100, "USD")}, nil, true},
+		{"failed order with invalid data", domain.Order{Status: domain.StatusFailed, CustomerID: "customer-1", CustomerEmail: "a@b.com"}, nil, false},
+		{"free failed order with zero amounts allowed", domain.Order{Status: domain.StatusFailed, CustomerID: "customer-1", CustomerEmail: "a@b.com", Amount: domain.NewMoney(0, "USD")}, []domain.ValidateOption{domain.AllowZeroAmount(true)}, true},
+		{"failed order with an email over the configured length", domain.Order{Status: domain.StatusFailed, CustomerID: "customer-1", CustomerEmail: "customer@example.com", Amount: domain.NewMoney(100, "USD")}, []domain.ValidateOption{domain.MaxEmailLength(10)}, false},
+		{"completed order", domain.Order{Status: domain.StatusCompleted, CustomerID: "customer-1", CustomerEmail: "a@b.com", Amount: domain.NewMoney(100, "USD")}, nil, false},
+		{"pending order", domain.Order{Status: domain.StatusPending, CustomerID: "customer-1", CustomerEmail: "a@b.com", Amount: domain.NewMoney(100, "USD")}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.order.CanReprocess(tt.opts...); got != tt.want {
+				t.Errorf("Order.CanReprocess() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatReference(t *testing.T) {
+	if got := domain.FormatReference(2024, 123); got != "ORD-2024-000123" {
+		t.Errorf("FormatReference() = %s, want ORD-2024-000123", got)
+	}
+	if got := domain.FormatReference(2024, 1234567); got != "ORD-2024-1234567" {
+		t.Errorf("FormatReference() = %s, want ORD-2024-1234567", got)
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= domain.MaxMetadataKeys; i++ {
+		tooMany[fmt.Sprintf("key-%d", i)] = "value"
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  bool
+	}{
+		{"nil metadata", nil, false},
+		{"within limits", map[string]string{"cart_id": "cart-42"}, false},
+		{"too many keys", tooMany, true},
+		{"empty key", map[string]string{" ": "value"}, true},
+		{"key too long", map[string]string{strings.Repeat("k", domain.MaxMetadataKeyLength+1): "value"}, true},
+		{"value too long", map[string]string{"note": strings.Repeat("v", domain.MaxMetadataValueLength+1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateMetadata(tt.metadata)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrValidation) {
+				t.Errorf("expected ErrValidation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	t.Run("matches ErrValidation and keeps rule message", func(t *testing.T) {
+		err := domain.Order{CustomerID: "customer-1", CustomerEmail: "a@b.com"}.Validate()
+
+		if !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("expected ErrValidation, got %v", err)
+		}
+
+		var validationErr *domain.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected ValidationError, got %T", err)
+		}
+		if validationErr.Message != "amount_cents must be positive" {
+			t.Errorf("expected rule message, got %q", validationErr.Message)
+		}
+	})
+}
+
+func TestCheckEmailDomainBlocklist(t *testing.T) {
+	blocklist := domain.NewEmailDomainBlocklist([]string{" Mailinator.com ", "", "tempmail.io"})
+
+	tests := []struct {
+		name    string
+		email   string
+		wantErr bool
+	}{
+		{"allows unlisted domain", "user@example.com", false},
+		{"rejects listed domain", "user@mailinator.com", true},
+		{"matches domain case-insensitively", "user@TempMail.IO", true},
+		{"ignores local part", "mailinator.com@example.com", false},
+		{"allows email without domain", "user", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := blocklist.Check(tt.email)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EmailDomainBlocklist.Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, domain.ErrBlockedEmailDomain) {
+				t.Errorf("expected ErrBlockedEmailDomain, got %v", err)
+			}
+		})
+	}
+}
@@ -8,9 +8,13 @@ import (
 	"go.opentelemetry.io/otel/metric"
 )
 
+// ScopeName is the instrumentation scope to create the meter for order business metrics with.
+const ScopeName = "github.com/dejobratic/tbd/internal/orders/metrics"
+
 type Metrics struct {
 	ordersCreatedTotal    metric.Int64Counter
 	orderCreationDuration metric.Float64Histogram
+	orderCreationPhase    metric.Float64Histogram
 }
 
 func NewMetrics(meter metric.Meter) (*Metrics, error) {
@@ -36,6 +40,15 @@ func NewMetrics(meter metric.Meter) (*Metrics, error) {
 		return nil, fmt.Errorf("create order_creation_duration histogram: %w", err)
 	}
 
+	m.orderCreationPhase, err = meter.Float64Histogram(
+		"order_creation_phase_duration_seconds",
+		metric.WithDescription("Duration of each phase of order creation"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create order_creation_phase_duration histogram: %w", err)
+	}
+
 	return m, nil
 }
 
@@ -52,3 +65,11 @@ func (m *Metrics) RecordOrderCreated(ctx context.Context, success bool) {
 func (m *Metrics) RecordOrderCreationDuration(ctx context.Context, durationSeconds float64) {
 	m.orderCreationDuration.Record(ctx, durationSeconds)
 }
+
+// RecordOrderCreationPhase records how long one phase of order creation (validate, persist,
+// publish) took.
+func (m *Metrics) RecordOrderCreationPhase(ctx context.Context, phase string, durationSeconds float64) {
+	m.orderCreationPhase.Record(ctx, durationSeconds, metric.WithAttributes(
+		attribute.String("phase", phase),
+	))
+}
@@ -121,3 +121,47 @@ func TestRecordOrderCreationDuration(t *testing.T) {
 		}
 	})
 }
+
+func TestRecordOrderCreationPhase(t *testing.T) {
+	t.Run("records one series per phase", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+		meter := mp.Meter("test")
+
+		metrics, err := NewMetrics(meter)
+		if err != nil {
+			t.Fatalf("NewMetrics() failed: %v", err)
+		}
+
+		ctx := context.Background()
+
+		metrics.RecordOrderCreationPhase(ctx, "validate", 0.001)
+		metrics.RecordOrderCreationPhase(ctx, "persist", 0.02)
+		metrics.RecordOrderCreationPhase(ctx, "persist", 0.03)
+
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			t.Fatalf("Failed to collect metrics: %v", err)
+		}
+
+		found := false
+		for _, sm := range rm.ScopeMetrics {
+			for _, m := range sm.Metrics {
+				if m.Name == "order_creation_phase_duration_seconds" {
+					found = true
+					histogram, ok := m.Data.(metricdata.Histogram[float64])
+					if !ok {
+						t.Fatal("Expected Histogram[float64] data type")
+					}
+					if len(histogram.DataPoints) != 2 {
+						t.Errorf("Expected 2 data points, got %d", len(histogram.DataPoints))
+					}
+				}
+			}
+		}
+
+		if !found {
+			t.Error("order_creation_phase_duration_seconds metric not found")
+		}
+	})
+}
@@ -0,0 +1,15 @@
+package ports
+
+import "time"
+
+// Clock tells the time. Code that stamps orders takes one so tests can pin timestamps.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock reads the wall clock, in UTC.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
@@ -7,4 +7,7 @@ type EventBus interface {
 	PublishOrderCreated(ctx context.Context, orderID string) error
 	PublishOrderProcessed(ctx context.Context, orderID string) error
 	PublishOrderFailed(ctx context.Context, orderID string, reason string) error
+	// HealthCheck reports whether events can currently be published, so readiness can
+	// check the bus without knowing its implementation.
+	HealthCheck(ctx context.Context) error
 }
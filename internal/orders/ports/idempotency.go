@@ -1,16 +1,24 @@
 package ports
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // StoredResponse contains the response data to replay for a reused key.
 type StoredResponse struct {
 	StatusCode int
 	Body       []byte
 	OrderID    string
+	// RetryAfter is the processing estimate sent with a 202, kept so replays advertise the
+	// same back-off as the original response.
+	RetryAfter time.Duration
 }
 
 // IdempotencyStore ensures create operations can be retried safely.
 type IdempotencyStore interface {
 	Get(ctx context.Context, key string) (*StoredResponse, error)
 	Save(ctx context.Context, key string, response StoredResponse) error
+	// CountSince returns how many keys were first saved at or after since.
+	CountSince(ctx context.Context, since time.Time) (int64, error)
 }
@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxMessage is an event persisted alongside a state change and relayed to the event bus later.
+type OutboxMessage struct {
+	ID        string
+	Topic     string
+	Key       string
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// OutboxStore records events that must be published once the surrounding transaction commits.
+type OutboxStore interface {
+	Add(ctx context.Context, msg OutboxMessage) error
+}
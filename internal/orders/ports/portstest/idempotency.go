@@ -0,0 +1,123 @@
+package portstest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// RunIdempotencyStoreTests exercises the IdempotencyStore contract. newStore must return an
+// empty store for every call.
+func RunIdempotencyStoreTests(t *testing.T, newStore func(t *testing.T) ports.IdempotencyStore) {
+	t.Helper()
+
+	t.Run("saves and retrieves a response", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		want := ports.StoredResponse{
+			StatusCode: 202,
+			Body:       []byte(`{"order":{"id":"order-1"}}`),
+			OrderID:    "order-1",
+			RetryAfter: 2 * time.Second,
+		}
+
+		if err := store.Save(ctx, "key-1", want); err != nil {
+			t.Fatalf("failed to save response: %v", err)
+		}
+
+		got, err := store.Get(ctx, "key-1")
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		if got == nil {
+			t.Fatal("expected stored response, got nil")
+		}
+		if got.StatusCode != want.StatusCode || string(got.Body) != string(want.Body) ||
+			got.OrderID != want.OrderID || got.RetryAfter != want.RetryAfter {
+			t.Errorf("expected %+v, got %+v", want, *got)
+		}
+	})
+
+	t.Run("returns nil for an unknown key", func(t *testing.T) {
+		store := newStore(t)
+
+		got, err := store.Get(context.Background(), "missing")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if got != nil {
+			t.Errorf("expected nil response, got %+v", *got)
+		}
+	})
+
+	t.Run("keeps the first response when a key is saved twice", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		first := ports.StoredResponse{StatusCode: 202, Body: []byte(`{"order":{"id":"order-1"}}`), OrderID: "order-1"}
+		second := ports.StoredResponse{StatusCode: 200, Body: []byte(`{"order":{"id":"order-2"}}`), OrderID: "order-2"}
+
+		if err := store.Save(ctx, "key-1", first); err != nil {
+			t.Fatalf("failed to save first response: %v", err)
+		}
+		if err := store.Save(ctx, "key-1", second); err != nil {
+			t.Fatalf("expected duplicate save to succeed, got %v", err)
+		}
+
+		got, err := store.Get(ctx, "key-1")
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		if got == nil || got.OrderID != first.OrderID || got.StatusCode != first.StatusCode {
+			t.Errorf("expected first response to win, got %+v", got)
+		}
+	})
+
+	t.Run("counts keys saved since a time", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+		before := time.Now().Add(-time.Minute)
+
+		for _, key := range []string{"key-a", "key-b", "key-b"} {
+			if err := store.Save(ctx, key, ports.StoredResponse{StatusCode: 202, Body: []byte(`{}`), OrderID: "order-" + key}); err != nil {
+				t.Fatalf("failed to save %s: %v", key, err)
+			}
+		}
+
+		count, err := store.CountSince(ctx, before)
+		if err != nil {
+			t.Fatalf("failed to count keys: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("expected 2 keys, got %d", count)
+		}
+
+		count, err = store.CountSince(ctx, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("failed to count keys: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("expected no keys after the window, got %d", count)
+		}
+	})
+
+	t.Run("keeps keys independent", func(t *testing.T) {
+		store := newStore(t)
+		ctx := context.Background()
+
+		for _, key := range []string{"key-a", "key-b"} {
+			if err := store.Save(ctx, key, ports.StoredResponse{StatusCode: 202, Body: []byte(`{}`), OrderID: "order-" + key}); err != nil {
+				t.Fatalf("failed to save %s: %v", key, err)
+			}
+		}
+
+		got, err := store.Get(ctx, "key-b")
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		if got == nil || got.OrderID != "order-key-b" {
+			t.Errorf("expected order-key-b, got %+v", got)
+		}
+	})
+}
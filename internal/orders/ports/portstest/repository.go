@@ -0,0 +1,452 @@
+// Package portstest provides conformance suites shared by every implementation of the
+// orders ports, so that backends stay behaviorally interchangeable.
+package portstest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+// RunOrderRepositoryTests exercises the OrderRepository contract. newRepo must return an
+// empty repository for every call.
+func RunOrderRepositoryTests(t *testing.T, newRepo func(t *testing.T) ports.OrderRepository) {
+	t.Helper()
+
+	// Postgres stores microsecond precision, so fixtures avoid finer timestamps.
+	base := time.Now().UTC().Truncate(time.Second)
+
+	fixtures := []domain.Order{
+		newOrder("order-1", "alice@example.com", domain.StatusPending, base),
+		newOrder("order-2", "bob@example.com", domain.StatusCompleted, base.Add(1*time.Hour)),
+		newOrder("order-3", "Alice@Example.com", domain.StatusFailed, base.Add(2*time.Hour)),
+		newOrder("order-4", "carol@example.com", domain.StatusPending, base.Add(3*time.Hour)),
+	}
+
+	seed := func(t *testing.T) ports.OrderRepository {
+		t.Helper()
+		repo := newRepo(t)
+		for _, order := range fixtures {
+			if err := repo.Create(context.Background(), order); err != nil {
+				t.Fatalf("failed to seed order %s: %v", order.ID, err)
+			}
+		}
+		return repo
+	}
+
+	t.Run("creates and retrieves order", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		order := fixtures[0]
+
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		if got.ID != order.ID || got.Reference != order.Reference || got.CustomerID != order.CustomerID || got.CustomerEmail != order.CustomerEmail ||
+			got.Amount.AmountCents != order.Amount.AmountCents || got.Status != order.Status {
+			t.Errorf("expected %+v, got %+v", order, *got)
+		}
+		if !got.CreatedAt.Equal(order.CreatedAt) {
+			t.Errorf("expected created_at %v, got %v", order.CreatedAt, got.CreatedAt)
+		}
+	})
+
+	t.Run("round-trips metadata", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		order := newOrder("order-meta", "meta@example.com", domain.StatusPending, base)
+		order.Metadata = map[string]string{"cart_id": "cart-42", "campaign": "spring"}
+
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("failed to create order: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		if len(got.Metadata) != 2 || got.Metadata["cart_id"] != "cart-42" || got.Metadata["campaign"] != "spring" {
+			t.Errorf("expected metadata %v, got %v", order.Metadata, got.Metadata)
+		}
+	})
+
+	t.Run("round-trips idempotency key", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		keyed := newOrder("order-keyed", "keyed@example.com", domain.StatusPending, base)
+		keyed.IdempotencyKey = "key-42"
+		unkeyed := newOrder("order-unkeyed", "unkeyed@example.com", domain.StatusPending, base)
+
+		for _, order := range []domain.Order{keyed, unkeyed} {
+			if err := repo.Create(ctx, order); err != nil {
+				t.Fatalf("failed to create order %s: %v", order.ID, err)
+			}
+			got, err := repo.GetByID(ctx, order.ID)
+			if err != nil {
+				t.Fatalf("failed to get order %s: %v", order.ID, err)
+			}
+			if got.IdempotencyKey != order.IdempotencyKey {
+				t.Errorf("expected idempotency key %q, got %q", order.IdempotencyKey, got.IdempotencyKey)
+			}
+		}
+	})
+
+	t.Run("returns not found for unknown order", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetByID(context.Background(), "missing")
+		if !errors.Is(err, ports.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("retrieves many orders by ID, skipping unknown ones", func(t *testing.T) {
+		repo := seed(t)
+
+		got, err := repo.GetByIDs(context.Background(), []string{"order-3", "missing", "order-1"})
+		if err != nil {
+			t.Fatalf("failed to get orders: %v", err)
+		}
+		if len(got) != 2 || got["order-1"].ID != "order-1" || got["order-3"].ID != "order-3" {
+			t.Errorf("expected order-1 and order-3, got %v", got)
+		}
+
+		empty, err := repo.GetByIDs(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("failed to get no orders: %v", err)
+		}
+		if len(empty) != 0 {
+			t.Errorf("expected no orders, got %v", empty)
+		}
+	})
+
+	t.Run("retrieves order by reference", func(t *testing.T) {
+		repo := seed(t)
+
+		got, err := repo.GetByReference(context.Background(), "REF-order-2")
+		if err != nil {
+			t.Fatalf("failed to get order by reference: %v", err)
+		}
+		if got.ID != "order-2" {
+			t.Errorf("expected order-2, got %s", got.ID)
+		}
+
+		if _, err := repo.GetByReference(context.Background(), "REF-missing"); !errors.Is(err, ports.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("rejects duplicate reference", func(t *testing.T) {
+		repo := seed(t)
+		order := newOrder("order-5", "dave@example.com", domain.StatusPending, base)
+		order.Reference = fixtures[0].Reference
+
+		if err := repo.Create(context.Background(), order); err == nil {
+			t.Error("expected error for duplicate reference")
+		}
+	})
+
+	t.Run("reports whether order exists", func(t *testing.T) {
+		repo := seed(t)
+		ctx := context.Background()
+
+		exists, err := repo.Exists(ctx, "order-1")
+		if err != nil {
+			t.Fatalf("failed to check existence: %v", err)
+		}
+		if !exists {
+			t.Error("expected order-1 to exist")
+		}
+
+		exists, err = repo.Exists(ctx, "missing")
+		if err != nil {
+			t.Fatalf("failed to check existence: %v", err)
+		}
+		if exists {
+			t.Error("expected missing order not to exist")
+		}
+	})
+
+	t.Run("updates status", func(t *testing.T) {
+		repo := seed(t)
+		ctx := context.Background()
+
+		if err := repo.UpdateStatus(ctx, "order-1", domain.StatusProcessing); err != nil {
+			t.Fatalf("failed to update status: %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, "order-1")
+		if err != nil {
+			t.Fatalf("failed to get order: %v", err)
+		}
+		if got.Status != domain.StatusProcessing {
+			t.Errorf("expected status processing, got %s", got.Status)
+		}
+	})
+
+	t.Run("records status history on update", func(t *testing.T) {
+		repo := seed(t)
+		ctx := context.Background()
+
+		for _, status := range []domain.OrderStatus{domain.StatusProcessing, domain.StatusFailed} {
+			if err := repo.UpdateStatus(ctx, "order-1", status); err != nil {
+				t.Fatalf("failed to update status: %v", err)
+			}
+		}
+
+		history, err := repo.GetStatusHistory(ctx, "order-1")
+		if err != nil {
+			t.Fatalf("failed to get status history: %v", err)
+		}
+		want := []domain.StatusTransition{
+			{From: domain.StatusPending, To: domain.StatusProcessing},
+			{From: domain.StatusProcessing, To: domain.StatusFailed},
+		}
+		if len(history) != len(want) {
+			t.Fatalf("expected %d transitions, got %d", len(want), len(history))
+		}
+		for i, transition := range history {
+			if transition.From != want[i].From || transition.To != want[i].To {
+				t.Errorf("expected transition %d to be %s -> %s, got %s -> %s",
+					i, want[i].From, want[i].To, transition.From, transition.To)
+			}
+			if transition.ChangedAt.IsZero() {
+				t.Errorf("expected transition %d to have a timestamp", i)
+			}
+		}
+	})
+
+	t.Run("appends status history", func(t *testing.T) {
+		repo := seed(t)
+		ctx := context.Background()
+		transition := domain.StatusTransition{From: domain.StatusPending, To: domain.StatusCanceled, ChangedAt: base}
+
+		if err := repo.AppendStatusHistory(ctx, "order-1", transition); err != nil {
+			t.Fatalf("failed to append status history: %v", err)
+		}
+
+		history, err := repo.GetStatusHistory(ctx, "order-1")
+		if err != nil {
+			t.Fatalf("failed to get status history: %v", err)
+		}
+		if len(history) != 1 || history[0].To != domain.StatusCanceled || !history[0].ChangedAt.Equal(base) {
+			t.Errorf("expected appended transition, got %+v", history)
+		}
+	})
+
+	t.Run("returns empty history for unchanged order", func(t *testing.T) {
+		repo := seed(t)
+
+		history, err := repo.GetStatusHistory(context.Background(), "order-1")
+		if err != nil {
+			t.Fatalf("failed to get status history: %v", err)
+		}
+		if len(history) != 0 {
+			t.Errorf("expected empty history, got %+v", history)
+		}
+	})
+
+	t.Run("returns not found for history of unknown order", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		if _, err := repo.GetStatusHistory(ctx, "missing"); !errors.Is(err, ports.ErrNotFound) {
+			t.Errorf("expected ErrNotFound from GetStatusHistory, got %v", err)
+		}
+		err := repo.AppendStatusHistory(ctx, "missing", domain.StatusTransition{To: domain.StatusFailed, ChangedAt: base})
+		if !errors.Is(err, ports.ErrNotFound) {
+			t.Errorf("expected ErrNotFound from AppendStatusHistory, got %v", err)
+		}
+	})
+
+	t.Run("returns not found when updating unknown order", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.UpdateStatus(context.Background(), "missing", domain.StatusCompleted)
+		if !errors.Is(err, ports.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	listTests := []struct {
+		name   string
+		filter ports.ListFilter
+		want   []string
+	}{
+		{
+			name:   "lists newest first",
+			filter: ports.ListFilter{},
+			want:   []string{"order-4", "order-3", "order-2", "order-1"},
+		},
+		{
+			name:   "filters by single status",
+			filter: ports.ListFilter{Status: statusPtr(domain.StatusPending)},
+			want:   []string{"order-4", "order-1"},
+		},
+		{
+			name:   "filters by multiple statuses",
+			filter: ports.ListFilter{Statuses: []domain.OrderStatus{domain.StatusCompleted, domain.StatusFailed}},
+			want:   []string{"order-3", "order-2"},
+		},
+		{
+			name:   "filters by customer email case-insensitively",
+			filter: ports.ListFilter{CustomerEmail: "ALICE@example.com"},
+			want:   []string{"order-3", "order-1"},
+		},
+		{
+			name:   "filters by customer id",
+			filter: ports.ListFilter{CustomerID: "customer-alice@example.com"},
+			want:   []string{"order-3", "order-1"},
+		},
+		{
+			name:   "filters by currency case-insensitively",
+			filter: ports.ListFilter{Currency: "usd"},
+			want:   []string{"order-4", "order-3", "order-2", "order-1"},
+		},
+		{
+			name:   "excludes orders in other currencies",
+			filter: ports.ListFilter{Currency: "EUR"},
+			want:   nil,
+		},
+		{
+			name:   "filters by inclusive created after",
+			filter: ports.ListFilter{CreatedAfter: base.Add(2 * time.Hour)},
+			want:   []string{"order-4", "order-3"},
+		},
+		{
+			name:   "filters by exclusive created before",
+			filter: ports.ListFilter{CreatedBefore: base.Add(2 * time.Hour)},
+			want:   []string{"order-2", "order-1"},
+		},
+		{
+			name:   "lists changes since updated after, oldest first",
+			filter: ports.ListFilter{UpdatedAfter: base.Add(90 * time.Minute)},
+			want:   []string{"order-3", "order-4"},
+		},
+		{
+			name: "combines filters",
+			filter: ports.ListFilter{
+				Statuses:      []domain.OrderStatus{domain.StatusPending, domain.StatusFailed},
+				CustomerEmail: "alice@example.com",
+				CreatedAfter:  base.Add(time.Minute),
+			},
+			want: []string{"order-3"},
+		},
+		{
+			name:   "paginates results",
+			filter: ports.ListFilter{Page: 2, PageSize: 3},
+			want:   []string{"order-1"},
+		},
+		{
+			name:   "returns empty page past the end",
+			filter: ports.ListFilter{Page: 3, PageSize: 3},
+			want:   nil,
+		},
+	}
+
+	t.Run("lists distinct customer emails", func(t *testing.T) {
+		repo := seed(t)
+		ctx := context.Background()
+
+		got, err := repo.DistinctCustomers(ctx, ports.ListFilter{})
+		if err != nil {
+			t.Fatalf("failed to list customers: %v", err)
+		}
+		if !slices.Equal(got, []string{"alice@example.com", "bob@example.com", "carol@example.com"}) {
+			t.Errorf("expected each customer once, case variants collapsed, got %v", got)
+		}
+
+		got, err = repo.DistinctCustomers(ctx, ports.ListFilter{Status: statusPtr(domain.StatusCompleted)})
+		if err != nil {
+			t.Fatalf("failed to list customers: %v", err)
+		}
+		if !slices.Equal(got, []string{"bob@example.com"}) {
+			t.Errorf("expected only bob for completed orders, got %v", got)
+		}
+
+		got, err = repo.DistinctCustomers(ctx, ports.ListFilter{Page: 2, PageSize: 1})
+		if err != nil {
+			t.Fatalf("failed to list customers: %v", err)
+		}
+		if !slices.Equal(got, []string{"bob@example.com"}) {
+			t.Errorf("expected bob on the second page, got %v", got)
+		}
+	})
+
+	t.Run("counts matching orders ignoring pagination", func(t *testing.T) {
+		repo := seed(t)
+		ctx := context.Background()
+
+		total, err := repo.Count(ctx, ports.ListFilter{Page: 2, PageSize: 1})
+		if err != nil {
+			t.Fatalf("failed to count orders: %v", err)
+		}
+		if total != len(fixtures) {
+			t.Errorf("expected %d orders, got %d", len(fixtures), total)
+		}
+
+		pending, err := repo.Count(ctx, ports.ListFilter{Status: statusPtr(domain.StatusPending)})
+		if err != nil {
+			t.Fatalf("failed to count orders: %v", err)
+		}
+		if pending != 2 {
+			t.Errorf("expected 2 pending orders, got %d", pending)
+		}
+	})
+
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := seed(t)
+
+			got, err := repo.List(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("failed to list orders: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d orders, got %d (%v)", len(tt.want), len(got), orderIDs(got))
+			}
+			for i, id := range tt.want {
+				if got[i].ID != id {
+					t.Errorf("expected order %d to be %s, got %s", i, id, got[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func newOrder(id, email string, status domain.OrderStatus, createdAt time.Time) domain.Order {
+	return domain.Order{
+		ID:            id,
+		Reference:     "REF-" + id,
+		CustomerID:    "customer-" + strings.ToLower(email),
+		CustomerEmail: email,
+		Amount:        domain.NewMoney(1000, "USD"),
+		Status:        status,
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
+	}
+}
+
+func statusPtr(status domain.OrderStatus) *domain.OrderStatus {
+	return &status
+}
+
+func orderIDs(orders []domain.Order) []string {
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	return ids
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "context"
+
+// ReferenceSequence hands out the numbers used in human-readable order references. Numbers
+// must be unique across every instance sharing the same order store.
+type ReferenceSequence interface {
+	NextReference(ctx context.Context) (int64, error)
+}
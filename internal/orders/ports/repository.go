@@ -3,6 +3,10 @@ package ports
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/dejobratic/tbd/internal/orders/domain"
 )
@@ -11,18 +15,173 @@ import (
 type OrderRepository interface {
 	Create(ctx context.Context, order domain.Order) error
 	GetByID(ctx context.Context, id string) (*domain.Order, error)
+	// GetByIDs loads many orders in one call, keyed by ID. Unknown IDs are absent from the
+	// map rather than an error.
+	GetByIDs(ctx context.Context, ids []string) (map[string]domain.Order, error)
+	// GetByReference looks an order up by its human-readable reference.
+	GetByReference(ctx context.Context, reference string) (*domain.Order, error)
+	// Exists reports whether an order exists without loading it.
+	Exists(ctx context.Context, id string) (bool, error)
 	List(ctx context.Context, filter ListFilter) ([]domain.Order, error)
+	// Count returns the number of orders matching the filter, ignoring pagination.
+	Count(ctx context.Context, filter ListFilter) (int, error)
+	// DistinctCustomers returns one page of the lower-cased emails of customers with orders
+	// matching the filter, sorted alphabetically.
+	DistinctCustomers(ctx context.Context, filter ListFilter) ([]string, error)
+	// UpdateStatus changes the order's status and records the transition in its status
+	// history atomically.
 	UpdateStatus(ctx context.Context, id string, status domain.OrderStatus) error
+	// AppendStatusHistory records a transition that was not made through UpdateStatus.
+	AppendStatusHistory(ctx context.Context, id string, transition domain.StatusTransition) error
+	// GetStatusHistory returns the order's transitions, oldest first.
+	GetStatusHistory(ctx context.Context, id string) ([]domain.StatusTransition, error)
 }
 
-// ListFilter narrows list queries by status and pagination.
+type primaryReadsContextKey struct{}
+
+// WithPrimaryReads marks reads made with ctx as needing the primary's current state, e.g.
+// the read a status change is checked against. Repositories that route reads to a replica
+// or serve them from a cache must read the primary instead.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsContextKey{}, true)
+}
+
+// PrimaryReads reports whether ctx was marked by WithPrimaryReads.
+func PrimaryReads(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsContextKey{}).(bool)
+	return primary
+}
+
+// ListFilter narrows list queries by status, customer, creation time and pagination.
+// Zero values leave the corresponding criterion unrestricted.
 type ListFilter struct {
 	Status   *domain.OrderStatus
-	Page     int
-	PageSize int
+	Statuses []domain.OrderStatus
+	// CustomerID matches exactly.
+	CustomerID string
+	// CustomerEmail matches case-insensitively.
+	CustomerEmail string
+	// Currency matches the ISO 4217 code of the order amount, case-insensitively.
+	Currency string
+	// CreatedAfter is inclusive, CreatedBefore is exclusive.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// UpdatedAfter is inclusive. When set, List returns the oldest changes first, so sync
+	// clients can page through everything changed since a timestamp.
+	UpdatedAfter time.Time
+	Page         int
+	PageSize     int
+}
+
+// PageLimits bounds the page size of list queries.
+type PageLimits struct {
+	DefaultSize int
+	MaxSize     int
+}
+
+// DefaultPageLimits applies when no limits are configured.
+var DefaultPageLimits = PageLimits{DefaultSize: 20, MaxSize: 100}
+
+// Pagination returns the page and page size to query, filling in unset values from
+// DefaultPageLimits. It does not clamp: Normalize enforces the configured maximum before
+// filters reach a repository.
+func (f ListFilter) Pagination() (page, pageSize int) {
+	page = f.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize = f.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageLimits.DefaultSize
+	}
+	return page, pageSize
+}
+
+// Validate reports the first invalid criterion as a domain.ValidationError: unknown
+// statuses, a malformed currency, negative pagination, or an empty creation time window.
+func (f ListFilter) Validate() error {
+	if f.Status != nil && !f.Status.IsValid() {
+		return domain.NewValidationError(fmt.Sprintf("invalid status %q", *f.Status))
+	}
+	for _, status := range f.Statuses {
+		if !status.IsValid() {
+			return domain.NewValidationError(fmt.Sprintf("invalid status %q", status))
+		}
+	}
+	if f.Currency != "" {
+		if err := domain.ValidateCurrency(strings.ToUpper(strings.TrimSpace(f.Currency))); err != nil {
+			return err
+		}
+	}
+	if f.Page < 0 {
+		return domain.NewValidationError("page must not be negative")
+	}
+	if f.PageSize < 0 {
+		return domain.NewValidationError("page_size must not be negative")
+	}
+	if !f.CreatedAfter.IsZero() && !f.CreatedBefore.IsZero() && !f.CreatedAfter.Before(f.CreatedBefore) {
+		return domain.NewValidationError("created_after must be before created_before")
+	}
+	return nil
+}
+
+// Normalize validates the filter and returns a copy with Page and PageSize set to their
+// effective values under limits and Currency upper-cased, so every adapter pages and
+// filters the same way. Zero limits fall back to DefaultPageLimits.
+func (f ListFilter) Normalize(limits PageLimits) (ListFilter, error) {
+	if err := f.Validate(); err != nil {
+		return ListFilter{}, err
+	}
+	if limits.DefaultSize <= 0 {
+		limits.DefaultSize = DefaultPageLimits.DefaultSize
+	}
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = DefaultPageLimits.MaxSize
+	}
+
+	f.Currency = strings.ToUpper(strings.TrimSpace(f.Currency))
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.PageSize <= 0 {
+		f.PageSize = limits.DefaultSize
+	}
+	f.PageSize = min(f.PageSize, limits.MaxSize)
+	return f, nil
+}
+
+// Matches reports whether order satisfies the filter criteria, ignoring pagination.
+func (f ListFilter) Matches(order domain.Order) bool {
+	if f.Status != nil && order.Status != *f.Status {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, order.Status) {
+		return false
+	}
+	if f.CustomerID != "" && order.CustomerID != f.CustomerID {
+		return false
+	}
+	if f.CustomerEmail != "" && !strings.EqualFold(order.CustomerEmail, f.CustomerEmail) {
+		return false
+	}
+	if f.Currency != "" && !strings.EqualFold(order.Amount.Currency, strings.TrimSpace(f.Currency)) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && order.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !order.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if !f.UpdatedAfter.IsZero() && order.UpdatedAt.Before(f.UpdatedAfter) {
+		return false
+	}
+	return true
 }
 
 var (
 	// ErrNotFound is returned when the requested order does not exist.
 	ErrNotFound = errors.New("order not found")
+	// ErrQueryTimeout is returned when a repository call exceeds its deadline.
+	ErrQueryTimeout = errors.New("query timed out")
 )
@@ -0,0 +1,103 @@
+package ports_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+	"github.com/dejobratic/tbd/internal/orders/ports"
+)
+
+func TestListFilterValidate(t *testing.T) {
+	unknown := domain.OrderStatus("shipped")
+	pending := domain.StatusPending
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		filter  ports.ListFilter
+		wantErr bool
+	}{
+		{"empty filter", ports.ListFilter{}, false},
+		{"known status", ports.ListFilter{Status: &pending}, false},
+		{"unknown status", ports.ListFilter{Status: &unknown}, true},
+		{"unknown status in list", ports.ListFilter{Statuses: []domain.OrderStatus{domain.StatusPending, unknown}}, true},
+		{"lower-case currency", ports.ListFilter{Currency: "eur"}, false},
+		{"malformed currency", ports.ListFilter{Currency: "EURO"}, true},
+		{"unknown currency", ports.ListFilter{Currency: "zzz"}, true},
+		{"negative page", ports.ListFilter{Page: -1}, true},
+		{"negative page size", ports.ListFilter{PageSize: -5}, true},
+		{"inverted time window", ports.ListFilter{CreatedAfter: now, CreatedBefore: now.Add(-time.Hour)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr && !errors.Is(err, domain.ErrValidation) {
+				t.Errorf("expected validation error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestListFilterNormalize(t *testing.T) {
+	t.Run("applies default pagination", func(t *testing.T) {
+		got, err := ports.ListFilter{}.Normalize(ports.DefaultPageLimits)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Page != 1 || got.PageSize != ports.DefaultPageLimits.DefaultSize {
+			t.Errorf("expected page 1 size %d, got page %d size %d", ports.DefaultPageLimits.DefaultSize, got.Page, got.PageSize)
+		}
+	})
+
+	t.Run("clamps page size to the maximum", func(t *testing.T) {
+		got, err := ports.ListFilter{PageSize: 1000}.Normalize(ports.DefaultPageLimits)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.PageSize != ports.DefaultPageLimits.MaxSize {
+			t.Errorf("expected page size %d, got %d", ports.DefaultPageLimits.MaxSize, got.PageSize)
+		}
+	})
+
+	t.Run("applies configured limits", func(t *testing.T) {
+		limits := ports.PageLimits{DefaultSize: 50, MaxSize: 500}
+
+		got, err := ports.ListFilter{}.Normalize(limits)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.PageSize != 50 {
+			t.Errorf("expected default page size 50, got %d", got.PageSize)
+		}
+
+		got, err = ports.ListFilter{PageSize: 1000}.Normalize(limits)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.PageSize != 500 {
+			t.Errorf("expected page size clamped to 500, got %d", got.PageSize)
+		}
+	})
+
+	t.Run("upper-cases the currency", func(t *testing.T) {
+		got, err := ports.ListFilter{Currency: " eur "}.Normalize(ports.DefaultPageLimits)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Currency != "EUR" {
+			t.Errorf("expected currency EUR, got %q", got.Currency)
+		}
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		if _, err := (ports.ListFilter{Page: -1}).Normalize(ports.DefaultPageLimits); !errors.Is(err, domain.ErrValidation) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
+}
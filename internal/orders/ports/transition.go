@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dejobratic/tbd/internal/orders/domain"
+)
+
+// TransitionRecorder is told about every order creation and status change once it is
+// persisted, e.g. to keep an audit log. from is empty for creations. An error fails the
+// operation that made the change.
+type TransitionRecorder interface {
+	RecordTransition(ctx context.Context, orderID string, from, to domain.OrderStatus) error
+}
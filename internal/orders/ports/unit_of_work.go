@@ -0,0 +1,9 @@
+package ports
+
+import "context"
+
+// UnitOfWork runs repository writes and outbox inserts atomically. The callback receives
+// transaction-bound stores; returning an error rolls back every write made through them.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repo OrderRepository, outbox OutboxStore) error) error
+}
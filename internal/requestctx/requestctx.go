@@ -0,0 +1,81 @@
+// Package requestctx stores request-scoped values in a context. Each value has its own
+// unexported key type, so middleware cannot collide with another package's keys; always
+// read and write these values through the functions below rather than context.WithValue.
+package requestctx
+
+import "context"
+
+type requestIDContextKey struct{}
+
+type idempotencyKeyContextKey struct{}
+
+type tenantIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestID returns the request ID stored by WithRequestID, or "" if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// WithIdempotencyKey returns a copy of ctx carrying the client's idempotency key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key stored by WithIdempotencyKey, or "" if there
+// is none.
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// WithTenantID returns a copy of ctx carrying the caller's tenant ID.
+func WithTenantID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantIDContextKey{}, id)
+}
+
+// TenantID returns the tenant ID stored by WithTenantID, or "" if there is none.
+func TenantID(ctx context.Context) string {
+	id, _ := ctx.Value(tenantIDContextKey{}).(string)
+	return id
+}
+
+type routeContextKey struct{}
+
+// Route records the route template that served a request, e.g. "/v1/orders/{id}". The
+// outermost middleware installs it with WithRoute before routing, handlers fill it in with
+// SetRoute, and the middleware reads it back once the handler returns.
+type Route struct {
+	Template string
+}
+
+// WithRoute returns ctx with an empty Route attached, or ctx unchanged if it already has
+// one, together with that Route.
+func WithRoute(ctx context.Context) (context.Context, *Route) {
+	if route, ok := ctx.Value(routeContextKey{}).(*Route); ok {
+		return ctx, route
+	}
+	route := &Route{}
+	return context.WithValue(ctx, routeContextKey{}, route), route
+}
+
+// SetRoute records template on the Route installed by WithRoute. It does nothing when no
+// Route is installed.
+func SetRoute(ctx context.Context, template string) {
+	if route, ok := ctx.Value(routeContextKey{}).(*Route); ok {
+		route.Template = template
+	}
+}
+
+// RouteTemplate returns the template recorded with SetRoute, or "" if none was.
+func RouteTemplate(ctx context.Context) string {
+	if route, ok := ctx.Value(routeContextKey{}).(*Route); ok {
+		return route.Template
+	}
+	return ""
+}
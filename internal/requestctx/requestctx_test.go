@@ -0,0 +1,97 @@
+package requestctx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dejobratic/tbd/internal/requestctx"
+)
+
+func TestRequestID(t *testing.T) {
+	t.Run("returns the stored request id", func(t *testing.T) {
+		ctx := requestctx.WithRequestID(context.Background(), "req-1")
+
+		if got := requestctx.RequestID(ctx); got != "req-1" {
+			t.Errorf("expected req-1, got %q", got)
+		}
+	})
+
+	t.Run("returns empty when unset", func(t *testing.T) {
+		if got := requestctx.RequestID(context.Background()); got != "" {
+			t.Errorf("expected empty request id, got %q", got)
+		}
+	})
+
+	t.Run("is not visible through a plain string key", func(t *testing.T) {
+		type stringKey string
+		ctx := context.WithValue(context.Background(), stringKey("request_id"), "spoofed")
+
+		if got := requestctx.RequestID(ctx); got != "" {
+			t.Errorf("expected empty request id, got %q", got)
+		}
+	})
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	t.Run("keeps values separate from the request id", func(t *testing.T) {
+		ctx := requestctx.WithRequestID(context.Background(), "req-1")
+		ctx = requestctx.WithIdempotencyKey(ctx, "key-12345")
+
+		if got := requestctx.IdempotencyKey(ctx); got != "key-12345" {
+			t.Errorf("expected key-12345, got %q", got)
+		}
+		if got := requestctx.RequestID(ctx); got != "req-1" {
+			t.Errorf("expected req-1, got %q", got)
+		}
+	})
+}
+
+func TestTenantID(t *testing.T) {
+	t.Run("returns the stored tenant id", func(t *testing.T) {
+		ctx := requestctx.WithTenantID(context.Background(), "acme")
+
+		if got := requestctx.TenantID(ctx); got != "acme" {
+			t.Errorf("expected acme, got %q", got)
+		}
+	})
+
+	t.Run("returns empty when unset", func(t *testing.T) {
+		if got := requestctx.TenantID(context.Background()); got != "" {
+			t.Errorf("expected empty tenant id, got %q", got)
+		}
+	})
+}
+
+func TestRoute(t *testing.T) {
+	t.Run("handlers record the template on the installed route", func(t *testing.T) {
+		ctx, route := requestctx.WithRoute(context.Background())
+		inner := context.WithValue(ctx, struct{}{}, "derived")
+
+		requestctx.SetRoute(inner, "/v1/orders/{id}")
+
+		if route.Template != "/v1/orders/{id}" {
+			t.Errorf("expected template to be visible to the installer, got %q", route.Template)
+		}
+		if got := requestctx.RouteTemplate(ctx); got != "/v1/orders/{id}" {
+			t.Errorf("expected /v1/orders/{id}, got %q", got)
+		}
+	})
+
+	t.Run("reuses an installed route", func(t *testing.T) {
+		ctx, outer := requestctx.WithRoute(context.Background())
+		_, inner := requestctx.WithRoute(ctx)
+
+		if outer != inner {
+			t.Error("expected WithRoute to return the existing route")
+		}
+	})
+
+	t.Run("ignores SetRoute without an installed route", func(t *testing.T) {
+		ctx := context.Background()
+		requestctx.SetRoute(ctx, "/v1/orders")
+
+		if got := requestctx.RouteTemplate(ctx); got != "" {
+			t.Errorf("expected empty template, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+// Package retry runs operations again after transient failures, backing off exponentially
+// between attempts.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy controls how Do retries an operation. The zero Policy runs it exactly once.
+type Policy struct {
+	// MaxAttempts caps the number of calls, including the first. Values below 1 mean 1.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt; each later wait is multiplied
+	// by Multiplier, up to MaxBackoff when it is positive.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Multiplier defaults to 2 when not greater than 1.
+	Multiplier float64
+	// Jitter randomizes each wait by up to this fraction in either direction, so clients
+	// that failed together do not retry in lockstep. It is clamped to [0, 1].
+	Jitter float64
+	// Retryable reports whether an error is worth another attempt. Nil retries every error.
+	Retryable func(error) bool
+}
+
+// Do calls fn until it succeeds, returns an error the policy does not retry, or runs out
+// of attempts, and returns fn's last error. If ctx is done before or between attempts Do
+// stops waiting and returns ctx's error joined with the last error from fn, if any.
+func Do(ctx context.Context, policy Policy, fn func() error) error {
+	attempts := max(policy.MaxAttempts, 1)
+
+	var lastErr error
+	for attempt := range attempts {
+		if attempt > 0 {
+			timer := time.NewTimer(policy.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return errors.Join(ctx.Err(), lastErr)
+			case <-timer.C:
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return errors.Join(err, lastErr)
+		}
+
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+		if policy.Retryable != nil && !policy.Retryable(lastErr) {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// backoff returns the wait before the given attempt, where attempt 1 is the first retry.
+func (p Policy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	wait := float64(p.InitialBackoff)
+	for range attempt - 1 {
+		wait *= multiplier
+		if p.MaxBackoff > 0 && wait >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 {
+		wait = min(wait, float64(p.MaxBackoff))
+	}
+
+	if jitter := min(max(p.Jitter, 0), 1); jitter > 0 {
+		wait *= 1 + jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(wait)
+}
@@ -0,0 +1,137 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dejobratic/tbd/internal/retry"
+)
+
+var errTransient = errors.New("transient")
+
+func TestDo(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Jitter: 0.5}
+
+	t.Run("retries until the operation succeeds", func(t *testing.T) {
+		calls := 0
+		err := retry.Do(context.Background(), policy, func() error {
+			calls++
+			if calls < 3 {
+				return errTransient
+			}
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("returns the last error once attempts run out", func(t *testing.T) {
+		calls := 0
+		err := retry.Do(context.Background(), policy, func() error {
+			calls++
+			return errTransient
+		})
+
+		if !errors.Is(err, errTransient) {
+			t.Errorf("expected transient error, got %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("stops at an error the policy does not retry", func(t *testing.T) {
+		permanent := errors.New("permanent")
+		nonRetrying := policy
+		nonRetrying.Retryable = func(err error) bool { return errors.Is(err, errTransient) }
+
+		calls := 0
+		err := retry.Do(context.Background(), nonRetrying, func() error {
+			calls++
+			return permanent
+		})
+
+		if !errors.Is(err, permanent) {
+			t.Errorf("expected permanent error, got %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("runs once with the zero policy", func(t *testing.T) {
+		calls := 0
+		_ = retry.Do(context.Background(), retry.Policy{}, func() error {
+			calls++
+			return errTransient
+		})
+
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+
+	t.Run("stops waiting when the context is canceled mid-retry", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		slow := retry.Policy{MaxAttempts: 5, InitialBackoff: time.Hour}
+
+		calls := 0
+		done := make(chan error, 1)
+		go func() {
+			done <- retry.Do(ctx, slow, func() error {
+				calls++
+				return errTransient
+			})
+		}()
+		cancel()
+
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+			if calls > 1 {
+				t.Errorf("expected at most 1 call, got %d", calls)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Do did not return after the context was canceled")
+		}
+	})
+
+	t.Run("keeps the last error when canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		err := retry.Do(ctx, retry.Policy{MaxAttempts: 3, InitialBackoff: time.Hour}, func() error {
+			cancel()
+			return errTransient
+		})
+
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, errTransient) {
+			t.Errorf("expected both context.Canceled and the last error, got %v", err)
+		}
+	})
+
+	t.Run("does not call fn when the context is already done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		calls := 0
+		err := retry.Do(ctx, policy, func() error {
+			calls++
+			return nil
+		})
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if calls != 0 {
+			t.Errorf("expected no calls, got %d", calls)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewBaggageSpanProcessor copies the named baggage members from the parent context onto
+// every span as attributes of the same name, so values set once per request, such as a
+// tenant ID, tag every span started below it.
+func NewBaggageSpanProcessor(keys ...string) sdktrace.SpanProcessor {
+	return baggageSpanProcessor{keys: keys}
+}
+
+type baggageSpanProcessor struct {
+	keys []string
+}
+
+func (p baggageSpanProcessor) OnStart(ctx context.Context, span sdktrace.ReadWriteSpan) {
+	bag := baggage.FromContext(ctx)
+	for _, key := range p.keys {
+		if member := bag.Member(key); member.Key() != "" {
+			span.SetAttributes(attribute.String(key, member.Value()))
+		}
+	}
+}
+
+func (baggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (baggageSpanProcessor) Shutdown(context.Context) error { return nil }
+
+func (baggageSpanProcessor) ForceFlush(context.Context) error { return nil }
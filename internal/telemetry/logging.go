@@ -2,23 +2,72 @@ package telemetry
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
+
+	"github.com/dejobratic/tbd/internal/requestctx"
+	"go.opentelemetry.io/otel/baggage"
 )
 
-func NewLogger(level slog.Level) *slog.Logger {
-	baseHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: level,
+// LoggerOption customizes NewLogger.
+type LoggerOption func(*loggerOptions)
+
+type loggerOptions struct {
+	baggageKeys []string
+	addSource   bool
+}
+
+// WithBaggageKeys copies the named baggage members from the context onto every log record as
+// root-level attributes. Members that are not listed are never logged.
+func WithBaggageKeys(keys ...string) LoggerOption {
+	return func(o *loggerOptions) {
+		o.baggageKeys = append(o.baggageKeys, keys...)
+	}
+}
+
+// WithSource adds the file and line of each log call to its record.
+func WithSource(enabled bool) LoggerOption {
+	return func(o *loggerOptions) {
+		o.addSource = enabled
+	}
+}
+
+// Log formats accepted by NewLogger.
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// NewLogger writes logs to stdout in the given format, falling back to JSON for unknown
+// formats. Trace, request and baggage attributes are added in either format.
+func NewLogger(level slog.Level, format string, opts ...LoggerOption) *slog.Logger {
+	options := &loggerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return slog.New(&traceHandler{
+		baseHandler: newBaseHandler(os.Stdout, level, format, options.addSource),
+		baggageKeys: options.baggageKeys,
 	})
+}
 
-	handler := &traceHandler{baseHandler: baseHandler}
-	return slog.New(handler)
+// newBaseHandler builds the handler traceHandler wraps. traceHandler hands records through
+// unchanged, so their PC still points at the original log call when addSource is set.
+func newBaseHandler(w io.Writer, level slog.Level, format string, addSource bool) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: level, AddSource: addSource}
+	if format == LogFormatText {
+		return slog.NewTextHandler(w, handlerOpts)
+	}
+	return slog.NewJSONHandler(w, handlerOpts)
 }
 
 type traceHandler struct {
 	baseHandler slog.Handler
 	groups      []string
 	attrs       []slog.Attr
+	baggageKeys []string
 }
 
 func (h *traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
@@ -42,6 +91,14 @@ func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
 		handler = handler.WithAttrs(traceAttrs)
 	}
 
+	if requestAttrs := requestAttrs(ctx); len(requestAttrs) > 0 {
+		handler = handler.WithAttrs(requestAttrs)
+	}
+
+	if baggageAttrs := h.baggageAttrs(ctx); len(baggageAttrs) > 0 {
+		handler = handler.WithAttrs(baggageAttrs)
+	}
+
 	if len(h.attrs) > 0 {
 		handler = handler.WithAttrs(h.attrs)
 	}
@@ -53,6 +110,33 @@ func (h *traceHandler) Handle(ctx context.Context, r slog.Record) error {
 	return handler.Handle(ctx, r)
 }
 
+// requestAttrs returns the request-scoped values stored in ctx by the HTTP middleware.
+func requestAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if id := requestctx.RequestID(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if key := requestctx.IdempotencyKey(ctx); key != "" {
+		attrs = append(attrs, slog.String("idempotency_key", key))
+	}
+	return attrs
+}
+
+// baggageAttrs returns the configured baggage members present in ctx.
+func (h *traceHandler) baggageAttrs(ctx context.Context) []slog.Attr {
+	if len(h.baggageKeys) == 0 {
+		return nil
+	}
+	bag := baggage.FromContext(ctx)
+	var attrs []slog.Attr
+	for _, key := range h.baggageKeys {
+		if member := bag.Member(key); member.Key() != "" {
+			attrs = append(attrs, slog.String(key, member.Value()))
+		}
+	}
+	return attrs
+}
+
 func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	newAttrs := make([]slog.Attr, len(h.attrs)+len(attrs))
 	copy(newAttrs, h.attrs)
@@ -62,6 +146,7 @@ func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		baseHandler: h.baseHandler,
 		groups:      h.groups,
 		attrs:       newAttrs,
+		baggageKeys: h.baggageKeys,
 	}
 }
 
@@ -74,5 +159,6 @@ func (h *traceHandler) WithGroup(name string) slog.Handler {
 		baseHandler: h.baseHandler,
 		groups:      newGroups,
 		attrs:       h.attrs,
+		baggageKeys: h.baggageKeys,
 	}
 }
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/dejobratic/tbd/internal/requestctx"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
@@ -140,6 +142,104 @@ func TestTraceAndSpanIDInclusion(t *testing.T) {
 	}
 }
 
+func TestTraceIDInclusionWithTextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(&traceHandler{baseHandler: newBaseHandler(&buf, slog.LevelInfo, LogFormatText, false)})
+
+	_, cleanup := setupTracerProvider(t)
+	defer cleanup()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	defer span.End()
+
+	logger.InfoContext(ctx, "test message")
+
+	output := buf.String()
+	if strings.HasPrefix(output, "{") {
+		t.Errorf("expected text output, got %s", output)
+	}
+	if want := "trace_id=" + span.SpanContext().TraceID().String(); !strings.Contains(output, want) {
+		t.Errorf("expected %q in text output, got %s", want, output)
+	}
+}
+
+func TestLogSource(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(&traceHandler{baseHandler: newBaseHandler(&buf, slog.LevelInfo, LogFormatJSON, true)}).With("key", "value")
+
+	logger.InfoContext(context.Background(), "test message")
+
+	var logEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logEntry); err != nil {
+		t.Fatalf("failed to parse log output: %v", err)
+	}
+
+	source, ok := logEntry["source"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected source to be present, got %v", logEntry["source"])
+	}
+	if file, _ := source["file"].(string); !strings.HasSuffix(file, "logging_test.go") {
+		t.Errorf("expected source file to be the caller, got %v", source["file"])
+	}
+}
+
+func TestLogWithRequestValues(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})
+	logger := slog.New(&traceHandler{baseHandler: handler})
+
+	ctx := requestctx.WithRequestID(context.Background(), "req-123")
+	ctx = requestctx.WithIdempotencyKey(ctx, "key-12345")
+
+	logger.InfoContext(ctx, "test message")
+
+	var logEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logEntry); err != nil {
+		t.Fatalf("failed to parse log output: %v", err)
+	}
+
+	if logEntry["request_id"] != "req-123" {
+		t.Errorf("expected request_id to be 'req-123', got %v", logEntry["request_id"])
+	}
+
+	if logEntry["idempotency_key"] != "key-12345" {
+		t.Errorf("expected idempotency_key to be 'key-12345', got %v", logEntry["idempotency_key"])
+	}
+}
+
+func TestLogWithBaggage(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	})
+	logger := slog.New(&traceHandler{baseHandler: handler, baggageKeys: []string{"tenant"}}).WithGroup("http")
+
+	tenant, _ := baggage.NewMember("tenant", "acme")
+	secret, _ := baggage.NewMember("session", "s3cr3t")
+	bag, err := baggage.New(tenant, secret)
+	if err != nil {
+		t.Fatalf("failed to create baggage: %v", err)
+	}
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	logger.InfoContext(ctx, "test message", "method", "GET")
+
+	var logEntry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &logEntry); err != nil {
+		t.Fatalf("failed to parse log output: %v", err)
+	}
+
+	if logEntry["tenant"] != "acme" {
+		t.Errorf("expected tenant to be 'acme' at root level, got %v", logEntry["tenant"])
+	}
+
+	if _, exists := logEntry["session"]; exists {
+		t.Error("expected unlisted baggage member to not be logged")
+	}
+}
+
 func TestLogWithoutTraceIDs(t *testing.T) {
 	var buf bytes.Buffer
 	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
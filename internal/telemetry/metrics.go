@@ -0,0 +1,124 @@
+package telemetry
+
+import (
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// instrumentFailures records instruments that could not be registered by a lenient meter.
+type instrumentFailures struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (f *instrumentFailures) add(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.names = append(f.names, name)
+}
+
+func (f *instrumentFailures) list() []string {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.names...)
+}
+
+// lenientMeter wraps a meter so that an instrument which fails to register is logged and
+// replaced by a no-op instrument instead of failing the whole metrics constructor.
+type lenientMeter struct {
+	metric.Meter
+	logger   *slog.Logger
+	failures *instrumentFailures
+}
+
+func newLenientMeter(meter metric.Meter, logger *slog.Logger, failures *instrumentFailures) metric.Meter {
+	return &lenientMeter{Meter: meter, logger: logger, failures: failures}
+}
+
+func lenient[T any](m *lenientMeter, name string, inst T, err error, fallback T) (T, error) {
+	if err == nil {
+		return inst, nil
+	}
+	m.logger.Warn("failed to register metric instrument, continuing without it",
+		"instrument", name,
+		"error", err,
+	)
+	m.failures.add(name)
+	return fallback, nil
+}
+
+func (m *lenientMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	inst, err := m.Meter.Int64Counter(name, options...)
+	return lenient[metric.Int64Counter](m, name, inst, err, noop.Int64Counter{})
+}
+
+func (m *lenientMeter) Int64UpDownCounter(name string, options ...metric.Int64UpDownCounterOption) (metric.Int64UpDownCounter, error) {
+	inst, err := m.Meter.Int64UpDownCounter(name, options...)
+	return lenient[metric.Int64UpDownCounter](m, name, inst, err, noop.Int64UpDownCounter{})
+}
+
+func (m *lenientMeter) Int64Histogram(name string, options ...metric.Int64HistogramOption) (metric.Int64Histogram, error) {
+	inst, err := m.Meter.Int64Histogram(name, options...)
+	return lenient[metric.Int64Histogram](m, name, inst, err, noop.Int64Histogram{})
+}
+
+func (m *lenientMeter) Int64Gauge(name string, options ...metric.Int64GaugeOption) (metric.Int64Gauge, error) {
+	inst, err := m.Meter.Int64Gauge(name, options...)
+	return lenient[metric.Int64Gauge](m, name, inst, err, noop.Int64Gauge{})
+}
+
+func (m *lenientMeter) Int64ObservableCounter(name string, options ...metric.Int64ObservableCounterOption) (metric.Int64ObservableCounter, error) {
+	inst, err := m.Meter.Int64ObservableCounter(name, options...)
+	return lenient[metric.Int64ObservableCounter](m, name, inst, err, noop.Int64ObservableCounter{})
+}
+
+func (m *lenientMeter) Int64ObservableUpDownCounter(name string, options ...metric.Int64ObservableUpDownCounterOption) (metric.Int64ObservableUpDownCounter, error) {
+	inst, err := m.Meter.Int64ObservableUpDownCounter(name, options...)
+	return lenient[metric.Int64ObservableUpDownCounter](m, name, inst, err, noop.Int64ObservableUpDownCounter{})
+}
+
+func (m *lenientMeter) Int64ObservableGauge(name string, options ...metric.Int64ObservableGaugeOption) (metric.Int64ObservableGauge, error) {
+	inst, err := m.Meter.Int64ObservableGauge(name, options...)
+	return lenient[metric.Int64ObservableGauge](m, name, inst, err, noop.Int64ObservableGauge{})
+}
+
+func (m *lenientMeter) Float64Counter(name string, options ...metric.Float64CounterOption) (metric.Float64Counter, error) {
+	inst, err := m.Meter.Float64Counter(name, options...)
+	return lenient[metric.Float64Counter](m, name, inst, err, noop.Float64Counter{})
+}
+
+func (m *lenientMeter) Float64UpDownCounter(name string, options ...metric.Float64UpDownCounterOption) (metric.Float64UpDownCounter, error) {
+	inst, err := m.Meter.Float64UpDownCounter(name, options...)
+	return lenient[metric.Float64UpDownCounter](m, name, inst, err, noop.Float64UpDownCounter{})
+}
+
+func (m *lenientMeter) Float64Histogram(name string, options ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	inst, err := m.Meter.Float64Histogram(name, options...)
+	return lenient[metric.Float64Histogram](m, name, inst, err, noop.Float64Histogram{})
+}
+
+func (m *lenientMeter) Float64Gauge(name string, options ...metric.Float64GaugeOption) (metric.Float64Gauge, error) {
+	inst, err := m.Meter.Float64Gauge(name, options...)
+	return lenient[metric.Float64Gauge](m, name, inst, err, noop.Float64Gauge{})
+}
+
+func (m *lenientMeter) Float64ObservableCounter(name string, options ...metric.Float64ObservableCounterOption) (metric.Float64ObservableCounter, error) {
+	inst, err := m.Meter.Float64ObservableCounter(name, options...)
+	return lenient[metric.Float64ObservableCounter](m, name, inst, err, noop.Float64ObservableCounter{})
+}
+
+func (m *lenientMeter) Float64ObservableUpDownCounter(name string, options ...metric.Float64ObservableUpDownCounterOption) (metric.Float64ObservableUpDownCounter, error) {
+	inst, err := m.Meter.Float64ObservableUpDownCounter(name, options...)
+	return lenient[metric.Float64ObservableUpDownCounter](m, name, inst, err, noop.Float64ObservableUpDownCounter{})
+}
+
+func (m *lenientMeter) Float64ObservableGauge(name string, options ...metric.Float64ObservableGaugeOption) (metric.Float64ObservableGauge, error) {
+	inst, err := m.Meter.Float64ObservableGauge(name, options...)
+	return lenient[metric.Float64ObservableGauge](m, name, inst, err, noop.Float64ObservableGauge{})
+}
@@ -0,0 +1,94 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestLenientMeter(t *testing.T) {
+	t.Run("replaces failing instruments with no-op instruments", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, nil))
+		failures := &instrumentFailures{}
+		provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+		meter := newLenientMeter(provider.Meter("test"), logger, failures)
+
+		counter, err := meter.Int64Counter("invalid name!")
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if counter == nil {
+			t.Fatal("expected fallback counter, got nil")
+		}
+		counter.Add(context.Background(), 1)
+
+		failed := failures.list()
+		if len(failed) != 1 || failed[0] != "invalid name!" {
+			t.Errorf("expected [invalid name!], got %v", failed)
+		}
+		if !strings.Contains(buf.String(), "failed to register metric instrument") {
+			t.Errorf("expected warning to be logged, got %q", buf.String())
+		}
+	})
+
+	t.Run("registers valid instruments", func(t *testing.T) {
+		failures := &instrumentFailures{}
+		provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))
+		meter := newLenientMeter(provider.Meter("test"), slog.Default(), failures)
+
+		_, err := meter.Float64Histogram("valid_histogram", metric.WithUnit("s"))
+
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if failed := failures.list(); len(failed) != 0 {
+			t.Errorf("expected no failures, got %v", failed)
+		}
+	})
+}
+
+func TestTelemetryMeter(t *testing.T) {
+	t.Run("returns no-op meter when metrics are disabled", func(t *testing.T) {
+		tel := &Telemetry{}
+
+		_, err := tel.Meter("test").Int64Counter("requests_total")
+
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if failed := tel.FailedInstruments(); len(failed) != 0 {
+			t.Errorf("expected no failures, got %v", failed)
+		}
+	})
+
+	t.Run("reports failed instruments when lenient", func(t *testing.T) {
+		ctx := context.Background()
+		cfg := testConfig()
+		cfg.EnableMetrics = true
+
+		tel, err := Initialize(ctx, cfg,
+			WithMetricExporter(NewNoopMetricExporter()),
+			WithLenientMetrics(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))),
+		)
+		if err != nil {
+			t.Fatalf("failed to initialize telemetry: %v", err)
+		}
+		defer func() { _ = tel.Shutdown(ctx) }()
+
+		if _, err := tel.Meter("test").Int64Counter("invalid name!"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		failed := tel.FailedInstruments()
+		if len(failed) != 1 || failed[0] != "invalid name!" {
+			t.Errorf("expected [invalid name!], got %v", failed)
+		}
+	})
+}
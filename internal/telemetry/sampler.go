@@ -0,0 +1,62 @@
+package telemetry
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ForceSampleKey is the span attribute that, when set to a positive integer, makes
+// ErrorAwareSampler sample a span regardless of the sample rate.
+const ForceSampleKey = "sampling.priority"
+
+// errorAttributeKey marks a span as failed when it is started with error=true.
+const errorAttributeKey = attribute.Key("error")
+
+// ErrorAttribute starts a span as failed, so ErrorAwareSampler samples it. See
+// RecordErrorSpan.
+var ErrorAttribute = errorAttributeKey.Bool(true)
+
+// NewErrorAwareSampler samples spans started with ErrorAttribute or a positive
+// ForceSampleKey attribute, even under an unsampled parent, and defers every other decision
+// to delegate. Only span attributes count: baggage arrives from callers, so a baggage
+// member cannot force sampling.
+func NewErrorAwareSampler(delegate sdktrace.Sampler) sdktrace.Sampler {
+	return errorAwareSampler{delegate: delegate}
+}
+
+type errorAwareSampler struct {
+	delegate sdktrace.Sampler
+}
+
+func (s errorAwareSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if forceSample(p) {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordAndSample,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	return s.delegate.ShouldSample(p)
+}
+
+func (s errorAwareSampler) Description() string {
+	return fmt.Sprintf("ErrorAwareSampler{%s}", s.delegate.Description())
+}
+
+func forceSample(p sdktrace.SamplingParameters) bool {
+	for _, attr := range p.Attributes {
+		switch attr.Key {
+		case errorAttributeKey:
+			if attr.Value.Type() == attribute.BOOL && attr.Value.AsBool() {
+				return true
+			}
+		case ForceSampleKey:
+			if attr.Value.Type() == attribute.INT64 && attr.Value.AsInt64() > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestErrorAwareSampler(t *testing.T) {
+	sampler := NewErrorAwareSampler(sdktrace.NeverSample())
+	traceID := trace.TraceID{1}
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		attrs []attribute.KeyValue
+		want  sdktrace.SamplingDecision
+	}{
+		{name: "defers to the delegate", ctx: context.Background(), want: sdktrace.Drop},
+		{name: "samples spans started as errors", ctx: context.Background(), attrs: []attribute.KeyValue{attribute.Bool("error", true)}, want: sdktrace.RecordAndSample},
+		{name: "ignores error=false", ctx: context.Background(), attrs: []attribute.KeyValue{attribute.Bool("error", false)}, want: sdktrace.Drop},
+		{name: "samples a forced priority attribute", ctx: context.Background(), attrs: []attribute.KeyValue{attribute.Int(ForceSampleKey, 1)}, want: sdktrace.RecordAndSample},
+		{name: "ignores a priority in baggage", ctx: withBaggage(t, "1"), want: sdktrace.Drop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sampler.ShouldSample(sdktrace.SamplingParameters{
+				ParentContext: tt.ctx,
+				TraceID:       traceID,
+				Name:          "test",
+				Attributes:    tt.attrs,
+			})
+
+			if result.Decision != tt.want {
+				t.Errorf("expected decision %v, got %v", tt.want, result.Decision)
+			}
+		})
+	}
+
+	t.Run("overrides an unsampled parent for errors", func(t *testing.T) {
+		sampler := NewErrorAwareSampler(sdktrace.ParentBased(sdktrace.AlwaysSample()))
+		parent := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  trace.SpanID{1},
+		}))
+
+		result := sampler.ShouldSample(sdktrace.SamplingParameters{
+			ParentContext: parent,
+			TraceID:       traceID,
+			Name:          "test",
+			Attributes:    []attribute.KeyValue{ErrorAttribute},
+		})
+
+		if result.Decision != sdktrace.RecordAndSample {
+			t.Errorf("expected decision %v, got %v", sdktrace.RecordAndSample, result.Decision)
+		}
+	})
+
+	t.Run("describes the delegate", func(t *testing.T) {
+		if got := sampler.Description(); got != "ErrorAwareSampler{AlwaysOffSampler}" {
+			t.Errorf("unexpected description %q", got)
+		}
+	})
+}
+
+func withBaggage(t *testing.T, value string) context.Context {
+	t.Helper()
+	member, err := baggage.NewMember(ForceSampleKey, value)
+	if err != nil {
+		t.Fatalf("create baggage member: %v", err)
+	}
+	bag, err := baggage.New(member)
+	if err != nil {
+		t.Fatalf("create baggage: %v", err)
+	}
+	return baggage.ContextWithBaggage(context.Background(), bag)
+}
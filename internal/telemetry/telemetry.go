@@ -4,10 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/propagation"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -15,6 +22,9 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
+// DefaultShutdownTimeout bounds Shutdown when the caller's context has no deadline.
+const DefaultShutdownTimeout = 10 * time.Second
+
 var (
 	ErrInvalidConfig         = errors.New("invalid telemetry configuration")
 	ErrMissingServiceName    = errors.New("service name is required")
@@ -30,31 +40,100 @@ type Config struct {
 	EnableTracing  bool
 	EnableMetrics  bool
 	SampleRate     float64
+	// ResourceAttributes are added to every span and metric. Keys in the service.*
+	// namespace are ignored so they cannot override the canonical service identity.
+	ResourceAttributes map[string]string
 }
 
 type Telemetry struct {
-	tracerProvider *sdktrace.TracerProvider
-	meterProvider  *sdkmetric.MeterProvider
-	traceExporter  sdktrace.SpanExporter
-	metricExporter sdkmetric.Exporter
+	tracerProvider  *sdktrace.TracerProvider
+	meterProvider   *sdkmetric.MeterProvider
+	traceExporters  []sdktrace.SpanExporter
+	metricExporters []sdkmetric.Exporter
+	lenientLogger   *slog.Logger
+	failures        *instrumentFailures
+	shutdownTimeout time.Duration
 }
 
 type Option func(*telemetryOptions)
 
 type telemetryOptions struct {
-	traceExporter  sdktrace.SpanExporter
-	metricExporter sdkmetric.Exporter
+	traceExporters  []sdktrace.SpanExporter
+	metricExporters []sdkmetric.Exporter
+	metricReaders   []sdkmetric.Reader
+	batchOptions    []sdktrace.BatchSpanProcessorOption
+	otlpHeaders     map[string]string
+	lenientLogger   *slog.Logger
+	shutdownTimeout time.Duration
+	spanBaggageKeys []string
 }
 
+// WithTraceExporter adds a span exporter, each registered with its own batch span processor.
+// The default OTLP exporter is only created when no exporter is provided.
 func WithTraceExporter(exporter sdktrace.SpanExporter) Option {
 	return func(opts *telemetryOptions) {
-		opts.traceExporter = exporter
+		opts.traceExporters = append(opts.traceExporters, exporter)
 	}
 }
 
+// WithMetricExporter adds a metric exporter, each wrapped in its own periodic reader.
+// The default OTLP exporter is only created when no exporter or reader is provided.
 func WithMetricExporter(exporter sdkmetric.Exporter) Option {
 	return func(opts *telemetryOptions) {
-		opts.metricExporter = exporter
+		opts.metricExporters = append(opts.metricExporters, exporter)
+	}
+}
+
+// WithMetricReader adds a metric reader, such as a manual or pull-based reader.
+func WithMetricReader(reader sdkmetric.Reader) Option {
+	return func(opts *telemetryOptions) {
+		opts.metricReaders = append(opts.metricReaders, reader)
+	}
+}
+
+// WithLenientMetrics makes meters returned by Telemetry.Meter log and skip instruments that
+// fail to register instead of returning an error. Failed instruments are reported by
+// Telemetry.FailedInstruments.
+func WithLenientMetrics(logger *slog.Logger) Option {
+	return func(opts *telemetryOptions) {
+		opts.lenientLogger = logger
+	}
+}
+
+// WithBatchOptions tunes the batch span processor of every trace exporter. Non-positive
+// values keep the SDK defaults.
+func WithBatchOptions(maxQueueSize, maxBatchSize int, timeout time.Duration) Option {
+	return func(opts *telemetryOptions) {
+		if maxQueueSize > 0 {
+			opts.batchOptions = append(opts.batchOptions, sdktrace.WithMaxQueueSize(maxQueueSize))
+		}
+		if maxBatchSize > 0 {
+			opts.batchOptions = append(opts.batchOptions, sdktrace.WithMaxExportBatchSize(maxBatchSize))
+		}
+		if timeout > 0 {
+			opts.batchOptions = append(opts.batchOptions, sdktrace.WithBatchTimeout(timeout))
+		}
+	}
+}
+
+// WithOTLPHeaders sets headers, such as API keys, sent with every OTLP export request.
+func WithOTLPHeaders(headers map[string]string) Option {
+	return func(opts *telemetryOptions) {
+		opts.otlpHeaders = headers
+	}
+}
+
+// WithSpanBaggageKeys copies the named baggage members onto every span as attributes.
+func WithSpanBaggageKeys(keys ...string) Option {
+	return func(opts *telemetryOptions) {
+		opts.spanBaggageKeys = append(opts.spanBaggageKeys, keys...)
+	}
+}
+
+// WithShutdownTimeout overrides DefaultShutdownTimeout.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(opts *telemetryOptions) {
+		opts.shutdownTimeout = timeout
 	}
 }
 
@@ -79,7 +158,7 @@ func Initialize(ctx context.Context, cfg Config, opts ...Option) (*Telemetry, er
 		return nil, err
 	}
 
-	options := &telemetryOptions{}
+	options := &telemetryOptions{shutdownTimeout: DefaultShutdownTimeout}
 	for _, opt := range opts {
 		opt(options)
 	}
@@ -89,29 +168,33 @@ func Initialize(ctx context.Context, cfg Config, opts ...Option) (*Telemetry, er
 		return nil, fmt.Errorf("create resource: %w", err)
 	}
 
-	tel := &Telemetry{}
+	tel := &Telemetry{
+		lenientLogger:   options.lenientLogger,
+		failures:        &instrumentFailures{},
+		shutdownTimeout: options.shutdownTimeout,
+	}
 
 	if cfg.EnableTracing {
-		tp, exp, err := initializeTracing(ctx, res, cfg, options.traceExporter)
+		tp, exps, err := initializeTracing(ctx, res, cfg, options)
 		if err != nil {
 			return nil, fmt.Errorf("initialize tracing: %w", err)
 		}
 		otel.SetTracerProvider(tp)
 		tel.tracerProvider = tp
-		tel.traceExporter = exp
+		tel.traceExporters = exps
 	}
 
 	if cfg.EnableMetrics {
-		mp, exp, err := initializeMetrics(ctx, res, cfg, options.metricExporter)
+		mp, exps, err := initializeMetrics(ctx, res, cfg, options)
 		if err != nil {
-			if tel.traceExporter != nil {
-				_ = tel.traceExporter.Shutdown(ctx)
+			for _, exp := range tel.traceExporters {
+				_ = exp.Shutdown(ctx)
 			}
 			return nil, fmt.Errorf("initialize metrics: %w", err)
 		}
 		otel.SetMeterProvider(mp)
 		tel.meterProvider = mp
-		tel.metricExporter = exp
+		tel.metricExporters = exps
 	}
 
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
@@ -124,6 +207,7 @@ func Initialize(ctx context.Context, cfg Config, opts ...Option) (*Telemetry, er
 
 func createResource(ctx context.Context, cfg Config) (*resource.Resource, error) {
 	return resource.New(ctx,
+		resource.WithAttributes(customResourceAttributes(cfg.ResourceAttributes)...),
 		resource.WithAttributes(
 			semconv.ServiceName(cfg.ServiceName),
 			semconv.ServiceVersion(cfg.ServiceVersion),
@@ -137,13 +221,26 @@ func createResource(ctx context.Context, cfg Config) (*resource.Resource, error)
 	)
 }
 
-func initializeTracing(ctx context.Context, res *resource.Resource, cfg Config, providedExporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, sdktrace.SpanExporter, error) {
-	var exporter sdktrace.SpanExporter
-	var err error
+func customResourceAttributes(attrs map[string]string) []attribute.KeyValue {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		if key == "" || strings.HasPrefix(key, "service.") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	if providedExporter != nil {
-		exporter = providedExporter
-	} else {
+	result := make([]attribute.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, attribute.String(key, attrs[key]))
+	}
+	return result
+}
+
+func initializeTracing(ctx context.Context, res *resource.Resource, cfg Config, options *telemetryOptions) (*sdktrace.TracerProvider, []sdktrace.SpanExporter, error) {
+	exporters := options.traceExporters
+	if len(exporters) == 0 {
 		// NOTE: Using WithInsecure() for plaintext gRPC connection.
 		// This is intentional for this learning/demo project to work with the local
 		// Docker Compose OTLP collector which doesn't have TLS configured.
@@ -151,50 +248,58 @@ func initializeTracing(ctx context.Context, res *resource.Resource, cfg Config,
 		// 1. Remove WithInsecure() to use TLS with system certificates
 		// 2. Use WithTLSCredentials() for custom TLS configuration
 		// 3. Run behind a service mesh (Istio/Linkerd) that handles TLS at the sidecar level
-		exporter, err = otlptracegrpc.New(ctx,
+		exporter, err := otlptracegrpc.New(ctx,
 			otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint),
 			otlptracegrpc.WithInsecure(),
+			otlptracegrpc.WithHeaders(options.otlpHeaders),
 		)
 		if err != nil {
 			return nil, nil, fmt.Errorf("create trace exporter: %w", err)
 		}
+		exporters = []sdktrace.SpanExporter{exporter}
 	}
 
 	sampler := createSampler(cfg.SampleRate)
 
-	tp := sdktrace.NewTracerProvider(
+	tpOpts := []sdktrace.TracerProviderOption{
 		sdktrace.WithResource(res),
 		sdktrace.WithSampler(sampler),
-		sdktrace.WithBatcher(exporter),
-	)
+	}
+	if len(options.spanBaggageKeys) > 0 {
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(NewBaggageSpanProcessor(options.spanBaggageKeys...)))
+	}
+	for _, exporter := range exporters {
+		tpOpts = append(tpOpts, sdktrace.WithBatcher(exporter, options.batchOptions...))
+	}
 
-	return tp, exporter, nil
+	return sdktrace.NewTracerProvider(tpOpts...), exporters, nil
 }
 
-func initializeMetrics(ctx context.Context, res *resource.Resource, cfg Config, providedExporter sdkmetric.Exporter) (*sdkmetric.MeterProvider, sdkmetric.Exporter, error) {
-	var exporter sdkmetric.Exporter
-	var err error
-
-	if providedExporter != nil {
-		exporter = providedExporter
-	} else {
+func initializeMetrics(ctx context.Context, res *resource.Resource, cfg Config, options *telemetryOptions) (*sdkmetric.MeterProvider, []sdkmetric.Exporter, error) {
+	exporters := options.metricExporters
+	if len(exporters) == 0 && len(options.metricReaders) == 0 {
 		// NOTE: Using WithInsecure() for plaintext gRPC connection.
 		// See comment in initializeTracing() for rationale and production alternatives.
-		exporter, err = otlpmetricgrpc.New(ctx,
+		exporter, err := otlpmetricgrpc.New(ctx,
 			otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint),
 			otlpmetricgrpc.WithInsecure(),
+			otlpmetricgrpc.WithHeaders(options.otlpHeaders),
 		)
 		if err != nil {
 			return nil, nil, fmt.Errorf("create metric exporter: %w", err)
 		}
+		exporters = []sdkmetric.Exporter{exporter}
 	}
 
-	mp := sdkmetric.NewMeterProvider(
-		sdkmetric.WithResource(res),
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
-	)
+	mpOpts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+	for _, exporter := range exporters {
+		mpOpts = append(mpOpts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)))
+	}
+	for _, reader := range options.metricReaders {
+		mpOpts = append(mpOpts, sdkmetric.WithReader(reader))
+	}
 
-	return mp, exporter, nil
+	return sdkmetric.NewMeterProvider(mpOpts...), exporters, nil
 }
 
 func createSampler(sampleRate float64) sdktrace.Sampler {
@@ -206,22 +311,37 @@ func createSampler(sampleRate float64) sdktrace.Sampler {
 		return sdktrace.AlwaysSample()
 	}
 
-	return sdktrace.ParentBased(
+	return NewErrorAwareSampler(sdktrace.ParentBased(
 		sdktrace.TraceIDRatioBased(sampleRate),
-	)
+	))
 }
 
+// Shutdown flushes and stops providers and exporters. A context without a deadline is
+// bounded by the configured shutdown timeout so a hung exporter cannot block forever.
 func (t *Telemetry) Shutdown(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := t.shutdownTimeout
+		if timeout <= 0 {
+			timeout = DefaultShutdownTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	var errs []error
 
 	if t.tracerProvider != nil {
+		if err := t.tracerProvider.ForceFlush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("flush tracer provider: %w", err))
+		}
 		if err := t.tracerProvider.Shutdown(ctx); err != nil {
 			errs = append(errs, fmt.Errorf("shutdown tracer provider: %w", err))
 		}
 	}
 
-	if t.traceExporter != nil {
-		if err := t.traceExporter.Shutdown(ctx); err != nil {
+	for _, exporter := range t.traceExporters {
+		if err := exporter.Shutdown(ctx); err != nil {
 			errs = append(errs, fmt.Errorf("shutdown trace exporter: %w", err))
 		}
 	}
@@ -232,8 +352,8 @@ func (t *Telemetry) Shutdown(ctx context.Context) error {
 		}
 	}
 
-	if t.metricExporter != nil {
-		if err := t.metricExporter.Shutdown(ctx); err != nil {
+	for _, exporter := range t.metricExporters {
+		if err := exporter.Shutdown(ctx); err != nil {
 			errs = append(errs, fmt.Errorf("shutdown metric exporter: %w", err))
 		}
 	}
@@ -252,3 +372,22 @@ func (t *Telemetry) TracerProvider() *sdktrace.TracerProvider {
 func (t *Telemetry) MeterProvider() *sdkmetric.MeterProvider {
 	return t.meterProvider
 }
+
+// Meter returns a named meter, or a no-op meter when metrics are disabled.
+func (t *Telemetry) Meter(name string) metric.Meter {
+	var meter metric.Meter = noop.NewMeterProvider().Meter(name)
+	if t.meterProvider != nil {
+		meter = t.meterProvider.Meter(name)
+	}
+
+	if t.lenientLogger != nil {
+		return newLenientMeter(meter, t.lenientLogger, t.failures)
+	}
+
+	return meter
+}
+
+// FailedInstruments returns the names of instruments that lenient meters failed to register.
+func (t *Telemetry) FailedInstruments() []string {
+	return t.failures.list()
+}
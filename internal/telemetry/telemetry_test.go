@@ -3,8 +3,15 @@ package telemetry
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestConfigValidate(t *testing.T) {
@@ -231,6 +238,142 @@ func TestInitialize(t *testing.T) {
 	})
 }
 
+func TestFanOut(t *testing.T) {
+	t.Run("exports spans to every trace exporter", func(t *testing.T) {
+		ctx := context.Background()
+		cfg := testConfig()
+		cfg.EnableTracing = true
+
+		first := tracetest.NewInMemoryExporter()
+		second := tracetest.NewInMemoryExporter()
+
+		tel, err := Initialize(ctx, cfg, WithTraceExporter(first), WithTraceExporter(second))
+		if err != nil {
+			t.Fatalf("failed to initialize telemetry: %v", err)
+		}
+		defer func() { _ = tel.Shutdown(ctx) }()
+
+		_, span := tel.TracerProvider().Tracer("test").Start(ctx, "fan-out")
+		span.End()
+
+		if err := tel.TracerProvider().ForceFlush(ctx); err != nil {
+			t.Fatalf("failed to flush spans: %v", err)
+		}
+
+		if got := len(first.GetSpans()); got != 1 {
+			t.Errorf("expected 1 span in first exporter, got %d", got)
+		}
+		if got := len(second.GetSpans()); got != 1 {
+			t.Errorf("expected 1 span in second exporter, got %d", got)
+		}
+	})
+
+	t.Run("collects metrics from every reader", func(t *testing.T) {
+		ctx := context.Background()
+		cfg := testConfig()
+		cfg.EnableMetrics = true
+
+		first := sdkmetric.NewManualReader()
+		second := sdkmetric.NewManualReader()
+
+		tel, err := Initialize(ctx, cfg, WithMetricReader(first), WithMetricReader(second))
+		if err != nil {
+			t.Fatalf("failed to initialize telemetry: %v", err)
+		}
+		defer func() { _ = tel.Shutdown(ctx) }()
+
+		counter, err := tel.Meter("test").Int64Counter("fan_out_total")
+		if err != nil {
+			t.Fatalf("failed to create counter: %v", err)
+		}
+		counter.Add(ctx, 1)
+
+		for name, reader := range map[string]*sdkmetric.ManualReader{"first": first, "second": second} {
+			var rm metricdata.ResourceMetrics
+			if err := reader.Collect(ctx, &rm); err != nil {
+				t.Fatalf("failed to collect from %s reader: %v", name, err)
+			}
+			if len(rm.ScopeMetrics) != 1 || len(rm.ScopeMetrics[0].Metrics) != 1 {
+				t.Errorf("expected 1 metric in %s reader, got %+v", name, rm.ScopeMetrics)
+			}
+		}
+	})
+}
+
+func TestWithBatchOptions(t *testing.T) {
+	t.Run("applies positive values", func(t *testing.T) {
+		opts := &telemetryOptions{}
+
+		WithBatchOptions(4096, 1024, time.Second)(opts)
+
+		if len(opts.batchOptions) != 3 {
+			t.Errorf("expected 3 batch options, got %d", len(opts.batchOptions))
+		}
+	})
+
+	t.Run("keeps SDK defaults for non-positive values", func(t *testing.T) {
+		opts := &telemetryOptions{}
+
+		WithBatchOptions(0, -1, 0)(opts)
+
+		if len(opts.batchOptions) != 0 {
+			t.Errorf("expected no batch options, got %d", len(opts.batchOptions))
+		}
+	})
+
+	t.Run("initializes tracing with batch options", func(t *testing.T) {
+		ctx := context.Background()
+		cfg := testConfig()
+		cfg.EnableTracing = true
+
+		tel, err := Initialize(ctx, cfg,
+			WithTraceExporter(NewNoopTraceExporter()),
+			WithBatchOptions(16, 8, 10*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatalf("failed to initialize telemetry: %v", err)
+		}
+		if err := tel.Shutdown(ctx); err != nil {
+			t.Errorf("shutdown failed: %v", err)
+		}
+	})
+}
+
+func TestCreateResource(t *testing.T) {
+	t.Run("merges custom attributes without overriding service keys", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.ResourceAttributes = map[string]string{
+			"team":            "orders",
+			"region":          "eu-west-1",
+			"service.name":    "spoofed",
+			"service.version": "9.9.9",
+		}
+
+		res, err := createResource(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("failed to create resource: %v", err)
+		}
+
+		want := map[string]string{
+			"team":            "orders",
+			"region":          "eu-west-1",
+			"service.name":    cfg.ServiceName,
+			"service.version": cfg.ServiceVersion,
+		}
+		set := res.Set()
+		for key, expected := range want {
+			value, ok := set.Value(attribute.Key(key))
+			if !ok {
+				t.Errorf("expected attribute %s to be present", key)
+				continue
+			}
+			if value.AsString() != expected {
+				t.Errorf("expected %s=%s, got %s", key, expected, value.AsString())
+			}
+		}
+	})
+}
+
 func TestCreateSampler(t *testing.T) {
 	t.Run("returns sampler when sample rate is 0.0", func(t *testing.T) {
 		sampler := createSampler(0.0)
@@ -280,7 +423,10 @@ func TestCreateSampler(t *testing.T) {
 		sampler := createSampler(0.5)
 
 		if sampler == nil {
-			t.Error("expected sampler, got nil")
+			t.Fatal("expected sampler, got nil")
+		}
+		if !strings.HasPrefix(sampler.Description(), "ErrorAwareSampler{") {
+			t.Errorf("expected ErrorAwareSampler, got %s", sampler.Description())
 		}
 	})
 }
@@ -337,6 +483,45 @@ func TestShutdown(t *testing.T) {
 	})
 }
 
+func TestShutdownTimeout(t *testing.T) {
+	t.Run("applies default deadline when context has none", func(t *testing.T) {
+		tel := &Telemetry{
+			traceExporters:  []sdktrace.SpanExporter{&blockingTraceExporter{}},
+			shutdownTimeout: 50 * time.Millisecond,
+		}
+
+		start := time.Now()
+		err := tel.Shutdown(context.Background())
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected shutdown to give up quickly, took %v", elapsed)
+		}
+	})
+
+	t.Run("honors caller deadline", func(t *testing.T) {
+		tel := &Telemetry{
+			traceExporters:  []sdktrace.SpanExporter{&blockingTraceExporter{}},
+			shutdownTimeout: time.Hour,
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := tel.Shutdown(ctx)
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected shutdown to honor caller deadline, took %v", elapsed)
+		}
+	})
+}
+
 func TestGetterMethods(t *testing.T) {
 	t.Run("TracerProvider returns nil when tracing not enabled", func(t *testing.T) {
 		tel := &Telemetry{}
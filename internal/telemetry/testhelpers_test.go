@@ -112,3 +112,16 @@ func setupTracerProvider(t *testing.T) (*tracetest.InMemoryExporter, func()) {
 
 	return exp, cleanup
 }
+
+// blockingTraceExporter simulates a hung exporter that only returns once its context ends.
+type blockingTraceExporter struct{}
+
+func (b *blockingTraceExporter) ExportSpans(ctx context.Context, _ []trace.ReadOnlySpan) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *blockingTraceExporter) Shutdown(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
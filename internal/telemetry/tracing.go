@@ -11,9 +11,26 @@ import (
 
 const tracerName = "github.com/dejobratic/tbd/internal/telemetry"
 
+// Tracer starts spans under one instrumentation scope, conventionally the import path of
+// the instrumented package, so tracing backends can filter spans by component.
+type Tracer struct {
+	scope string
+}
+
+// NewTracer returns a Tracer for scope. The global tracer provider is resolved on every
+// span, so tracers can be created before telemetry is set up.
+func NewTracer(scope string) Tracer {
+	return Tracer{scope: scope}
+}
+
+func (t Tracer) StartSpan(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(t.scope).Start(ctx, spanName, opts...)
+}
+
+// StartSpan starts a span under this package's scope. Instrumented packages should prefer
+// their own Tracer.
 func StartSpan(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
-	tracer := otel.Tracer(tracerName)
-	return tracer.Start(ctx, spanName, opts...)
+	return NewTracer(tracerName).StartSpan(ctx, spanName, opts...)
 }
 
 func AddSpanAttributes(span trace.Span, attrs ...attribute.KeyValue) {
@@ -45,6 +62,15 @@ func SetSpanSuccess(span trace.Span) {
 	span.SetStatus(codes.Ok, "")
 }
 
+// RecordErrorSpan records err on a short span started with ErrorAttribute. A request span
+// is sampled before any failure is known; this span is sampled whatever the sample rate,
+// so the failure is traced even when its request was not.
+func (t Tracer) RecordErrorSpan(ctx context.Context, spanName string, err error) {
+	_, span := t.StartSpan(ctx, spanName, trace.WithAttributes(ErrorAttribute))
+	RecordSpanError(span, err)
+	span.End()
+}
+
 func TraceID(ctx context.Context) string {
 	spanCtx := trace.SpanContextFromContext(ctx)
 	if spanCtx.HasTraceID() {
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"testing"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestStartSpan(t *testing.T) {
@@ -209,6 +212,29 @@ func TestAddSpanEvent(t *testing.T) {
 	})
 }
 
+func TestTracer(t *testing.T) {
+	t.Run("starts spans under its scope", func(t *testing.T) {
+		exp, cleanup := setupTracerProvider(t)
+		defer cleanup()
+
+		_, span := NewTracer("github.com/dejobratic/tbd/internal/example").StartSpan(context.Background(), "op")
+		span.End()
+		_, span = StartSpan(context.Background(), "default")
+		span.End()
+
+		spans := exp.GetSpans()
+		if len(spans) != 2 {
+			t.Fatalf("expected 2 spans, got %d", len(spans))
+		}
+		if got := spans[0].InstrumentationScope.Name; got != "github.com/dejobratic/tbd/internal/example" {
+			t.Errorf("expected the tracer's scope, got %q", got)
+		}
+		if got := spans[1].InstrumentationScope.Name; got != tracerName {
+			t.Errorf("expected the default scope %q, got %q", tracerName, got)
+		}
+	})
+}
+
 func TestRecordSpanError(t *testing.T) {
 	t.Run("records error and sets error status", func(t *testing.T) {
 		exp, cleanup := setupTracerProvider(t)
@@ -420,3 +446,28 @@ func TestSpanID(t *testing.T) {
 		span1.End()
 	})
 }
+
+func TestRecordErrorSpan(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(NewErrorAwareSampler(sdktrace.ParentBased(sdktrace.NeverSample()))),
+		sdktrace.WithSyncer(exp),
+	)
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(nil)
+
+	ctx, request := StartSpan(context.Background(), "request")
+	NewTracer(tracerName).RecordErrorSpan(ctx, "request.error", errors.New("boom"))
+	request.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected only the error span to be sampled, got %d spans", len(spans))
+	}
+	if spans[0].Name != "request.error" || spans[0].Status.Code != codes.Error {
+		t.Errorf("expected failed span request.error, got %s with status %v", spans[0].Name, spans[0].Status.Code)
+	}
+	if spans[0].Parent.TraceID() != request.SpanContext().TraceID() {
+		t.Error("expected the error span to join the request's trace")
+	}
+}
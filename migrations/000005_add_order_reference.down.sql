@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_reference;
+ALTER TABLE orders DROP COLUMN IF EXISTS reference;
+DROP SEQUENCE IF EXISTS order_reference_seq;
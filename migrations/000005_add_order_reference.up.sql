@@ -0,0 +1,19 @@
+-- Sequence backing human-readable order references such as ORD-2024-000123
+CREATE SEQUENCE IF NOT EXISTS order_reference_seq;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS reference TEXT;
+
+-- Backfill references for existing orders in creation order
+UPDATE orders o
+SET reference = 'ORD-' || to_char(n.created_at AT TIME ZONE 'UTC', 'YYYY') || '-' || lpad(n.seq::text, 6, '0')
+FROM (
+    SELECT id, created_at, row_number() OVER (ORDER BY created_at, id) AS seq
+    FROM orders
+    WHERE reference IS NULL
+) n
+WHERE o.id = n.id;
+
+SELECT setval('order_reference_seq', (SELECT count(*) FROM orders) + 1, false);
+
+-- Unique index for lookups by reference
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_reference ON orders(reference);
@@ -0,0 +1,2 @@
+-- Client-supplied key/value metadata attached to an order
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
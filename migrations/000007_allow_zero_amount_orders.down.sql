@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_amount_cents_check;
+ALTER TABLE orders ADD CONSTRAINT orders_amount_cents_check CHECK (amount_cents > 0);
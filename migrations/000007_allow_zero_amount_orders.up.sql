@@ -0,0 +1,3 @@
+-- Zero-amount orders are allowed when the API enables them; negatives stay invalid
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_amount_cents_check;
+ALTER TABLE orders ADD CONSTRAINT orders_amount_cents_check CHECK (amount_cents >= 0);
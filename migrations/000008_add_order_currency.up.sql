@@ -0,0 +1,2 @@
+-- ISO 4217 currency of amount_cents; existing orders were all placed in USD
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_customer_id_created_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS customer_id;
@@ -0,0 +1,9 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS customer_id TEXT;
+
+-- Orders placed before customer IDs existed are attributed to their lower-cased email
+UPDATE orders SET customer_id = lower(customer_email) WHERE customer_id IS NULL;
+
+ALTER TABLE orders ALTER COLUMN customer_id SET NOT NULL;
+
+-- Index for listing a customer's orders newest first
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id_created_at ON orders(customer_id, created_at DESC);
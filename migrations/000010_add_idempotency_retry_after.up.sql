@@ -0,0 +1,2 @@
+-- Processing estimate replayed as Retry-After with stored 202 responses
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS retry_after_ms BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Idempotency key of the create request that produced the order; NULL when none was sent
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
@@ -0,0 +1,2 @@
+-- Supports listing orders changed since a timestamp (?updated_after=)
+CREATE INDEX IF NOT EXISTS idx_orders_updated_at ON orders(updated_at);